@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/alert"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/power"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+)
+
+// alertItems lists the problems of a trend that require a crash dump and
+// a notification as alert items
+func alertItems(problems []trend.Problem) []alert.Item {
+	var items []alert.Item
+	for _, problem := range problems {
+		severity := alert.Warning
+		if problem.Critical {
+			severity = alert.Critical
+		}
+		items = append(items, alert.Item{Severity: severity, Source: problem.Source, Message: problem.Message})
+	}
+	return items
+}
+
+// powerItems lists the discharging batteries that are low or projected to
+// run out soon as alert items
+func powerItems(p *power.PowerStats) []alert.Item {
+	if p == nil {
+		return nil
+	}
+
+	var items []alert.Item
+	add := func(severity alert.Severity, format string, args ...any) {
+		items = append(items, alert.Item{Severity: severity, Source: "power", Message: fmt.Sprintf(format, args...)})
+	}
+	for _, name := range sortedKeys(p.Supplies) {
+		supply := p.Supplies[name]
+		if supply.Low(powerThresholds) {
+			add(alert.Critical, "Low battery %s: %d%% remaining", name, supply.Capacity)
+		}
+		if tte := supply.TimeToEmpty(); tte > 0 {
+			switch {
+			case tte <= powerThresholds.EmptyCritical:
+				add(alert.Critical, "Battery %s projected to run out in %s at %.1f W", name, tte.Round(time.Minute), supply.Draw())
+			case tte <= powerThresholds.EmptyWarning:
+				add(alert.Warning, "Battery %s projected to run out in %s at %.1f W", name, tte.Round(time.Minute), supply.Draw())
+			}
+		}
+	}
+	return items
+}
+
+// raiseAlert groups items into an alert and, unless there are none, logs
+// it, creates a crash dump capturing the surrounding state and notifies the
+// items not held back by the -alert-cooldown
+func (m *monitor) raiseAlert(systemStress float64, stats *parser.SystemStats, items []alert.Item) {
+	a := alert.Group(time.Now(), m.hostname, systemStress, items)
+	if a == nil {
+		return
+	}
+
+	m.log.Warnf("Detected conditions requiring crash dump:")
+	for _, item := range a.Items {
+		m.log.Warnf("- %s", item)
+	}
+
+	// Force crash dump creation
+	crashFile := saveCrashDump(m.analyzer, m.log)
+	if crashFile != "" {
+		m.log.Warnf("Successfully created crash dump: %s", crashFile)
+		m.summary.Update(stats, m.power, &stats.Temperature, crashFile)
+	} else {
+		m.log.Errorf("Failed to create crash dump!")
+	}
+	a.CrashFile = crashFile
+	m.recordEvent(alert.AlertEvent(a))
+
+	notified := alert.Group(a.Time, a.Host, a.Stress, m.throttle.Filter(a.Time, a.Items))
+	if notified == nil {
+		m.log.Infof("Alert notification held back, all conditions were notified within their cool-down")
+		return
+	}
+	notified.CrashFile = crashFile
+	m.notify(notified)
+}
+
+// recordEvent appends an event to the -event-log file, if any
+func (m *monitor) recordEvent(e alert.Event) {
+	if m.events == nil {
+		return
+	}
+	if err := m.events.Record(e); err != nil {
+		m.log.Errorf("Failed to record %s event: %v", e.Type, err)
+	}
+}
+
+// recordCrash records a recovered panic and its crash dump in the event log
+func (m *monitor) recordCrash(r any, crashFile string) {
+	m.recordEvent(alert.Event{
+		Time:      time.Now(),
+		Type:      alert.EventCrash,
+		Severity:  alert.Critical,
+		Value:     m.summary.SystemStress,
+		CrashFile: crashFile,
+		Message:   fmt.Sprint(r),
+	})
+}
+
+// checkCriticalTemperature raises an alert with a crash dump the moment a
+// sensor reaches -temp-critical. It runs on every raw sample, so neither the
+// calibration nor the evaluation interval delays it. A sensor staying above
+// the limit triggers again only after cooling below it.
+func (m *monitor) checkCriticalTemperature(stats *parser.SystemStats) {
+	if *tempCritical <= 0 {
+		return
+	}
+
+	var items []alert.Item
+	overheated := make(map[string]bool)
+	for _, name := range stats.Temperature.Above(*tempCritical) {
+		overheated[name] = true
+		if !m.overheated[name] {
+			items = append(items, alert.Item{
+				Severity: alert.Critical,
+				Source:   "temperature",
+				Message:  fmt.Sprintf("Sensor %s reached critical temperature: %.1f°C (limit: %.1f°C)", name, stats.Temperature.Sensors[name], *tempCritical),
+			})
+		}
+	}
+	m.overheated = overheated
+
+	if len(items) > 0 && !m.silence.Silenced(time.Now()) {
+		m.raiseAlert(m.summary.SystemStress, stats, items)
+	}
+}
+
+// notify delivers the alert to every configured notifier in the background,
+// so a slow endpoint never delays sampling
+func (m *monitor) notify(a *alert.Alert) {
+	for _, notifier := range m.notifiers {
+		go func(notifier alert.Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), *interval)
+			defer cancel()
+			if err := notifier.Notify(ctx, a); err != nil {
+				m.log.Warnf("Failed to send alert: %v", err)
+			}
+		}(notifier)
+	}
+}
+
+// newNotifiers creates the notifiers configured on the command line
+func newNotifiers() []alert.Notifier {
+	var notifiers []alert.Notifier
+	if *webhook != "" {
+		notifiers = append(notifiers, alert.NewWebhook(*webhook, *interval))
+	}
+	if *slackWebhook != "" {
+		notifiers = append(notifiers, alert.NewSlack(*slackWebhook, *interval))
+	}
+	return notifiers
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
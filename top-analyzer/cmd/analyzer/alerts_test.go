@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/alert"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/power"
+	"github.com/sirupsen/logrus"
+)
+
+// recordingNotifier sends every alert it is notified of to alerts
+type recordingNotifier struct {
+	alerts chan *alert.Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, a *alert.Alert) error {
+	n.alerts <- a
+	return nil
+}
+
+func TestDiskFullGroupedAlert(t *testing.T) {
+	setFlag(t, calibration, 0)
+	setFlag(t, stressCrash, 30)
+	// Projections to full are left out, the disk is full already
+	setFlag(t, &fsThresholds, filesystem.Thresholds{
+		CriticalFreePercent: 10,
+		FullWarning:         time.Nanosecond,
+		FullCritical:        time.Nanosecond,
+		AnomalyDeadband:     1,
+	})
+
+	root := func(usedPct float64) parser.SystemStats {
+		return parser.SystemStats{Filesystem: map[string]parser.FilesystemStats{
+			"/": {Device: "/dev/sda1", Size: 50 << 30, UsedPct: usedPct, MountPoint: "/", Critical: usedPct > 90},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		samples     []parser.SystemStats
+		wantAlert   bool
+		wantSources []string
+	}{
+		{
+			name:        "disk filled up",
+			samples:     []parser.SystemStats{root(40), root(40), root(40), root(40), root(98)},
+			wantAlert:   true,
+			wantSources: []string{"stress", "filesystem", "filesystem"},
+		},
+		{name: "disk steady", samples: []parser.SystemStats{root(40), root(40), root(40), root(40), root(40)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(t, &mockProvider{samples: tt.samples})
+			notifier := &recordingNotifier{alerts: make(chan *alert.Alert, len(tt.samples))}
+			m.notifiers = []alert.Notifier{notifier}
+			for range tt.samples {
+				m.sample()
+			}
+
+			var alerts []*alert.Alert
+			// Notifications are sent in the background
+			timeout := time.After(200 * time.Millisecond)
+		collect:
+			for {
+				select {
+				case a := <-notifier.alerts:
+					alerts = append(alerts, a)
+				case <-timeout:
+					break collect
+				}
+			}
+
+			if !tt.wantAlert {
+				if len(alerts) > 0 {
+					t.Errorf("notified %d alerts, want none: %v", len(alerts), alerts[0].Items)
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("notified %d alerts, want 1 grouped alert", len(alerts))
+			}
+			a := alerts[0]
+			if a.Severity != alert.Critical {
+				t.Errorf("alert severity = %s, want critical", a.Severity)
+			}
+			var sources []string
+			for _, item := range a.Items {
+				sources = append(sources, item.Source)
+			}
+			if !slices.Equal(sources, tt.wantSources) {
+				t.Errorf("alert items = %v, want sources %q", a.Items, tt.wantSources)
+			}
+		})
+	}
+}
+
+func TestPowerItems(t *testing.T) {
+	setFlag(t, &powerThresholds, power.DefaultThresholds)
+
+	tests := []struct {
+		name      string
+		battery   map[string]string // BAT0 attribute -> content
+		wantItems []alert.Item
+	}{
+		{
+			// 2.5 Wh left at 30 W
+			name:    "rapidly discharging battery",
+			battery: map[string]string{"status": "Discharging", "capacity": "8", "energy_now": "2500000", "power_now": "30000000"},
+			wantItems: []alert.Item{
+				{Severity: alert.Critical, Source: "power", Message: "Low battery BAT0: 8% remaining"},
+				{Severity: alert.Critical, Source: "power", Message: "Battery BAT0 projected to run out in 5m0s at 30.0 W"},
+			},
+		},
+		{
+			// 10 Wh left at 30 W
+			name:      "runtime within the warning horizon",
+			battery:   map[string]string{"status": "Discharging", "capacity": "35", "energy_now": "10000000", "power_now": "30000000"},
+			wantItems: []alert.Item{{Severity: alert.Warning, Source: "power", Message: "Battery BAT0 projected to run out in 20m0s at 30.0 W"}},
+		},
+		{
+			// Reported negative by some drivers, 3 Wh at 1.8 A and 10 V
+			name:      "negative current while discharging",
+			battery:   map[string]string{"status": "Discharging", "capacity": "30", "charge_now": "300000", "current_now": "-1800000", "voltage_now": "10000000"},
+			wantItems: []alert.Item{{Severity: alert.Critical, Source: "power", Message: "Battery BAT0 projected to run out in 10m0s at 18.0 W"}},
+		},
+		{
+			name:    "low battery charging",
+			battery: map[string]string{"status": "Charging", "capacity": "5", "energy_now": "2500000", "power_now": "30000000"},
+		},
+		{
+			name:    "full battery discharging slowly",
+			battery: map[string]string{"status": "Discharging", "capacity": "95", "energy_now": "50000000", "power_now": "5000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "BAT0")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{"type": power.TypeBattery, "present": "1"}
+			maps.Copy(files, tt.battery)
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stats, err := power.ReadPowerStatsFrom(filepath.Dir(dir))
+			if err != nil {
+				t.Fatalf("ReadPowerStatsFrom() error = %v", err)
+			}
+			if got := powerItems(stats); !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("powerItems() = %+v, want %+v", got, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestCriticalTemperatureCrashDump(t *testing.T) {
+	setFlag(t, tempCritical, 90.0)
+	// Still calibrating, so only the hard limit can trigger a dump
+	setFlag(t, calibration, 10)
+
+	reading := func(temp float64) collection {
+		stats := &parser.SystemStats{}
+		stats.Temperature.Sensors = map[string]float64{"cpu_thermal": temp}
+		return collection{stats: stats}
+	}
+
+	tests := []struct {
+		name      string
+		cooldown  string
+		readings  []float64
+		wantDumps []bool // Whether each sample forced a crash dump
+	}{
+		{
+			name:      "dump once per crossing",
+			readings:  []float64{80, 95, 96, 85, 91},
+			wantDumps: []bool{false, true, false, false, true},
+		},
+		{
+			name:      "dump within the alert cooldown",
+			cooldown:  "1h",
+			readings:  []float64{95, 85, 91},
+			wantDumps: []bool{true, false, true},
+		},
+		{
+			name:      "below the limit",
+			readings:  []float64{80, 89.9, 85},
+			wantDumps: []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var collections []collection
+			for _, temp := range tt.readings {
+				collections = append(collections, reading(temp))
+			}
+			m := newTestMonitor(t, &scriptedProvider{collections: collections})
+			cooldowns, err := alert.ParseCooldowns(tt.cooldown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.throttle = alert.NewThrottle(cooldowns)
+
+			for i, temp := range tt.readings {
+				m.summary.LastCrashFile = ""
+				m.sample()
+				if got := m.summary.LastCrashFile != ""; got != tt.wantDumps[i] {
+					t.Errorf("sample %d at %v°C forced a crash dump = %v, want %v", i, temp, got, tt.wantDumps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQuietKeepsAlerts(t *testing.T) {
+	setFlag(t, tempCritical, 90.0)
+
+	tests := []struct {
+		name       string
+		quiet      bool
+		quietLog   bool
+		wantStdout bool
+		wantLogged bool // Stats block in the log
+	}{
+		{name: "quiet", quiet: true, wantLogged: true},
+		{name: "quiet with quiet log", quiet: true, quietLog: true},
+		{name: "not quiet", wantStdout: true, wantLogged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, crashDir, t.TempDir())
+			setFlag(t, quiet, tt.quiet)
+			setFlag(t, quietLog, tt.quietLog)
+			setFlag(t, tui, false)
+
+			// The monitor prints to the stdout it is created with
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			setFlag(t, &os.Stdout, w)
+
+			var logged bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&logged)
+
+			stats := parser.SystemStats{}
+			stats.Temperature.Sensors = map[string]float64{"cpu_thermal": 95}
+			m := newMonitor(&mockProvider{samples: []parser.SystemStats{stats}}, log, &silencer{})
+			notifier := &recordingNotifier{alerts: make(chan *alert.Alert, 1)}
+			m.notifiers = []alert.Notifier{notifier}
+			m.sample()
+
+			w.Close()
+			printed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(printed) > 0; got != tt.wantStdout {
+				t.Errorf("printed to stdout = %v, want %v: %q", got, tt.wantStdout, printed)
+			}
+			if got := strings.Contains(logged.String(), "=== System Stats at"); got != tt.wantLogged {
+				t.Errorf("stats block logged = %v, want %v", got, tt.wantLogged)
+			}
+
+			select {
+			case a := <-notifier.alerts:
+				if a.Severity != alert.Critical {
+					t.Errorf("alert severity = %s, want critical", a.Severity)
+				}
+			case <-time.After(time.Second):
+				t.Error("no alert notified")
+			}
+		})
+	}
+}
+
+func TestEventLogLines(t *testing.T) {
+	setFlag(t, tempCritical, 90.0)
+
+	hot := parser.SystemStats{}
+	hot.Temperature.Sensors = map[string]float64{"cpu_thermal": 95}
+
+	tests := []struct {
+		name     string
+		provider StatsProvider
+		wantType string
+	}{
+		{name: "anomaly", provider: &mockProvider{samples: []parser.SystemStats{hot}}, wantType: alert.EventAlert},
+		{name: "crash", provider: &panickingProvider{panics: map[int]bool{0: true}}, wantType: alert.EventCrash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.log")
+			m := newTestMonitor(t, tt.provider)
+			m.events = alert.NewEventLog(path)
+			m.safeSample()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("event log not written: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("event log has %d lines, want 1:\n%s", len(lines), data)
+			}
+
+			var event map[string]any
+			if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+				t.Fatalf("event line isn't JSON: %v", err)
+			}
+			if event["type"] != tt.wantType || event["severity"] != "critical" {
+				t.Errorf("event = %v, want a critical %s", event, tt.wantType)
+			}
+			for _, field := range []string{"time", "value", "crash_file"} {
+				if _, ok := event[field]; !ok {
+					t.Errorf("event = %v, want a %s field", event, field)
+				}
+			}
+		})
+	}
+}
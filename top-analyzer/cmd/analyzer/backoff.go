@@ -0,0 +1,20 @@
+package main
+
+import "time"
+
+// backoffDelay returns how long to wait before the next collection attempt
+// after the given number of consecutive failures: the interval doubled for
+// every failure, capped at max. A max of zero disables the backoff.
+func backoffDelay(interval, max time.Duration, failures int) time.Duration {
+	if max <= 0 || failures <= 0 {
+		return interval
+	}
+	delay := interval
+	for i := 0; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{name: "no failure", max: time.Minute, failures: 0, want: 5 * time.Second},
+		{name: "first failure", max: time.Minute, failures: 1, want: 10 * time.Second},
+		{name: "third failure", max: time.Minute, failures: 3, want: 40 * time.Second},
+		{name: "capped", max: time.Minute, failures: 4, want: time.Minute},
+		{name: "long capped", max: time.Minute, failures: 100, want: time.Minute},
+		{name: "disabled", max: 0, failures: 5, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffDelay(5*time.Second, tt.max, tt.failures); got != tt.want {
+				t.Errorf("backoffDelay(5s, %v, %d) = %v, want %v", tt.max, tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleBacksOff(t *testing.T) {
+	setFlag(t, interval, 5*time.Second)
+	setFlag(t, backoffMax, time.Minute)
+	failure := collection{err: &CollectionError{Subsystem: subsystemTop, Err: errors.New("top not found")}}
+	success := collection{stats: &parser.SystemStats{CPU: parser.CPU{User: 10}}}
+
+	m := newTestMonitor(t, &scriptedProvider{collections: []collection{failure, failure, failure, success, failure}})
+	tests := []struct {
+		name      string
+		wantDelay time.Duration // Until the next attempt, 0 when not delayed
+	}{
+		{name: "first failure", wantDelay: 10 * time.Second},
+		{name: "second failure", wantDelay: 20 * time.Second},
+		{name: "third failure", wantDelay: 40 * time.Second},
+		{name: "success resets", wantDelay: 0},
+		{name: "failure after recovery", wantDelay: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The retry delay has passed
+			m.nextAttempt = time.Time{}
+			start := time.Now()
+			m.sample()
+
+			var delay time.Duration
+			if !m.nextAttempt.IsZero() {
+				delay = m.nextAttempt.Sub(start)
+			}
+			if delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Errorf("next attempt in %v, want %v", delay, tt.wantDelay)
+			}
+		})
+	}
+
+	// A sample before the next attempt is skipped
+	m.sample()
+	if m.failures != 1 {
+		t.Errorf("consecutive failures = %d after a sample within the backoff, want 1", m.failures)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+func TestCalibrationSuppressesAlerts(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration int
+		wantDumps   []bool // Whether each sample wrote a crash dump
+	}{
+		// The trend needs two samples
+		{name: "no calibration", calibration: 0, wantDumps: []bool{false, true}},
+		{name: "one evaluation", calibration: 1, wantDumps: []bool{false, true}},
+		{name: "three evaluations", calibration: 3, wantDumps: []bool{false, false, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, calibration, tt.calibration)
+			var collections []collection
+			for range tt.wantDumps {
+				hot := &parser.SystemStats{Temperature: temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 95}}}
+				collections = append(collections, collection{stats: hot})
+			}
+			m := newTestMonitor(t, &scriptedProvider{collections: collections})
+			var log bytes.Buffer
+			m.log.SetOutput(&log)
+
+			for i, want := range tt.wantDumps {
+				before := crashDumps(t)
+				m.sample()
+				if got := crashDumps(t) > before; got != want {
+					t.Errorf("sample %d wrote a crash dump = %v, want %v", i+1, got, want)
+				}
+			}
+
+			if got := strings.Contains(log.String(), "Calibration complete"); got != (tt.calibration > 0) {
+				t.Errorf("calibration complete logged = %v, want %v", got, tt.calibration > 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// runCheck validates that the commands the monitor depends on can be found
+// and reports the result to w. It returns the process exit code.
+func runCheck(w io.Writer) int {
+	commands := []struct {
+		flag string
+		path string
+	}{
+		{"-top-path", *topPath},
+		{"-df-path", *dfPath},
+	}
+
+	exitCode := 0
+	for _, c := range commands {
+		resolved, err := exec.LookPath(c.path)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL %s: %v\n", c.flag, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintf(w, "OK   %s: %s\n", c.flag, resolved)
+	}
+
+	return exitCode
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	top, _ := fakeCommand(t, dir, "top", "")
+	df, _ := fakeCommand(t, dir, "df", "")
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name         string
+		topPath      string
+		dfPath       string
+		wantExitCode int
+		wantLines    []string
+	}{
+		{name: "both found", topPath: top, dfPath: df, wantExitCode: 0, wantLines: []string{"OK   -top-path: " + top, "OK   -df-path: " + df}},
+		{name: "top missing", topPath: missing, dfPath: df, wantExitCode: 1, wantLines: []string{"FAIL -top-path:", "OK   -df-path: " + df}},
+		{name: "df missing", topPath: top, dfPath: missing, wantExitCode: 1, wantLines: []string{"OK   -top-path: " + top, "FAIL -df-path:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, topPath, tt.topPath)
+			setFlag(t, dfPath, tt.dfPath)
+
+			var out bytes.Buffer
+			if got := runCheck(&out); got != tt.wantExitCode {
+				t.Errorf("runCheck() = %d, want %d", got, tt.wantExitCode)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("output = %q, want %d lines", out.String(), len(tt.wantLines))
+			}
+			for i, want := range tt.wantLines {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("line %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "flag"
+
+// secretFlags may hold URLs embedding credentials; the recorded
+// configuration only tells whether they are set
+var secretFlags = map[string]bool{
+	"webhook":       true,
+	"slack-webhook": true,
+	"otlp-endpoint": true,
+	"peers":         true,
+}
+
+// effectiveConfig returns the value of every option of fs, defaults
+// included, with the secret ones redacted
+func effectiveConfig(fs *flag.FlagSet) map[string]string {
+	config := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" {
+			value = "<redacted>"
+		}
+		config[f.Name] = value
+	})
+	return config
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestEvalInterval(t *testing.T) {
+	tests := []struct {
+		name         string
+		evalInterval time.Duration
+		wantHistory  []int   // Length of the analyzer history after each sample
+		wantCPUUser  float64 // Of the latest history entry
+	}{
+		{name: "every sample evaluated", evalInterval: 0, wantHistory: []int{1, 2, 3, 4}, wantCPUUser: 40},
+		{name: "eval interval equal to the sample interval", evalInterval: 5 * time.Second, wantHistory: []int{1, 2, 3, 4}, wantCPUUser: 40},
+		{name: "two samples per evaluation", evalInterval: 10 * time.Second, wantHistory: []int{0, 1, 1, 2}, wantCPUUser: 35},
+		{name: "four samples per evaluation", evalInterval: 20 * time.Second, wantHistory: []int{0, 0, 0, 1}, wantCPUUser: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, interval, 5*time.Second)
+			setFlag(t, evalInterval, tt.evalInterval)
+			var collections []collection
+			for _, user := range []float64{10, 20, 30, 40} {
+				collections = append(collections, collection{stats: &parser.SystemStats{CPU: parser.CPU{User: user}}})
+			}
+			m := newTestMonitor(t, &scriptedProvider{collections: collections})
+
+			var history []int
+			for range tt.wantHistory {
+				m.sample()
+				history = append(history, len(m.analyzer.GetHistory()))
+			}
+
+			if !reflect.DeepEqual(history, tt.wantHistory) {
+				t.Errorf("history lengths = %v, want %v", history, tt.wantHistory)
+			}
+			if got := m.analyzer.GetCurrentStats().CPU.User; got != tt.wantCPUUser {
+				t.Errorf("latest CPU user = %v, want %v", got, tt.wantCPUUser)
+			}
+		})
+	}
+}
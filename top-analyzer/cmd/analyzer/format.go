@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+)
+
+// Output formats of the per-interval stats
+const (
+	formatText = "text"
+	formatFlat = "flat"
+)
+
+// Units memory is displayed in. MB and GB follow -byte-units, so they are
+// shown as MiB and GiB in binary; GiB is always binary.
+const (
+	memUnitMB   = "MB"
+	memUnitGB   = "GB"
+	memUnitGiB  = "GiB"
+	memUnitAuto = "auto"
+)
+
+// validateMemUnit checks the value of -mem-unit
+func validateMemUnit(unit string) error {
+	switch unit {
+	case memUnitMB, memUnitGB, memUnitGiB, memUnitAuto:
+		return nil
+	default:
+		return fmt.Errorf("invalid -mem-unit %q: must be %s, %s, %s or %s", unit, memUnitMB, memUnitGB, memUnitGiB, memUnitAuto)
+	}
+}
+
+// formatMemory formats bytes in unit. auto uses MB below 1 GB and GB from
+// there on.
+func formatMemory[T int64 | uint64](bytes T, unit string) string {
+	system := bytesize.Current()
+	if unit == memUnitGiB {
+		system, unit = bytesize.Binary, memUnitGB
+	}
+	if unit == memUnitAuto {
+		unit = memUnitMB
+		if float64(bytes) >= system.Size(bytesize.Giga) {
+			unit = memUnitGB
+		}
+	}
+
+	switch unit {
+	case memUnitGB:
+		return fmt.Sprintf("%.1f %s", system.In(float64(bytes), bytesize.Giga), system.Unit(bytesize.Giga))
+	default:
+		return fmt.Sprintf("%.0f %s", system.In(float64(bytes), bytesize.Mega), system.Unit(bytesize.Mega))
+	}
+}
+
+// validateFormat checks the value of -format
+func validateFormat(format string) error {
+	switch format {
+	case formatText, formatFlat:
+		return nil
+	default:
+		return fmt.Errorf("invalid -format %q: must be %s or %s", format, formatText, formatFlat)
+	}
+}
+
+// writeFlat writes the flattened trend to w as sorted key=value lines
+func writeFlat(w io.Writer, t *trend.Trend) {
+	flat := t.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=%g\n", key, flat[key])
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
+)
+
+func TestFormatMemory(t *testing.T) {
+	const (
+		mib = int64(1) << 20
+		mb  = int64(1000 * 1000)
+	)
+
+	tests := []struct {
+		name   string
+		system bytesize.System
+		bytes  int64
+		unit   string
+		want   string
+	}{
+		{name: "2048MB under auto", system: bytesize.Decimal, bytes: 2048 * mb, unit: memUnitAuto, want: "2.0 GB"},
+		{name: "2048MiB under auto", system: bytesize.Binary, bytes: 2048 * mib, unit: memUnitAuto, want: "2.0 GiB"},
+		{name: "512MB under auto", system: bytesize.Decimal, bytes: 512 * mb, unit: memUnitAuto, want: "512 MB"},
+		{name: "2048MB under MB", system: bytesize.Decimal, bytes: 2048 * mb, unit: memUnitMB, want: "2048 MB"},
+		{name: "2048MiB under GB", system: bytesize.Binary, bytes: 2048 * mib, unit: memUnitGB, want: "2.0 GiB"},
+		{name: "1.5GiB under GiB with decimal units", system: bytesize.Decimal, bytes: 1536 * mib, unit: memUnitGiB, want: "1.5 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := bytesize.Current()
+			bytesize.SetSystem(tt.system)
+			t.Cleanup(func() { bytesize.SetSystem(old) })
+
+			if got := formatMemory(tt.bytes, tt.unit); got != tt.want {
+				t.Errorf("formatMemory(%d, %s) = %q, want %q", tt.bytes, tt.unit, got, tt.want)
+			}
+			if got := formatMemory(uint64(tt.bytes), tt.unit); got != tt.want {
+				t.Errorf("formatMemory(uint64(%d), %s) = %q, want %q", tt.bytes, tt.unit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMemUnit(t *testing.T) {
+	tests := []struct {
+		unit    string
+		wantErr bool
+	}{
+		{unit: memUnitMB},
+		{unit: memUnitGB},
+		{unit: memUnitGiB},
+		{unit: memUnitAuto},
+		{unit: "TB", wantErr: true},
+		{unit: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			if err := validateMemUnit(tt.unit); (err != nil) != tt.wantErr {
+				t.Errorf("validateMemUnit(%q) error = %v, wantErr %v", tt.unit, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestWriteHeartbeat(t *testing.T) {
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		file  string
+		times []time.Time
+		want  string
+	}{
+		{name: "single write", file: "heartbeat", times: []time.Time{first}, want: "2024-03-01T12:00:00Z\n"},
+		{name: "advances", file: "heartbeat", times: []time.Time{first, first.Add(5 * time.Second)}, want: "2024-03-01T12:00:05Z\n"},
+		{name: "missing directory", file: "run/analyzer/heartbeat", times: []time.Time{first}, want: "2024-03-01T12:00:00Z\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, tt.file)
+			for _, now := range tt.times {
+				if err := writeHeartbeat(filename, now); err != nil {
+					t.Fatalf("writeHeartbeat() error = %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("heartbeat = %q, want %q", data, tt.want)
+			}
+			entries, err := os.ReadDir(filepath.Dir(filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory holds %d files, want only the heartbeat", len(entries))
+			}
+		})
+	}
+}
+
+// failingProvider fails every collection after the first ok ones
+type failingProvider struct {
+	ok    int
+	calls int
+}
+
+func (p *failingProvider) Collect(ctx context.Context) (*parser.SystemStats, error) {
+	p.calls++
+	if p.calls > p.ok {
+		return nil, errors.New("top not found")
+	}
+	return &parser.SystemStats{}, nil
+}
+
+func TestSampleHeartbeat(t *testing.T) {
+	tests := []struct {
+		name         string
+		ok           int
+		wantAdvanced bool
+	}{
+		{name: "two successful samples", ok: 2, wantAdvanced: true},
+		{name: "second sample failed", ok: 1, wantAdvanced: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "heartbeat")
+			setFlag(t, heartbeatFile, filename)
+			m := newTestMonitor(t, &failingProvider{ok: tt.ok})
+
+			m.sample()
+			first, err := os.Stat(filename)
+			if err != nil {
+				t.Fatalf("no heartbeat after the first sample: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			m.sample()
+			second, err := os.Stat(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := second.ModTime().After(first.ModTime()); got != tt.wantAdvanced {
+				t.Errorf("heartbeat advanced = %v, want %v", got, tt.wantAdvanced)
+			}
+		})
+	}
+}
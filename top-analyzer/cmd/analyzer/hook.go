@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// crashFilePlaceholder is replaced by the crash dump path in the -on-crash
+// command
+const crashFilePlaceholder = "{file}"
+
+// crashFileEnv is the environment variable holding the crash dump path for
+// the -on-crash command
+const crashFileEnv = "TOP_ANALYZER_CRASH_FILE"
+
+// crashHookArgs splits the -on-crash command template into arguments, with
+// every {file} replaced by the crash dump path, or the path appended as the
+// last argument when the template has no placeholder
+func crashHookArgs(template, crashFile string) []string {
+	args := strings.Fields(template)
+	placed := false
+	for i, arg := range args {
+		if strings.Contains(arg, crashFilePlaceholder) {
+			args[i] = strings.ReplaceAll(arg, crashFilePlaceholder, crashFile)
+			placed = true
+		}
+	}
+	if !placed && len(args) > 0 {
+		args = append(args, crashFile)
+	}
+	return args
+}
+
+// runCrashHook runs the command template for the crash dump, with its path
+// also in the environment, and returns the combined output. The command is
+// killed when ctx is done.
+func runCrashHook(ctx context.Context, template, crashFile string) ([]byte, error) {
+	args := crashHookArgs(template, crashFile)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty crash hook command")
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), crashFileEnv+"="+crashFile)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return output, fmt.Errorf("crash hook did not finish in time: %w", ctx.Err())
+	}
+	if err != nil {
+		return output, fmt.Errorf("crash hook failed: %w", err)
+	}
+	return output, nil
+}
+
+// startCrashHook runs the -on-crash command for a new crash dump in the
+// background, so a slow script never delays sampling, and logs its output
+func startCrashHook(crashFile string, log *logrus.Logger) {
+	if *onCrash == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), *onCrashTimeout)
+		defer cancel()
+		output, err := runCrashHook(ctx, *onCrash, crashFile)
+		if len(output) > 0 {
+			log.Infof("Crash hook output for %s:\n%s", crashFile, strings.TrimRight(string(output), "\n"))
+		}
+		if err != nil {
+			log.Warnf("Failed to run crash hook for %s: %v", crashFile, err)
+		}
+	}()
+}
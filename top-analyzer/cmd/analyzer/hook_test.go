@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrashHookArgs(t *testing.T) {
+	const crashFile = "/var/lib/top-analyzer/crash_20261015.json"
+
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{name: "path appended", template: "/usr/local/bin/collect-dmesg", want: []string{"/usr/local/bin/collect-dmesg", crashFile}},
+		{name: "placeholder", template: "/usr/local/bin/collect-dmesg {file} --quiet", want: []string{"/usr/local/bin/collect-dmesg", crashFile, "--quiet"}},
+		{name: "placeholder within an argument", template: "upload --file={file}", want: []string{"upload", "--file=" + crashFile}},
+		{name: "empty", template: "  ", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crashHookArgs(tt.template, crashFile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("crashHookArgs(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCrashHook(t *testing.T) {
+	dir := t.TempDir()
+	crashFile := filepath.Join(dir, "crash_20261015.json")
+
+	// envHook prints the crash file path it finds in the environment
+	envHook := filepath.Join(dir, "env-hook")
+	if err := os.WriteFile(envHook, []byte("#!/bin/sh\necho \"$"+crashFileEnv+"\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// slowHook is replaced by sleep, so that it is killed along with it
+	slowHook := filepath.Join(dir, "slow-hook")
+	if err := os.WriteFile(slowHook, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	argsHook, argsFile := fakeCommand(t, dir, "args-hook", "hook ran\n")
+
+	tests := []struct {
+		name       string
+		template   string
+		timeout    time.Duration
+		wantArgs   []string // Recorded by the args hook
+		wantOutput string
+		wantErr    string
+	}{
+		{name: "path as argument", template: argsHook, timeout: 5 * time.Second, wantArgs: []string{crashFile}, wantOutput: "hook ran\n"},
+		{name: "placeholder", template: argsHook + " --dump {file}", timeout: 5 * time.Second, wantArgs: []string{"--dump", crashFile}, wantOutput: "hook ran\n"},
+		{name: "path in the environment", template: envHook, timeout: 5 * time.Second, wantOutput: crashFile + "\n"},
+		{name: "command failed", template: "false", timeout: 5 * time.Second, wantErr: "crash hook failed"},
+		{name: "deadline exceeded", template: slowHook, timeout: 100 * time.Millisecond, wantErr: "did not finish in time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(argsFile)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			output, err := runCrashHook(ctx, tt.template, crashFile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runCrashHook() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runCrashHook() error = %v", err)
+			}
+			if string(output) != tt.wantOutput {
+				t.Errorf("runCrashHook() output = %q, want %q", output, tt.wantOutput)
+			}
+			if tt.wantArgs != nil {
+				if got := readArgs(t, argsFile); !reflect.DeepEqual(got, tt.wantArgs) {
+					t.Errorf("hook args = %q, want %q", got, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
@@ -6,36 +6,234 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
-	"os/exec"
-
+	"github.com/parth2601/monchecker/top-analyzer/pkg/alert"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/power"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/rotate"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sqlite"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
 	"github.com/sirupsen/logrus"
 )
 
+// dedupStrategy is the validated value of -dedup
+var dedupStrategy parser.DedupStrategy
+
+// anomalyMethodValue is the validated value of -anomaly-method
+var anomalyMethodValue trend.AnomalyMethod
+
+// processThresholds are the -high-cpu and -high-mem thresholds
+var processThresholds parser.ProcessThresholds
+
+// memThresholds are the -min-free-mem-percent and -min-free-mem thresholds
+var memThresholds parser.MemoryThresholds
+
+// fsThresholds are the -critical-free-percent, -min-partition-size and
+// -full-*-horizon thresholds
+var fsThresholds filesystem.Thresholds
+
+// powerThresholds are the -low-battery and -empty-*-horizon thresholds
+var powerThresholds power.Thresholds
+
+// sensorFilter is built from -sensors-include and -sensors-exclude
+var sensorFilter temperature.Filter
+
+// overallTempValue is the parsed -overall-temp
+var overallTempValue temperature.Overall
+
+// summaryOutputValues are the parsed -summary-outputs
+var summaryOutputValues []summary.Output
+
+// alertCooldowns are the parsed -alert-cooldown
+var alertCooldowns alert.Cooldowns
+
 var (
 	interval         = flag.Duration("interval", 5*time.Second, "Interval between top command executions")
+	evalInterval     = flag.Duration("eval-interval", 0, "Interval between analyses and alert evaluations; the samples collected in between are averaged (0 evaluates every sample)")
 	history          = flag.Int("history", 10, "Number of samples to keep in history")
+	tempWindow       = flag.Int("temp-window", 0, "Number of samples to keep per temperature sensor (0 uses -history)")
 	logFile          = flag.String("log", "top-analyzer.log", "Path to log file")
+	logMaxSize       = flag.Int64("log-max-size", 10, "Size in MB after which the log file is rotated (0 disables rotation)")
+	logMaxBackups    = flag.Int("log-max-backups", 3, "Number of rotated log files to keep")
+	eventLog         = flag.String("event-log", "", "File each alert and crash is appended to as one JSON line with its time, type, severity, system stress and crash dump (empty disables)")
 	snapshotDir      = flag.String("snapshot-dir", "snapshots", "Directory for snapshots")
 	crashDir         = flag.String("crash-dir", "crashes", "Directory for crash dumps")
 	summaryDir       = flag.String("summary-dir", "summary", "Directory for summary files")
+	summaryOutputs   = flag.String("summary-outputs", "latest.json", "Comma separated files the summary is written to each save cycle, relative to -summary-dir, with an optional format: json, csv or jsonl (default from the extension), e.g. latest.json,share.csv,ship.log=jsonl")
+	skipUnchanged    = flag.Bool("skip-unchanged-summary", false, "Skip writing the summary file when nothing but timestamps changed since the last write, sparing flash storage")
 	snapshotPeriod   = flag.Duration("snapshot-period", 1*time.Hour, "Period between snapshots")
+	note             = flag.String("note", "", "Free-text operator note stored in the snapshots and crash dumps of this run")
 	anomalyThreshold = flag.Float64("anomaly-threshold", 2, "Z-score threshold for anomaly detection (higher = less sensitive)")
-	trendThreshold   = flag.Float64("trend-threshold", 0.1, "Trend slope threshold for anomaly detection")
+	anomalyMethod    = flag.String("anomaly-method", string(trend.AnomalyZScore), "Anomaly detection method: zscore, or percentile (above -anomaly-percentile of the window, better on skewed metrics)")
+	anomalyPct       = flag.Float64("anomaly-percentile", trend.DefaultAnomalyPercentile, "Percentile of the window above which a value is an anomaly with -anomaly-method=percentile")
+	trendThreshold   = flag.Float64("trend-threshold", trend.DefaultTrendThreshold, "Trend slope threshold for anomaly detection, in units per second whatever the -interval")
 	tempThreshold    = flag.Float64("temp-threshold", 70, "Absolute temperature threshold in °C")
+	tempCritical     = flag.Float64("temp-critical", 0, "Temperature in °C at which a sensor immediately triggers a crash dump and critical alert, even while calibrating or between evaluations (0 disables)")
+	suppressWarmup   = flag.Bool("suppress-warmup", false, "Don't report temperature trend anomalies while the temperature rises along with the CPU usage, e.g. after a CPU-heavy task starts (threshold breaches are still reported)")
+	calibration      = flag.Int("calibration-samples", 10, "Evaluations run after start to establish baselines before alerts and crash dumps can fire; each is a single sample unless -eval-interval is set")
+	stressCrash      = flag.Float64("stress-crash-threshold", trend.DefaultStressThreshold, "System stress at or above which a crash dump is created")
 	longTermWindow   = flag.Int("long-term-window", 100, "Number of samples to keep in long-term history")
+	longTermDuration = flag.Duration("long-term-duration", 0, "Period of long-term temperature history to keep, e.g. 24h, sized from the evaluation interval so it doesn't change with -interval (0 uses -long-term-window)")
+	persistentBase   = flag.Bool("persistent-baseline", false, "Detect anomalies against a long-term baseline persisted across restarts")
+	stateFile        = flag.String("state-file", "analyzer-state.json", "Path to the analyzer state file used by -persistent-baseline")
+	dfPath           = flag.String("df-path", "df", "Path to the df command")
+	topPath          = flag.String("top-path", "top", "Path to the top command")
+	topArgs          = flag.String("top-args", "-b -n 1", "Arguments passed to the top command")
+	source           = flag.String("source", sourceTop, "Source of the samples: top, or mock to replay -scenario")
+	scenario         = flag.String("scenario", "", "JSON scenario file replayed by -source=mock")
+	backoffMax       = flag.Duration("backoff-max", 5*time.Minute, "Maximum delay between collection attempts while they keep failing (0 disables backoff)")
+	sampleTimeout    = flag.Duration("sample-timeout", 10*time.Second, "Maximum time the top and df commands may take per sample")
+	shutdownTimeout  = flag.Duration("shutdown-timeout", 10*time.Second, "Maximum time saving state may take on shutdown before exiting anyway (0 waits indefinitely)")
+	listenAddr       = flag.String("listen", "", "Address to serve the REST API on, e.g. :8080 (disabled when empty)")
+	pprofAddr        = flag.String("pprof-addr", "", "Address to serve the analyzer's own pprof profiles on under /debug/pprof/, e.g. localhost:6060 (disabled when empty)")
+	webhook          = flag.String("webhook", "", "URL the grouped alert of each evaluation is posted to as JSON")
+	slackWebhook     = flag.String("slack-webhook", "", "Slack incoming webhook URL alerts are posted to")
+	alertCooldown    = flag.String("alert-cooldown", "", "Minimum time between two notifications of the same source (e.g. stress, filesystem) while its condition persists, with optional overrides by source, e.g. 10m,filesystem=1h (empty notifies every evaluation)")
+	onCrash          = flag.String("on-crash", "", "Command run in the background whenever a crash dump is created, with {file} replaced by the dump path (appended when absent) and the path also in TOP_ANALYZER_CRASH_FILE, e.g. \"/usr/local/bin/collect-dmesg {file}\"")
+	onCrashTimeout   = flag.Duration("on-crash-timeout", 30*time.Second, "Time after which the -on-crash command is killed")
+	sqlitePath       = flag.String("sqlite", "", "SQLite database every sample is stored in for on-device historical queries (requires building with -tags sqlite)")
+	otlpEndpoint     = flag.String("otlp-endpoint", "", "OpenTelemetry collector base URL (e.g. http://collector:4318) metrics are pushed to over OTLP/HTTP every interval")
+	peers            = flag.String("peers", "", "Comma separated base URLs of peers whose /stats are merged into /fleet")
+	precision        = flag.Int("precision", 2, "Decimals stored percentages are rounded to in summaries and snapshots (-1 keeps full precision)")
+	heartbeatFile    = flag.String("heartbeat-file", "", "File updated with the current time after every successful sample")
+	check            = flag.Bool("check", false, "Validate the configuration and required commands, then exit")
+	listSensors      = flag.Bool("list-sensors", false, "Print the discovered temperature sensors with their value and source path, then exit")
+	validateSnap     = flag.String("validate-snapshot", "", "Check the schema version and structural integrity of this snapshot file, then exit with 0 when it is valid or 1 otherwise")
+	format           = flag.String("format", formatText, "Output format of the per-interval stats: text or flat (key=value trend pairs)")
+	memUnit          = flag.String("mem-unit", memUnitMB, "Unit memory is displayed in: MB, GB, GiB or auto (MB below 1 GB, GB above)")
+	byteUnits        = flag.String("byte-units", string(bytesize.Binary), "How byte counts are printed: binary (MiB, GiB, powers of 1024) or decimal (MB, GB, powers of 1000)")
+	tui              = flag.Bool("tui", false, "Redraw a compact colored dashboard each interval instead of printing the stats block")
+	quiet            = flag.Bool("quiet", false, "Print nothing to the console each interval, e.g. under systemd; alerts, logs and summaries are unaffected")
+	quietLog         = flag.Bool("quiet-log", false, "Leave the per-interval stats block out of the log file; warnings and alerts are still logged")
+	once             = flag.Bool("once", false, "Take a single sample, then exit with a code reflecting the health band")
+	explain          = flag.Bool("explain", false, "Print the factors the system stress is made of under the stats block")
+	dedup            = flag.String("dedup", "command", "Process deduplication strategy: none, command, base (command before ':', e.g. postgres) or pid")
+	highCPU          = flag.Float64("high-cpu", parser.DefaultProcessThresholds.CPUPercent, "CPU percentage above which a process counts as a high CPU process")
+	highMem          = flag.Float64("high-mem", parser.DefaultProcessThresholds.MemoryPercent, "Memory percentage above which a process counts as a high memory process")
+	excludeSelf      = flag.Bool("exclude-self", false, "Leave the analyzer's own process out of the high CPU and high memory counts and the top processes")
+	minFreeMemPct    = flag.Float64("min-free-mem-percent", parser.DefaultMemoryThresholds.MinFreePercent, "Free memory percentage below which a low memory alert is raised (0 disables)")
+	minFreeMem       = flag.Int64("min-free-mem", 0, "Free memory in bytes below which a low memory alert is raised, whatever the percentage (0 disables)")
+	watch            = flag.String("watch", "", "Comma separated command substrings of processes to watch; a missing one raises a critical alert")
+	watchCritical    = flag.String("watch-critical", "", "Comma separated command substrings of critical processes, watched like -watch; one exiting triggers a crash dump and alert immediately, even while calibrating")
+	expectedProcs    = flag.String("expected-processes", "", "Comma separated command substrings of the processes expected on this machine; a process matching none of them and using more than -high-cpu raises a warning (empty disables)")
+	stuckSamples     = flag.Int("stuck-samples", 5, "Consecutive samples in uninterruptible sleep after which a process is reported as stuck (0 disables)")
+	overallTemp      = flag.String("overall-temp", string(temperature.OverallMax), "How sensors are combined into the overall temperature the stress score is based on: max (hottest sensor) or average (mean of the sensor averages)")
+	sensorsInclude   = flag.String("sensors-include", "", "Comma separated glob patterns of the sensors to track, e.g. cpu*,coretemp-*/Core * (empty tracks all)")
+	sensorsExclude   = flag.String("sensors-exclude", "", "Comma separated glob patterns of sensors to ignore, e.g. pmic*,gpu*")
+	lostSamples      = flag.Int("lost-sensor-samples", 3, "Consecutive samples a previously seen sensor may be missing before it is reported as lost (0 disables)")
+	selfMemLimit     = flag.Int("self-mem-limit", 0, "Warn when the analyzer's own resident memory exceeds this many MB (0 disables)")
+	selfTrim         = flag.Bool("self-trim", false, "Trim the analyzer history when -self-mem-limit is exceeded")
+	silenceUntil     = flag.String("silence-until", "", "Silence alerts and crash dumps until this RFC3339 timestamp")
+	silenceWindow    = flag.String("silence-window", "", "Comma separated daily maintenance windows (HH:MM-HH:MM) during which alerts are silenced")
+	criticalFree     = flag.Float64("critical-free-percent", filesystem.DefaultThresholds.CriticalFreePercent, "Free space percentage below which a partition is critical")
+	minPartSize      = flag.Int64("min-partition-size", 0, "Partitions smaller than this many bytes are excluded from critical and low space evaluation")
+	fullWarning      = flag.Duration("full-warning-horizon", filesystem.DefaultThresholds.FullWarning, "Warn when a partition is projected to fill up within this duration (0 disables)")
+	fullCritical     = flag.Duration("full-critical-horizon", filesystem.DefaultThresholds.FullCritical, "Raise a critical alert when a partition is projected to fill up within this duration (0 disables)")
+	criticalParts    = flag.String("critical-partitions", "", "Comma separated mount points whose low space adds more system stress, with an optional weight multiplying the stress of an ordinary partition, e.g. /data=2,/boot=1.5 (default weight 2, unlisted partitions weigh 1; empty keeps the built-in stress of / and /boot)")
+	fsDeadband       = flag.Float64("fs-deadband", filesystem.DefaultThresholds.AnomalyDeadband, "Change of a partition's free space percentage over the window, in percentage points, below which it is never reported as anomalous (0 disables)")
+	lowBattery       = flag.Int("low-battery", power.DefaultThresholds.LowCapacity, "Battery capacity percentage below which a discharging battery raises a critical alert (0 disables)")
+	emptyWarning     = flag.Duration("empty-warning-horizon", power.DefaultThresholds.EmptyWarning, "Warn when a discharging battery is projected to run out within this duration (0 disables)")
+	emptyCritical    = flag.Duration("empty-critical-horizon", power.DefaultThresholds.EmptyCritical, "Raise a critical alert when a discharging battery is projected to run out within this duration (0 disables)")
 )
 
 func main() {
 	flag.Parse()
 
+	var err error
+	if dedupStrategy, err = parser.ParseDedupStrategy(*dedup); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if anomalyMethodValue, err = trend.ParseAnomalyMethod(*anomalyMethod); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if overallTempValue, err = temperature.ParseOverall(*overallTemp); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if sensorFilter, err = temperature.ParseFilter(*sensorsInclude, *sensorsExclude); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if summaryOutputValues, err = summary.ParseOutputs(*summaryOutputs, *summaryDir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if alertCooldowns, err = alert.ParseCooldowns(*alertCooldown); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	byteSystem, err := bytesize.ParseSystem(*byteUnits)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	bytesize.SetSystem(byteSystem)
+
+	processThresholds = parser.ProcessThresholds{CPUPercent: *highCPU, MemoryPercent: *highMem}
+	if *excludeSelf {
+		processThresholds.ExcludePID = os.Getpid()
+	}
+	memThresholds = parser.MemoryThresholds{MinFreePercent: *minFreeMemPct, MinFreeBytes: *minFreeMem}
+	partitionWeights, err := filesystem.ParsePartitionWeights(*criticalParts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fsThresholds = filesystem.Thresholds{
+		CriticalFreePercent: *criticalFree,
+		MinSize:             *minPartSize,
+		FullWarning:         *fullWarning,
+		FullCritical:        *fullCritical,
+		AnomalyDeadband:     *fsDeadband,
+		Weights:             partitionWeights,
+	}
+	powerThresholds = power.Thresholds{
+		LowCapacity:   *lowBattery,
+		EmptyWarning:  *emptyWarning,
+		EmptyCritical: *emptyCritical,
+	}
+
+	if err := validateSource(*source, *scenario); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := validateFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := validateMemUnit(*memUnit); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	instance.SetConfig(effectiveConfig(flag.CommandLine))
+
+	silence, err := newSilencer(*silenceUntil, *silenceWindow)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *check {
+		os.Exit(runCheck(os.Stdout))
+	}
+	if *listSensors {
+		os.Exit(runListSensors(os.Stdout))
+	}
+	if *validateSnap != "" {
+		os.Exit(runValidateSnapshot(os.Stdout, *validateSnap))
+	}
+
 	// Create directories
 	os.MkdirAll(*snapshotDir, 0755)
 	os.MkdirAll(*crashDir, 0755)
@@ -43,15 +241,35 @@ func main() {
 
 	// Setup logger
 	log := logrus.New()
-	file, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := rotate.Open(*logFile, *logMaxSize*1024*1024, *logMaxBackups)
 	if err == nil {
 		log.SetOutput(file)
 	}
 	log.SetLevel(logrus.InfoLevel)
 
 	// Initialize analyzer with configurable anomaly threshold
-	analyzer := trend.NewWithFullOptions(*history, *anomalyThreshold, *trendThreshold, *tempThreshold, *longTermWindow)
-	s := summary.New()
+	var provider StatsProvider = newTopProvider(*topPath, strings.Fields(*topArgs), *dfPath, log)
+	if *source == sourceMock {
+		mock, err := loadScenario(*scenario)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		provider = mock
+	}
+	m := newMonitor(provider, log, silence)
+	if *sqlitePath != "" {
+		if m.sink, err = sqlite.Open(*sqlitePath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer m.sink.Close()
+	}
+	if *once {
+		os.Exit(runOnce(m))
+	}
+	startAPI(m.api, log)
+	startPprof(log)
 
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -61,14 +279,13 @@ func main() {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Errorf("Panic occurred: %v", r)
-			saveCrashDump(analyzer, log)
+			m.recordCrash(r, saveCrashDump(m.analyzer, log))
 		}
 	}()
 
 	// Create tickers
 	statsTicker := time.NewTicker(*interval)
 	snapshotTicker := time.NewTicker(*snapshotPeriod)
-	lastSummarySave := time.Now()
 	defer statsTicker.Stop()
 	defer snapshotTicker.Stop()
 
@@ -76,217 +293,37 @@ func main() {
 	for {
 		select {
 		case <-statsTicker.C:
-			// Read system stats
-			cmd := exec.Command("top", "-b", "-n", "1")
-			output, err := cmd.Output()
-			if err != nil {
-				log.Printf("Failed to run top command: %v", err)
-				continue
-			}
-
-			// Debug logging for raw top output
-			log.Debugf("Raw top output:\n%s", string(output))
-
-			stats, err := parser.ParseTopOutput(output)
-			if err != nil {
-				log.Printf("Failed to parse top output: %v", err)
-				continue
-			}
-
-			// Debug logging for CPU and memory stats
-			log.Debugf("Raw CPU stats - User: %.1f%%, Sys: %.1f%%, Idle: %.1f%%",
-				stats.CPU.User, stats.CPU.Sys, stats.CPU.Idle)
-
-			// Calculate memory percentage safely
-			memUsedPct := 0.0
-			if stats.Memory.Total > 0 {
-				memUsedPct = float64(stats.Memory.Used) / float64(stats.Memory.Total) * 100
-			}
-			log.Debugf("Raw Memory stats - Total: %d MB, Used: %d MB, Free: %d MB, Used%%: %.1f%%",
-				stats.Memory.Total/1024/1024, stats.Memory.Used/1024/1024, stats.Memory.Free/1024/1024, memUsedPct)
-
-			// Read temperature stats
-			tempStats, err := temperature.ReadTemperatureStats()
-			if err != nil {
-				log.Warnf("Failed to read temperature stats: %v", err)
-				// Initialize empty temperature stats structure to avoid null in logs
-				tempStats = &temperature.TemperatureStats{
-					Sensors: make(map[string]float64),
-				}
-
-				// Try to set default values for known sensors for testing
-				if os.Getenv("MOCK_TEMP") == "1" {
-					tempStats.Sensors["cpu"] = 45.0
-					tempStats.Sensors["board"] = 40.0
-				}
-			}
-
-			// Read filesystem stats
-			fsStats, err := filesystem.ReadFilesystemStats()
-			if err != nil {
-				log.Warnf("Failed to read filesystem stats: %v", err)
-				fsStats = &filesystem.FilesystemStats{
-					Filesystems: make(map[string]filesystem.Filesystem),
-				}
-			}
-
-			// Convert filesystem stats to parser format
-			stats.Filesystem = make(map[string]parser.FilesystemStats)
-			for mountPoint, fs := range fsStats.Filesystems {
-				stats.Filesystem[mountPoint] = parser.FilesystemStats{
-					Device:     fs.Device,
-					Size:       fs.Size,
-					Used:       fs.Used,
-					Available:  fs.Available,
-					UsedPct:    fs.UsedPct,
-					MountPoint: fs.MountPoint,
-					Critical:   fs.Critical,
-				}
-			}
-
-			// Debug info to track sensors detected
-			if len(tempStats.Sensors) > 0 {
-				log.Infof("Temperature sensors detected: %v", tempStats.String())
-			} else {
-				log.Warnf("No temperature sensors detected")
-			}
-
-			// Debug info for filesystem stats
-			if len(fsStats.Filesystems) > 0 {
-				log.Infof("Filesystem stats: %v", fsStats.String())
-			} else {
-				log.Warnf("No filesystem stats detected")
-			}
-
-			// Update analyzer and summary
-			analyzer.AddStats(stats)
-			s.Update(stats, nil, tempStats, "")
-
-			// Analyze trends
-			trend := analyzer.Analyze()
-			if trend != nil {
-				// Check for conditions that should trigger a crash dump
-				if trend.SystemStress >= 85 ||
-					trend.CPUUsage.Anomaly ||
-					trend.ProcessCount.Anomaly ||
-					trend.Temperature.Anomaly ||
-					trend.MemoryUsage.Anomaly ||
-					trend.Temperature.ThresholdExceeded ||
-					trend.Filesystem.Critical ||
-					trend.Filesystem.Anomaly {
-
-					log.Warnf("Detected conditions requiring crash dump:")
-					if trend.SystemStress >= 85 {
-						log.Warnf("- High system stress: %.1f%%", trend.SystemStress)
-					}
-					if trend.CPUUsage.Anomaly {
-						log.Warnf("- CPU anomaly detected: %.1f%% (threshold: %.1f)", trend.CPUUsage.Mean, trend.CPUUsage.StdDev*(*anomalyThreshold))
-					}
-					if trend.MemoryUsage.Anomaly {
-						log.Warnf("- Memory anomaly detected: %.1f%% (threshold: %.1f)", trend.MemoryUsage.Mean, trend.MemoryUsage.StdDev*(*anomalyThreshold))
-					}
-					if trend.Temperature.Anomaly {
-						log.Warnf("- Temperature anomaly detected: %.1f°C (threshold: %.1f)", trend.Temperature.Mean, trend.Temperature.StdDev*(*anomalyThreshold))
-					}
-					if trend.Temperature.ThresholdExceeded {
-						log.Warnf("- Temperature threshold exceeded: %.1f°C (threshold: %.1f°C)", trend.Temperature.Max, *tempThreshold)
-					}
-					if trend.ProcessCount.Anomaly {
-						log.Warnf("- Process count anomaly detected: %.1f (threshold: %.1f)", trend.ProcessCount.Mean, trend.ProcessCount.StdDev*(*anomalyThreshold))
-					}
-
-					// Log filesystem issues
-					if trend.Filesystem.Critical {
-						log.Warnf("- CRITICAL: Low disk space detected on one or more partitions!")
-						for mount, fs := range trend.Filesystem.Partitions {
-							if fs.Critical {
-								log.Warnf("  * %s: Only %.1f%% free space remaining (%.2f GB)",
-									mount, fs.Current, fs.Current*float64(stats.Filesystem[mount].Size)/100.0/1024.0/1024.0/1024.0)
-							}
-						}
-					} else if trend.Filesystem.Anomaly {
-						log.Warnf("- Filesystem anomaly detected:")
-						for mount, fs := range trend.Filesystem.Partitions {
-							if fs.Anomaly {
-								if fs.Trend < 0 {
-									log.Warnf("  * %s: Abnormal decrease in free space (trend: %.2f%%/sample)",
-										mount, fs.Trend)
-								} else {
-									log.Warnf("  * %s: Abnormal change in free space (current: %.1f%%, mean: %.1f%%)",
-										mount, fs.Current, fs.Mean)
-								}
-							}
-						}
-					}
-
-					// Force crash dump creation
-					crashFile := saveCrashDump(analyzer, log)
-					if crashFile != "" {
-						log.Warnf("Successfully created crash dump: %s", crashFile)
-						s.Update(stats, nil, tempStats, crashFile)
-					} else {
-						log.Errorf("Failed to create crash dump!")
-					}
-				}
-			}
-
-			// Log current stats
-			statsStr := fmt.Sprintf("=== System Stats at %s ===\n"+
-				"CPU: %.1f%% user, %.1f%% system, %.1f%% idle\n"+
-				"Memory: %.1f%% used (Total: %d MB, Used: %d MB, Free: %d MB)\n"+
-				"Load: %.2f (1min), %.2f (5min), %.2f (15min)\n"+
-				"System Stress: %.1f%%\n"+
-				"Process States:\n"+
-				"S: %d\n"+
-				"R: %d\n"+
-				"D: %d\n"+
-				"Z: %d\n"+
-				"Temperature:\n%s"+
-				"Filesystem:\n%s"+
-				"High Memory Usage Processes (>5%%):\n%s"+
-				"Total CPU Usage: %.1f%%\n"+
-				"Total Memory Usage: %.1f%%\n"+
-				"=============================\n",
-				s.Timestamp.Format(time.RFC3339),
-				s.CPU.User, s.CPU.System, s.CPU.Idle,
-				memUsedPct, s.Memory.Total/1024/1024, s.Memory.Used/1024/1024, s.Memory.Free/1024/1024,
-				stats.LoadAverage.One, stats.LoadAverage.Five, stats.LoadAverage.Fifteen,
-				s.SystemStress,
-				s.Processes.Sleeping, s.Processes.Running, s.Processes.Uninterr, s.Processes.Zombie,
-				getTemperatureInfo(s),
-				getFilesystemInfo(stats),
-				getHighMemoryProcesses(stats),
-				s.CPU.User+s.CPU.System,
-				memUsedPct)
-
-			// Log to both console and file
-			fmt.Print(statsStr)
-			log.Print(statsStr)
-
-			// Save summary every minute
-			if time.Since(lastSummarySave) >= time.Minute {
-				if err := s.Save(filepath.Join(*summaryDir, "latest.json")); err != nil {
-					log.Printf("Failed to save summary: %v", err)
-				}
-				lastSummarySave = time.Now()
-			}
+			m.safeSample()
+			m.checkSelfMemory()
 
 		case <-snapshotTicker.C:
 			// Save periodic snapshot
-			filename := filepath.Join(*snapshotDir, fmt.Sprintf("snapshot-%s.json", time.Now().Format("2006-01-02-15-04-05")))
-			if err := analyzer.SaveSnapshot(filename); err != nil {
-				log.Errorf("Failed to save snapshot: %v", err)
-			} else {
-				log.Infof("Saved snapshot to %s", filename)
-			}
+			saveSnapshot(m.analyzer, *note, log)
+
+		case req := <-m.api.snapshots:
+			// Save snapshot requested over the REST API
+			req.done <- saveSnapshot(m.analyzer, req.note, log)
 
 		case sig := <-sigChan:
 			log.Infof("Received signal %v, shutting down...", sig)
+			m.shutdown()
 			return
 		}
 	}
 }
 
+// saveSnapshot saves a snapshot annotated with note and returns its filename,
+// or an empty string when it could not be saved
+func saveSnapshot(t *trend.TrendAnalyzer, note string, log *logrus.Logger) string {
+	filename := filepath.Join(*snapshotDir, fmt.Sprintf("snapshot-%s.json", time.Now().Format("2006-01-02-15-04-05")))
+	if err := t.SaveSnapshotWithNote(filename, note); err != nil {
+		log.Errorf("Failed to save snapshot: %v", err)
+		return ""
+	}
+	log.Infof("Saved snapshot to %s", filename)
+	return filename
+}
+
 func saveCrashDump(t *trend.TrendAnalyzer, log *logrus.Logger) string {
 	// Create crash directory if it doesn't exist
 	if err := os.MkdirAll(*crashDir, 0755); err != nil {
@@ -299,7 +336,7 @@ func saveCrashDump(t *trend.TrendAnalyzer, log *logrus.Logger) string {
 
 	log.Infof("Attempting to save crash dump to %s", filename)
 
-	if err := t.SaveSnapshot(filename); err != nil {
+	if err := t.SaveSnapshotWithNote(filename, *note); err != nil {
 		log.Errorf("Failed to save crash dump: %v", err)
 		return ""
 	}
@@ -311,6 +348,7 @@ func saveCrashDump(t *trend.TrendAnalyzer, log *logrus.Logger) string {
 	}
 
 	log.Infof("Successfully saved crash dump to %s", filename)
+	startCrashHook(filename, log)
 	return filename
 }
 
@@ -324,40 +362,30 @@ func getTemperatureInfo(s *summary.SystemSummary) string {
 
 	// Display each sensor with its max and avg
 	for name, sensor := range s.Temperature.Sensors {
-		result += fmt.Sprintf("  %s (%s): Current: %.1f°C, Max: %.1f°C, Avg: %.1f°C\n",
-			name, sensor.Location, sensor.Value, sensor.MaxTemp, sensor.AvgTemp)
+		result += fmt.Sprintf("  %s (%s): Current: %.1f°C, Smoothed: %.1f°C, Max: %.1f°C, Avg: %.1f°C\n",
+			name, sensor.Location, sensor.Value, sensor.Smoothed, sensor.MaxTemp, sensor.AvgTemp)
 	}
 
 	// Add overall temperature stats
-	result += fmt.Sprintf("  Overall: Max: %.1f°C, Avg: %.1f°C\n", s.Temperature.MaxTemp, s.Temperature.AvgTemp)
+	result += fmt.Sprintf("  Overall: Max: %.1f°C, Avg: %.1f°C, Stress basis (%s): %.1f°C\n", s.Temperature.MaxTemp, s.Temperature.AvgTemp, overallTempValue, s.Temperature.Overall)
 
 	return result
 }
 
 func getHighMemoryProcesses(stats *parser.SystemStats) string {
 	var result string
-	// Create a map to deduplicate processes with the same command
-	processMap := make(map[string]float64)
-
-	for _, proc := range stats.Processes {
-		if proc.VSZPercent > 5 {
-			// Check if this command is already in the map
-			if existingValue, ok := processMap[proc.Command]; ok {
-				// Keep the higher memory usage value
-				if proc.VSZPercent > existingValue {
-					processMap[proc.Command] = proc.VSZPercent
-				}
+
+	for _, proc := range parser.DedupProcesses(stats.Processes, dedupStrategy) {
+		if processThresholds.HighMemory(proc) {
+			memPercent := proc.MemoryPercent()
+			if dedupStrategy == parser.DedupCommand || dedupStrategy == parser.DedupBase {
+				result += fmt.Sprintf("%s: %.1f%%\n", proc.Command, memPercent)
 			} else {
-				processMap[proc.Command] = proc.VSZPercent
+				result += fmt.Sprintf("%s (PID %d): %.1f%%\n", proc.Command, proc.PID, memPercent)
 			}
 		}
 	}
 
-	// Generate output from the deduplicated map
-	for cmd, memPercent := range processMap {
-		result += fmt.Sprintf("%s: %.1f%%\n", cmd, memPercent)
-	}
-
 	return result
 }
 
@@ -376,17 +404,27 @@ func getFilesystemInfo(stats *parser.SystemStats) string {
 		status := "OK"
 		if fs.Critical {
 			status = "CRITICAL"
-		} else if free < 20 {
+		} else if free < 20 && fsThresholds.Evaluated(fs.Size) {
 			status = "WARNING"
 		}
 
-		result += fmt.Sprintf("  %s (%s): %.1f%% used, %.2f GB free [%s]\n",
+		result += fmt.Sprintf("  %s (%s): %.1f%% used, %s free [%s]\n",
 			mount,
 			fs.Device,
 			fs.UsedPct,
-			float64(fs.Available)/1024.0/1024.0/1024.0,
+			bytesize.Format(fs.Available, bytesize.Giga),
 			status)
 	}
 
 	return result
 }
+
+// getStressBreakdown formats the factors of the sample's system stress and,
+// once the window has enough samples, of the trend's
+func getStressBreakdown(s *summary.SystemSummary, t *trend.Trend) string {
+	result := fmt.Sprintf("Stress Breakdown:\n  Sample: %s\n", s.StressBreakdown)
+	if t != nil {
+		result += fmt.Sprintf("  Trend: %s\n", t.StressBreakdown)
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/otlp"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/prometheus"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+	"github.com/sirupsen/logrus"
+)
+
+// summaryGauges returns the gauges exported for a summary. Memory and disk
+// usage are exported both in bytes and as percentages, so consumers can pick
+// either; the /metrics endpoint turns e.g. top_analyzer.memory.used with
+// unit By into top_analyzer_memory_used_bytes.
+func summaryGauges(s *summary.SystemSummary) []otlp.Gauge {
+	gauges := []otlp.Gauge{
+		{Name: "top_analyzer.cpu.usage", Unit: "%", Value: s.CPU.User + s.CPU.System},
+		{Name: "top_analyzer.memory.usage", Unit: "%", Value: s.Memory.UsedPc},
+		{Name: "top_analyzer.memory.used", Unit: "By", Value: float64(s.Memory.Used)},
+		{Name: "top_analyzer.memory.total", Unit: "By", Value: float64(s.Memory.Total)},
+		{Name: "top_analyzer.system.stress", Unit: "%", Value: s.SystemStress},
+	}
+	for name, sensor := range s.Temperature.Sensors {
+		// Sensor names such as f10e4078.thermal are sanitized into stable
+		// label values, the known location gives dashboards a readable one
+		attributes := map[string]string{"sensor": prometheus.LabelValue(name)}
+		if sensor.Location != "" && sensor.Location != "Unknown" {
+			attributes["location"] = sensor.Location
+		}
+		gauges = append(gauges, otlp.Gauge{
+			Name:       "top_analyzer.temperature",
+			Unit:       "Cel",
+			Value:      sensor.Value,
+			Attributes: attributes,
+		})
+	}
+	if len(s.Temperature.Sensors) > 0 {
+		gauges = append(gauges, otlp.Gauge{Name: "top_analyzer.temperature.overall", Unit: "Cel", Value: s.Temperature.Overall})
+	}
+	for subsystem, status := range s.Collectors {
+		up := 0.0
+		if status.Up {
+			up = 1
+		}
+		gauges = append(gauges, otlp.Gauge{
+			Name:       "top_analyzer.collector.up",
+			Value:      up,
+			Attributes: map[string]string{"subsystem": subsystem},
+		})
+	}
+	for mount, partition := range s.Filesystem.Partitions {
+		attributes := map[string]string{"mount_point": mount}
+		gauges = append(gauges,
+			otlp.Gauge{Name: "top_analyzer.filesystem.free", Unit: "%", Value: partition.FreeSpace, Attributes: attributes},
+			otlp.Gauge{Name: "top_analyzer.filesystem.usage", Unit: "%", Value: partition.UsedPct, Attributes: attributes},
+			otlp.Gauge{Name: "top_analyzer.filesystem.used", Unit: "By", Value: float64(partition.Used), Attributes: attributes},
+			otlp.Gauge{Name: "top_analyzer.filesystem.available", Unit: "By", Value: float64(partition.Available), Attributes: attributes},
+		)
+	}
+	return gauges
+}
+
+// exportMetrics records the gauges of the current summary, pushed to
+// -otlp-endpoint in the background every interval so a slow collector never
+// delays sampling
+func (m *monitor) exportMetrics() {
+	if m.exporter == nil {
+		return
+	}
+	if err := m.exporter.Record(context.Background(), summaryGauges(m.summary)); err != nil {
+		m.log.Warnf("Failed to export metrics: %v", err)
+	}
+}
+
+// flushMetrics pushes the gauges not exported yet and stops the exporter
+func (m *monitor) flushMetrics() {
+	if m.exporter == nil {
+		return
+	}
+	if err := m.exporter.Shutdown(context.Background()); err != nil {
+		m.log.Warnf("Failed to export metrics: %v", err)
+	}
+}
+
+// storeSample inserts the sample into the -sqlite database
+func (m *monitor) storeSample(stats *parser.SystemStats) {
+	if m.sink == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *interval)
+	defer cancel()
+	if err := m.sink.Insert(ctx, stats); err != nil {
+		m.log.Warnf("Failed to store sample: %v", err)
+	}
+}
+
+// newExporter creates the OTLP exporter, or nil when -otlp-endpoint is unset
+// or invalid
+func newExporter(log *logrus.Logger) *otlp.Exporter {
+	if *otlpEndpoint == "" {
+		return nil
+	}
+	exporter, err := otlp.New(context.Background(), *otlpEndpoint, *interval)
+	if err != nil {
+		log.Errorf("Metrics won't be exported: %v", err)
+		return nil
+	}
+	return exporter
+}
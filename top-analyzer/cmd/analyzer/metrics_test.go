@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/prometheus"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+)
+
+func TestSummaryGaugesBytesAndPercent(t *testing.T) {
+	stats := &parser.SystemStats{
+		Memory:         parser.Memory{Total: 1000, Used: 250, Free: 750},
+		ParsedSections: map[parser.Section]bool{parser.SectionMemory: true},
+		Filesystem: map[string]parser.FilesystemStats{
+			"/data": {Device: "/dev/sdb1", Size: 100, Used: 40, Available: 60, UsedPct: 40, MountPoint: "/data"},
+		},
+	}
+	s := summary.New()
+	s.Update(stats, nil, &stats.Temperature, "")
+
+	// Values by Prometheus metric name, with the mount point for partitions
+	values := make(map[string]float64)
+	for _, g := range summaryGauges(s) {
+		values[prometheus.MetricName(g.Name, g.Unit)+g.Attributes["mount_point"]] = g.Value
+	}
+
+	tests := []struct {
+		metric string
+		want   float64
+	}{
+		{metric: "top_analyzer_memory_used_bytes", want: 250},
+		{metric: "top_analyzer_memory_total_bytes", want: 1000},
+		{metric: "top_analyzer_memory_usage_percent", want: 25},
+		{metric: "top_analyzer_filesystem_used_bytes/data", want: 40},
+		{metric: "top_analyzer_filesystem_available_bytes/data", want: 60},
+		{metric: "top_analyzer_filesystem_usage_percent/data", want: 40},
+		{metric: "top_analyzer_filesystem_free_percent/data", want: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			got, ok := values[tt.metric]
+			if !ok {
+				t.Fatalf("%s missing from the gauges", tt.metric)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.metric, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryGaugesTemperatureLabels(t *testing.T) {
+	stats := &parser.SystemStats{}
+	stats.Temperature.Sensors = map[string]float64{
+		"f10e4078.thermal":         52,
+		"coretemp-isa-0000/Core 0": 49,
+	}
+	s := summary.New()
+	s.Update(stats, nil, &stats.Temperature, "")
+
+	var b bytes.Buffer
+	if err := prometheus.Write(&b, summaryGauges(s)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := b.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "known location", want: `top_analyzer_temperature_celsius{location="CPU Core",sensor="f10e4078_thermal"} 52` + "\n"},
+		{name: "unknown location left out", want: `top_analyzer_temperature_celsius{sensor="coretemp-isa-0000_Core_0"} 49` + "\n"},
+		{name: "overall", want: "top_analyzer_temperature_overall_celsius 52\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("metrics missing %q in\n%s", tt.want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// Sources of the samples
+const (
+	sourceTop  = "top"
+	sourceMock = "mock"
+)
+
+// validateSource checks the value of -source and its -scenario
+func validateSource(source, scenario string) error {
+	switch source {
+	case sourceTop:
+		return nil
+	case sourceMock:
+		if scenario == "" {
+			return fmt.Errorf("-source=%s requires -scenario", sourceMock)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid -source %q: must be %s or %s", source, sourceTop, sourceMock)
+	}
+}
+
+// scenarioStep is a sample of a scenario file, replayed Repeat times. The
+// stats use the field names of parser.SystemStats, e.g.
+// {"Repeat": 5, "CPU": {"User": 95}, "Memory": {"Total": 8589934592, "Used": 4294967296}}
+type scenarioStep struct {
+	Repeat int // Times the sample is replayed, at least once
+	parser.SystemStats
+}
+
+// mockProvider replays the samples of a scenario, for demos and for
+// exercising the pipeline deterministically. Once the scenario is over its
+// last sample keeps being replayed.
+type mockProvider struct {
+	mu      sync.Mutex
+	samples []parser.SystemStats
+	next    int
+}
+
+// loadScenario reads a scenario file: a JSON array of scenario steps
+func loadScenario(filename string) (*mockProvider, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	var steps []scenarioStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", filename, err)
+	}
+
+	p := &mockProvider{}
+	for _, step := range steps {
+		for i := 0; i < max(step.Repeat, 1); i++ {
+			p.samples = append(p.samples, step.SystemStats)
+		}
+	}
+	if len(p.samples) == 0 {
+		return nil, fmt.Errorf("scenario %s has no samples", filename)
+	}
+	return p, nil
+}
+
+// Collect returns the next sample of the scenario, timestamped now
+func (p *mockProvider) Collect(ctx context.Context) (*parser.SystemStats, error) {
+	p.mu.Lock()
+	stats := p.samples[p.next]
+	if p.next < len(p.samples)-1 {
+		p.next++
+	}
+	p.mu.Unlock()
+
+	stats.Timestamp = time.Now()
+	return &stats, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	calmStep     = `{"Repeat": 3, "CPU": {"User": 5, "Idle": 95}, "Memory": {"Total": 8589934592, "Used": 1073741824}, "LoadAverage": {"One": 0.2}}`
+	overloadStep = `{"Repeat": 3, "CPU": {"User": 99}, "Memory": {"Total": 8589934592, "Used": 8160437862}, "LoadAverage": {"One": 12}}`
+)
+
+// writeScenario writes a scenario file and returns its path
+func writeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScenarioCrashDump(t *testing.T) {
+	tests := []struct {
+		name      string
+		scenario  string
+		samples   int
+		wantDumps bool
+	}{
+		{name: "crosses the crash threshold", scenario: "[" + calmStep + "," + overloadStep + "]", samples: 6, wantDumps: true},
+		{name: "stays calm", scenario: "[" + calmStep + "]", samples: 6, wantDumps: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, calibration, 2)
+			provider, err := loadScenario(writeScenario(t, tt.scenario))
+			if err != nil {
+				t.Fatalf("loadScenario() error = %v", err)
+			}
+			m := newTestMonitor(t, provider)
+
+			for i := 0; i < tt.samples; i++ {
+				m.sample()
+			}
+			if got := crashDumps(t) > 0; got != tt.wantDumps {
+				t.Errorf("crash dump written = %v, want %v (stress %.1f)", got, tt.wantDumps, m.summary.SystemStress)
+			}
+		})
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name        string
+		scenario    string
+		wantSamples int
+		wantErr     bool
+	}{
+		{name: "repeated steps", scenario: "[" + calmStep + "," + overloadStep + "]", wantSamples: 6},
+		{name: "step without repeat", scenario: `[{"CPU": {"User": 50}}]`, wantSamples: 1},
+		{name: "no steps", scenario: "[]", wantErr: true},
+		{name: "truncated file", scenario: "[" + calmStep, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := loadScenario(writeScenario(t, tt.scenario))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadScenario() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(provider.samples) != tt.wantSamples {
+				t.Errorf("scenario has %d samples, want %d", len(provider.samples), tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		scenario string
+		wantErr  bool
+	}{
+		{name: "top", source: sourceTop},
+		{name: "mock with a scenario", source: sourceMock, scenario: "scenario.json"},
+		{name: "mock without a scenario", source: sourceMock, wantErr: true},
+		{name: "unknown source", source: "procfs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSource(tt.source, tt.scenario); (err != nil) != tt.wantErr {
+				t.Errorf("validateSource(%q, %q) error = %v, wantErr %v", tt.source, tt.scenario, err, tt.wantErr)
+			}
+		})
+	}
+}
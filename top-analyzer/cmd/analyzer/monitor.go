@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/alert"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/analyzer"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/otlp"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/power"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sqlite"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+	"github.com/sirupsen/logrus"
+)
+
+// monitor holds the state shared across samples of the main loop
+type monitor struct {
+	provider        StatsProvider
+	analyzer        *trend.TrendAnalyzer
+	insights        *analyzer.Analyzer
+	summary         *summary.SystemSummary
+	log             *logrus.Logger
+	silence         *silencer
+	readSelfRSS     func() (int64, error)
+	api             *apiServer
+	stdout          io.Writer       // Console the stats block is printed to, discarded with -quiet
+	dashboard       *dashboard      // nil unless -tui is set
+	exporter        *otlp.Exporter  // nil unless -otlp-endpoint is set
+	sink            *sqlite.Sink    // nil unless -sqlite is set
+	events          *alert.EventLog // nil unless -event-log is set
+	notifiers       []alert.Notifier
+	throttle        *alert.Throttle // Spaces out the notifications of persisting conditions
+	hostname        string
+	lastSummarySave time.Time
+	evaluations     int                   // Evaluations run by the current trend analyzer
+	calibrated      bool                  // Whether an evaluation ran with alerts enabled since the analyzer was created
+	pending         []*parser.SystemStats // Samples collected since the last evaluation
+	failures        int                   // Consecutive collection failures
+	nextAttempt     time.Time             // No collection is attempted before this time
+	power           *power.PowerStats     // Latest power supply state, nil until read
+	overheated      map[string]bool       // Sensors at or above -temp-critical in the previous sample
+}
+
+func newMonitor(provider StatsProvider, log *logrus.Logger, silence *silencer) *monitor {
+	hostname, _ := os.Hostname()
+	s := summary.New()
+	s.SetPrecision(*precision)
+	s.SetProcessThresholds(processThresholds)
+	s.SetFilesystemThresholds(fsThresholds)
+	s.SetWatchlist(watchlist())
+	s.SetSkipUnchanged(*skipUnchanged)
+	s.SetOverallTemperature(overallTempValue)
+
+	m := &monitor{
+		provider:        provider,
+		analyzer:        newAnalyzer(log),
+		insights:        newInsights(),
+		summary:         s,
+		log:             log,
+		silence:         silence,
+		readSelfRSS:     readSelfRSS,
+		api:             newAPIServer(),
+		exporter:        newExporter(log),
+		notifiers:       newNotifiers(),
+		throttle:        alert.NewThrottle(alertCooldowns),
+		hostname:        hostname,
+		lastSummarySave: time.Now(),
+		stdout:          os.Stdout,
+	}
+	if *eventLog != "" {
+		m.events = alert.NewEventLog(*eventLog)
+	}
+	if *quiet {
+		m.stdout = io.Discard
+	} else if *tui {
+		m.dashboard = newDashboard(os.Stdout)
+	}
+	return m
+}
+
+// watchlist returns the patterns of -watch and -watch-critical, without
+// duplicates
+func watchlist() []string {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, pattern := range append(parser.ParseWatchlist(*watch), parser.ParseWatchlist(*watchCritical)...) {
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// newInsights creates the insight analyzer from the command line options
+func newInsights() *analyzer.Analyzer {
+	insights := analyzer.New(*history)
+	insights.SetProcessThresholds(processThresholds)
+	insights.SetInterval(time.Duration(samplesPerEval()) * *interval)
+	return insights
+}
+
+// newAnalyzer creates a trend analyzer from the command line options,
+// restoring the persisted baseline when -persistent-baseline is set
+func newAnalyzer(log *logrus.Logger) *trend.TrendAnalyzer {
+	analyzer := trend.NewWithFullOptions(*history, *anomalyThreshold, *trendThreshold, *tempThreshold, longTermSamples())
+	analyzer.SetDedupStrategy(dedupStrategy)
+	analyzer.SetInterval(time.Duration(samplesPerEval()) * *interval)
+	analyzer.SetTempWindow(*tempWindow)
+	analyzer.SetStuckProcessSamples(*stuckSamples)
+	analyzer.SetLostSensorSamples(*lostSamples)
+	analyzer.SetWatchlist(watchlist())
+	analyzer.SetCriticalWatchlist(parser.ParseWatchlist(*watchCritical))
+	analyzer.SetExpectedProcesses(parser.ParseWatchlist(*expectedProcs), processThresholds)
+	analyzer.SetWarmupSuppression(*suppressWarmup)
+	analyzer.SetPrecision(*precision)
+	analyzer.SetFilesystemThresholds(fsThresholds)
+	analyzer.SetMemoryThresholds(memThresholds)
+	analyzer.SetStressThreshold(*stressCrash)
+	analyzer.SetExcludedPID(processThresholds.ExcludePID)
+	analyzer.SetAnomalyMethod(anomalyMethodValue, *anomalyPct)
+	analyzer.SetOverallTemperature(overallTempValue)
+	if *persistentBase {
+		analyzer.SetPersistentBaseline(true)
+		if err := analyzer.LoadState(*stateFile); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Warnf("Failed to restore analyzer state: %v", err)
+			}
+		} else {
+			log.Infof("Restored analyzer baseline from %s", *stateFile)
+		}
+	}
+	return analyzer
+}
+
+// saveState persists the analyzer baseline when -persistent-baseline is set
+func (m *monitor) saveState() {
+	if !*persistentBase {
+		return
+	}
+	if err := m.analyzer.SaveState(*stateFile); err != nil {
+		m.log.Errorf("Failed to save analyzer state: %v", err)
+	}
+}
+
+// shutdown saves the state and pushes the last metrics before exiting,
+// giving up after -shutdown-timeout so a save hanging on a stuck disk or an
+// unreachable collector doesn't keep the process from exiting
+func (m *monitor) shutdown() {
+	if !runWithTimeout(func() {
+		m.saveState()
+		m.flushMetrics()
+	}, *shutdownTimeout) {
+		m.log.Errorf("Saving state took longer than %s, exiting anyway", *shutdownTimeout)
+	}
+}
+
+// runWithTimeout runs fn and reports whether it returned within timeout. A
+// timeout of zero or less waits for fn however long it takes. When fn times
+// out it is left running in the background.
+func runWithTimeout(fn func(), timeout time.Duration) bool {
+	if timeout <= 0 {
+		fn()
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// safeSample runs a single sample and recovers from any panic raised while
+// collecting or analyzing it, so one bad sample doesn't stop the monitor.
+// The crash dump is taken from the current analyzer, which is then replaced
+// with a fresh one so the sample that caused the panic isn't analyzed again.
+func (m *monitor) safeSample() {
+	defer func() {
+		if r := recover(); r != nil {
+			m.log.Errorf("Panic occurred while sampling: %v\n%s", r, debug.Stack())
+			crashFile := saveCrashDump(m.analyzer, m.log)
+			if crashFile != "" {
+				m.log.Warnf("Saved crash dump after panic: %s", crashFile)
+			}
+			m.recordCrash(r, crashFile)
+			m.analyzer = newAnalyzer(m.log)
+			m.insights = newInsights()
+			m.evaluations = 0
+			m.calibrated = false
+			m.pending = nil
+			m.log.Warnf("Analyzer re-created after panic, continuing with next sample")
+		}
+	}()
+
+	m.sample()
+}
+
+// collectorStatus returns the state of every collection subsystem given the
+// error returned by Collect
+func collectorStatus(err error) map[string]summary.CollectorStatus {
+	status := make(map[string]summary.CollectorStatus, len(subsystems))
+	for _, subsystem := range subsystems {
+		status[subsystem] = summary.CollectorStatus{Up: true}
+	}
+	for _, collectionErr := range collectionErrors(err) {
+		status[collectionErr.Subsystem] = summary.CollectorStatus{Error: collectionErr.Err.Error()}
+	}
+	if err != nil && len(collectionErrors(err)) == 0 {
+		// Not attributed to a subsystem, blame the essential one
+		status[subsystemTop] = summary.CollectorStatus{Error: err.Error()}
+	}
+	return status
+}
+
+// longTermSamples returns the number of samples in the long-term temperature
+// history: -long-term-duration worth of evaluations when set, so that the
+// retention period doesn't change with the interval, and -long-term-window
+// otherwise
+func longTermSamples() int {
+	if *longTermDuration <= 0 {
+		return *longTermWindow
+	}
+	return trend.WindowFor(*longTermDuration, *interval*time.Duration(samplesPerEval()))
+}
+
+// samplesPerEval returns how many samples are aggregated into each
+// evaluation, 1 unless -eval-interval is longer than -interval
+func samplesPerEval() int {
+	if *once || *evalInterval <= *interval {
+		return 1
+	}
+	return int(*evalInterval / *interval)
+}
+
+// sample collects the current system stats. Once every -eval-interval it
+// updates the analyzer and summary with the aggregated samples and creates a
+// crash dump if the analysis requires one.
+func (m *monitor) sample() {
+	// Back off while collection keeps failing
+	start := time.Now()
+	if start.Before(m.nextAttempt) {
+		return
+	}
+
+	// Bound the collection so a hung command doesn't stall the loop
+	ctx, cancel := context.WithTimeout(context.Background(), *sampleTimeout)
+	defer cancel()
+
+	stats, err := m.provider.Collect(ctx)
+	m.summary.SetCollectors(collectorStatus(err))
+	if stats == nil {
+		m.failures++
+		delay := backoffDelay(*interval, *backoffMax, m.failures)
+		m.nextAttempt = start.Add(delay)
+		m.log.Warnf("Failed to collect system stats (%d consecutive failures), retrying in %v: %v", m.failures, delay, err)
+		if err := m.api.publishStats(m.summary); err != nil {
+			m.log.Errorf("Failed to publish summary: %v", err)
+		}
+		return
+	}
+	if m.failures > 0 {
+		m.log.Infof("Collection recovered after %d consecutive failures", m.failures)
+		m.failures = 0
+		m.nextAttempt = time.Time{}
+	}
+
+	if stats.CPUScale > 0 {
+		m.log.Warnf("CPU usage was reported summed across cores, normalized using %d cores", stats.CPUScale)
+	} else if stats.CPUClamped {
+		m.log.Warnf("CPU usage was reported above 100%% per core, scaled down to a 100%% total")
+	}
+	if missing := stats.Missing(); len(missing) > 0 {
+		m.log.Warnf("top output was cut short, missing sections: %v", missing)
+	}
+	if stats.Truncated {
+		m.log.Warnf("top printed %d of %d processes, per-process checks only see the printed ones", len(stats.Processes), stats.Tasks.Total)
+	}
+	m.storeSample(stats)
+	m.checkCriticalTemperature(stats)
+	if *heartbeatFile != "" {
+		if err := writeHeartbeat(*heartbeatFile, time.Now()); err != nil {
+			m.log.Errorf("Failed to write heartbeat: %v", err)
+		}
+	}
+
+	// Aggregate the samples collected until the next evaluation is due
+	m.pending = append(m.pending, stats)
+	if len(m.pending) < samplesPerEval() {
+		m.log.Debugf("Collected %d/%d samples for the next evaluation", len(m.pending), samplesPerEval())
+		return
+	}
+	stats = parser.Aggregate(m.pending)
+	m.pending = nil
+
+	// Debug logging for CPU and memory stats
+	m.log.Debugf("Raw CPU stats - User: %.1f%%, Sys: %.1f%%, Idle: %.1f%%",
+		stats.CPU.User, stats.CPU.Sys, stats.CPU.Idle)
+
+	// Calculate memory percentage safely
+	memUsedPct := 0.0
+	if stats.Memory.Total > 0 {
+		memUsedPct = float64(stats.Memory.Used) / float64(stats.Memory.Total) * 100
+	} else if stats.Has(parser.SectionMemory) {
+		m.log.Warnf("top reported a zero memory total, memory usage recorded as 0%%")
+	}
+	m.log.Debugf("Raw Memory stats - Total: %s, Used: %s, Free: %s, Used%%: %.1f%%",
+		formatMemory(stats.Memory.Total, *memUnit), formatMemory(stats.Memory.Used, *memUnit), formatMemory(stats.Memory.Free, *memUnit), memUsedPct)
+
+	// Update analyzer and summary
+	m.analyzer.AddStats(stats)
+	m.insights.AddStats(stats)
+	if powerStats, err := power.ReadPowerStats(); err != nil {
+		m.log.Debugf("Failed to read power supplies: %v", err)
+	} else {
+		m.power = powerStats
+	}
+	m.summary.Update(stats, m.power, &stats.Temperature, "")
+	m.summary.SetInsights(m.insights.GetInsights())
+
+	// Analyze trends
+	trend := m.analyzer.Analyze()
+	if trend != nil {
+		m.summary.SetAnomalies(trend.AnomalyCount())
+	} else {
+		m.summary.SetAnomalies(0)
+	}
+	m.evaluations++
+	calibrating := m.evaluations <= *calibration
+	if trend != nil && !calibrating && !m.calibrated {
+		m.calibrated = true
+		if *calibration > 0 {
+			m.log.Infof("Calibration complete after %d evaluations, alerts enabled", *calibration)
+		}
+	}
+	if trend != nil && calibrating {
+		m.log.Debugf("Calibrating baseline (%d/%d evaluations), alerts and crash dumps are suppressed", m.evaluations, *calibration)
+		// A critical process exiting or a battery running out doesn't
+		// depend on the baseline
+		if !m.silence.Silenced(time.Now()) {
+			m.raiseAlert(trend.SystemStress, stats, append(alertItems(trend.ExitedProblems()), powerItems(m.power)...))
+		}
+	} else if trend != nil && m.silence.Silenced(time.Now()) {
+		m.log.Infof("Maintenance window active, alerts and crash dumps are silenced")
+	} else if trend != nil {
+		// Bundle all conditions of this evaluation into a single alert
+		m.raiseAlert(trend.SystemStress, stats, append(alertItems(trend.ProblemDetails()), powerItems(m.power)...))
+	}
+
+	// Log current stats
+	stressExplanation := ""
+	if *explain {
+		stressExplanation = getStressBreakdown(m.summary, trend)
+	}
+	statsStr := fmt.Sprintf("=== System Stats at %s ===\n"+
+		"CPU: %.1f%% user, %.1f%% system, %.1f%% idle\n"+
+		"Memory: %.1f%% used (Total: %s, Used: %s, Free: %s)\n"+
+		"Load: %.2f (1min), %.2f (5min), %.2f (15min)\n"+
+		"System Stress: %.1f%%\n"+
+		"Health: %d (%s)\n"+
+		"Process States:\n"+
+		"S: %d\n"+
+		"R: %d\n"+
+		"D: %d\n"+
+		"Z: %d\n"+
+		"Temperature:\n%s"+
+		"Filesystem:\n%s"+
+		"High Memory Usage Processes (>%g%%):\n%s"+
+		"Total CPU Usage: %.1f%%\n"+
+		"Total Memory Usage: %.1f%%\n"+
+		"%s"+
+		"=============================\n",
+		m.summary.Timestamp.Format(time.RFC3339),
+		m.summary.CPU.User, m.summary.CPU.System, m.summary.CPU.Idle,
+		memUsedPct, formatMemory(m.summary.Memory.Total, *memUnit), formatMemory(m.summary.Memory.Used, *memUnit), formatMemory(m.summary.Memory.Free, *memUnit),
+		stats.LoadAverage.One, stats.LoadAverage.Five, stats.LoadAverage.Fifteen,
+		m.summary.SystemStress,
+		m.summary.HealthScore(), m.summary.HealthBand(),
+		m.summary.Processes.Sleeping, m.summary.Processes.Running, m.summary.Processes.Uninterr, m.summary.Processes.Zombie,
+		getTemperatureInfo(m.summary),
+		getFilesystemInfo(stats),
+		processThresholds.MemoryPercent, getHighMemoryProcesses(stats),
+		m.summary.CPU.User+m.summary.CPU.System,
+		memUsedPct,
+		stressExplanation)
+
+	// Log to both console and file
+	if m.dashboard != nil {
+		m.dashboard.render(m.summary)
+	} else if *format == formatFlat {
+		if trend != nil {
+			writeFlat(m.stdout, trend)
+		}
+	} else {
+		fmt.Fprint(m.stdout, statsStr)
+	}
+	if !*quietLog {
+		m.log.Print(statsStr)
+	}
+
+	if err := m.api.publishStats(m.summary); err != nil {
+		m.log.Errorf("Failed to publish summary: %v", err)
+	}
+	m.exportMetrics()
+
+	// Save summary every minute
+	if time.Since(m.lastSummarySave) >= time.Minute {
+		for _, output := range summaryOutputValues {
+			if err := m.summary.Write(output); err != nil {
+				m.log.Printf("Failed to save summary to %s: %v", output.Path, err)
+			}
+		}
+		m.saveState()
+		m.lastSummarySave = time.Now()
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/otlp"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/sirupsen/logrus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// setFlag sets a command line option for the duration of the test
+func setFlag[T any](t *testing.T, flag *T, value T) {
+	t.Helper()
+	old := *flag
+	*flag = value
+	t.Cleanup(func() { *flag = old })
+}
+
+// newTestMonitor creates a monitor sampling provider, with nothing printed
+// and crash dumps saved to a temporary directory
+func newTestMonitor(t *testing.T, provider StatsProvider) *monitor {
+	t.Helper()
+	setFlag(t, crashDir, t.TempDir())
+	setFlag(t, quiet, true)
+	setFlag(t, quietLog, true)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return newMonitor(provider, log, &silencer{})
+}
+
+// crashDumps returns the number of files in the crash directory
+func crashDumps(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir(*crashDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+// panickingProvider panics on the collections whose index is in panics and
+// returns stats with the CPU user percentage set to the index otherwise
+type panickingProvider struct {
+	panics map[int]bool
+	calls  int
+}
+
+func (p *panickingProvider) Collect(ctx context.Context) (*parser.SystemStats, error) {
+	call := p.calls
+	p.calls++
+	if p.panics[call] {
+		panic("injected panic")
+	}
+	return &parser.SystemStats{CPU: parser.CPU{User: float64(call)}}, nil
+}
+
+func TestSafeSampleRecoversFromPanic(t *testing.T) {
+	tests := []struct {
+		name          string
+		samples       int
+		panics        map[int]bool
+		wantCrashDump bool
+		wantHistory   int
+		wantCPUUser   float64
+	}{
+		{name: "no panic", samples: 3, wantHistory: 3, wantCPUUser: 2},
+		{name: "panic on the first sample", samples: 2, panics: map[int]bool{0: true}, wantCrashDump: true, wantHistory: 1, wantCPUUser: 1},
+		{name: "panic between samples", samples: 3, panics: map[int]bool{1: true}, wantCrashDump: true, wantHistory: 1, wantCPUUser: 2},
+		{name: "consecutive panics", samples: 4, panics: map[int]bool{1: true, 2: true}, wantCrashDump: true, wantHistory: 1, wantCPUUser: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(t, &panickingProvider{panics: tt.panics})
+			for i := 0; i < tt.samples; i++ {
+				m.safeSample()
+			}
+
+			if got := len(m.analyzer.GetHistory()); got != tt.wantHistory {
+				t.Errorf("analyzer history = %d samples, want %d", got, tt.wantHistory)
+			}
+			if got := m.summary.CPU.User; got != tt.wantCPUUser {
+				t.Errorf("summary CPU user = %v, want %v", got, tt.wantCPUUser)
+			}
+			if got := crashDumps(t) > 0; got != tt.wantCrashDump {
+				t.Errorf("crash dump saved = %v, want %v", got, tt.wantCrashDump)
+			}
+		})
+	}
+}
+
+// collection is a scripted result of a fake provider
+type collection struct {
+	stats *parser.SystemStats
+	err   error
+}
+
+// scriptedProvider returns its collections in turn, then fails
+type scriptedProvider struct {
+	collections []collection
+}
+
+func (p *scriptedProvider) Collect(ctx context.Context) (*parser.SystemStats, error) {
+	if len(p.collections) == 0 {
+		return nil, errors.New("script over")
+	}
+	c := p.collections[0]
+	p.collections = p.collections[1:]
+	return c.stats, c.err
+}
+
+func TestMonitorDrivenByProvider(t *testing.T) {
+	cpu := func(user float64) *parser.SystemStats {
+		return &parser.SystemStats{CPU: parser.CPU{User: user}}
+	}
+	sensorsDown := &CollectionError{Subsystem: subsystemTemperature, Err: errors.New("no sensors")}
+
+	tests := []struct {
+		name           string
+		collections    []collection
+		wantHistory    int
+		wantCPUUser    float64
+		wantFailures   int
+		wantCollectors map[string]bool // Up per subsystem after the last sample
+	}{
+		{
+			name:           "every sample collected",
+			collections:    []collection{{stats: cpu(10)}, {stats: cpu(20)}, {stats: cpu(30)}},
+			wantHistory:    3,
+			wantCPUUser:    30,
+			wantCollectors: map[string]bool{subsystemTop: true, subsystemTemperature: true, subsystemFilesystem: true},
+		},
+		{
+			name:           "partial sample",
+			collections:    []collection{{stats: cpu(10)}, {stats: cpu(20), err: sensorsDown}},
+			wantHistory:    2,
+			wantCPUUser:    20,
+			wantCollectors: map[string]bool{subsystemTop: true, subsystemTemperature: false, subsystemFilesystem: true},
+		},
+		{
+			name:           "collection failed",
+			collections:    []collection{{stats: cpu(10)}, {err: &CollectionError{Subsystem: subsystemTop, Err: errors.New("top not found")}}},
+			wantHistory:    1,
+			wantCPUUser:    10,
+			wantFailures:   1,
+			wantCollectors: map[string]bool{subsystemTop: false, subsystemTemperature: true, subsystemFilesystem: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(t, &scriptedProvider{collections: tt.collections})
+			for range tt.collections {
+				m.safeSample()
+			}
+
+			if got := len(m.analyzer.GetHistory()); got != tt.wantHistory {
+				t.Errorf("analyzer history = %d samples, want %d", got, tt.wantHistory)
+			}
+			if got := m.summary.CPU.User; got != tt.wantCPUUser {
+				t.Errorf("summary CPU user = %v, want %v", got, tt.wantCPUUser)
+			}
+			if m.failures != tt.wantFailures {
+				t.Errorf("consecutive failures = %d, want %d", m.failures, tt.wantFailures)
+			}
+			for subsystem, up := range tt.wantCollectors {
+				if got := m.summary.Collectors[subsystem].Up; got != up {
+					t.Errorf("collector %s up = %v, want %v", subsystem, got, up)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	tests := []struct {
+		name       string
+		fn         func()
+		timeout    time.Duration
+		want       bool
+		maxElapsed time.Duration
+	}{
+		{name: "save blocks past the timeout", fn: func() { <-block }, timeout: 50 * time.Millisecond, want: false, maxElapsed: time.Second},
+		{name: "save returns in time", fn: func() {}, timeout: 50 * time.Millisecond, want: true, maxElapsed: time.Second},
+		{name: "slow save within the timeout", fn: func() { time.Sleep(20 * time.Millisecond) }, timeout: time.Second, want: true, maxElapsed: time.Second},
+		{name: "no timeout", fn: func() {}, timeout: 0, want: true, maxElapsed: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			if got := runWithTimeout(tt.fn, tt.timeout); got != tt.want {
+				t.Errorf("runWithTimeout() = %v, want %v", got, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > tt.maxElapsed {
+				t.Errorf("runWithTimeout() took %v, want under %v", elapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
+
+// blockingReader is a metric reader whose shutdown, the last export, blocks
+// until release is closed, like an unreachable collector
+type blockingReader struct {
+	*sdkmetric.ManualReader
+	release chan struct{}
+}
+
+func (r *blockingReader) Shutdown(ctx context.Context) error {
+	<-r.release
+	return r.ManualReader.Shutdown(ctx)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		blocks     bool
+		maxElapsed time.Duration
+	}{
+		{name: "last export blocks", blocks: true, maxElapsed: time.Second},
+		{name: "last export returns", maxElapsed: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, shutdownTimeout, 100*time.Millisecond)
+			setFlag(t, persistentBase, true)
+			setFlag(t, stateFile, filepath.Join(t.TempDir(), "state.json"))
+
+			reader := &blockingReader{ManualReader: sdkmetric.NewManualReader(), release: make(chan struct{})}
+			if tt.blocks {
+				defer close(reader.release)
+			} else {
+				close(reader.release)
+			}
+
+			m := newTestMonitor(t, &mockProvider{})
+			m.exporter = otlp.NewWithReader(reader)
+
+			start := time.Now()
+			m.shutdown()
+			if elapsed := time.Since(start); elapsed > tt.maxElapsed {
+				t.Errorf("shutdown() took %v, want under %v", elapsed, tt.maxElapsed)
+			}
+			if _, err := os.Stat(*stateFile); err != nil {
+				t.Errorf("state not saved before the last export: %v", err)
+			}
+		})
+	}
+}
+
+func TestLongTermSamples(t *testing.T) {
+	tests := []struct {
+		name         string
+		duration     time.Duration
+		window       int
+		interval     time.Duration
+		evalInterval time.Duration
+		want         int
+	}{
+		{name: "window when no duration", window: 100, interval: 5 * time.Second, want: 100},
+		{name: "24h at 5s", duration: 24 * time.Hour, window: 100, interval: 5 * time.Second, want: 17280},
+		{name: "24h at 10s", duration: 24 * time.Hour, window: 100, interval: 10 * time.Second, want: 8640},
+		{name: "24h evaluated every minute", duration: 24 * time.Hour, window: 100, interval: 5 * time.Second, evalInterval: time.Minute, want: 1440},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, longTermDuration, tt.duration)
+			setFlag(t, longTermWindow, tt.window)
+			setFlag(t, interval, tt.interval)
+			setFlag(t, evalInterval, tt.evalInterval)
+			setFlag(t, once, false)
+
+			if got := longTermSamples(); got != tt.want {
+				t.Errorf("longTermSamples() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
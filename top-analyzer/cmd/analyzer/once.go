@@ -0,0 +1,22 @@
+package main
+
+import "github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+
+// healthExitCodes maps health bands to the exit code of -once
+var healthExitCodes = map[string]int{
+	summary.HealthHealthy:  0,
+	summary.HealthDegraded: 1,
+	summary.HealthCritical: 2,
+}
+
+// onceFailedExitCode is the exit code of -once when no sample was collected
+const onceFailedExitCode = 3
+
+// runOnce takes a single sample and returns the exit code of its health band
+func runOnce(m *monitor) int {
+	m.safeSample()
+	if m.summary.Timestamp.IsZero() {
+		return onceFailedExitCode
+	}
+	return healthExitCodes[m.summary.HealthBand()]
+}
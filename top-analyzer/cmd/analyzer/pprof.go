@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pprofHandler serves the runtime profiles of the analyzer under
+// /debug/pprof/. It uses its own mux so the profiles are never exposed on
+// the REST API address.
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// startPprof serves the profiles on -pprof-addr, when set
+func startPprof(log *logrus.Logger) {
+	if *pprofAddr == "" {
+		return
+	}
+	go func() {
+		log.Infof("Serving pprof on %s", *pprofAddr)
+		if err := http.ListenAndServe(*pprofAddr, pprofHandler()); err != nil {
+			log.Errorf("pprof server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPprofHandler(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/debug/pprof/", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/heap", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/goroutine?debug=1", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/cmdline", wantStatus: http.StatusOK},
+		{path: "/debug/pprof/missing", wantStatus: http.StatusNotFound},
+		// The REST API is never served on the pprof address
+		{path: "/stats", wantStatus: http.StatusNotFound},
+	}
+
+	server := httptest.NewServer(pprofHandler())
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStartPprof(t *testing.T) {
+	// Reserve a free port for the pprof server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		wantUp  bool
+		timeout time.Duration
+	}{
+		{name: "disabled", addr: "", wantUp: false, timeout: 200 * time.Millisecond},
+		{name: "enabled", addr: addr, wantUp: true, timeout: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, pprofAddr, tt.addr)
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			startPprof(log)
+
+			up := false
+			for deadline := time.Now().Add(tt.timeout); time.Now().Before(deadline) && !up; time.Sleep(20 * time.Millisecond) {
+				resp, err := http.Get("http://" + addr + "/debug/pprof/")
+				if err == nil {
+					resp.Body.Close()
+					up = resp.StatusCode == http.StatusOK
+				}
+			}
+			if up != tt.wantUp {
+				t.Errorf("pprof served on %s = %v, want %v", addr, up, tt.wantUp)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+	"github.com/sirupsen/logrus"
+)
+
+// StatsProvider collects a complete sample of system statistics. When only
+// some subsystems fail, Collect returns the partial stats along with an error
+// joining a *CollectionError per failed subsystem. When no stats could be
+// collected at all, the returned stats are nil.
+type StatsProvider interface {
+	Collect(ctx context.Context) (*parser.SystemStats, error)
+}
+
+// Collection subsystems
+const (
+	subsystemTop         = "top"
+	subsystemTemperature = "temperature"
+	subsystemFilesystem  = "filesystem"
+)
+
+// subsystems lists every collection subsystem
+var subsystems = []string{subsystemTop, subsystemTemperature, subsystemFilesystem}
+
+// CollectionError is the failure of a single collection subsystem
+type CollectionError struct {
+	Subsystem string
+	Err       error
+}
+
+func (e *CollectionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Subsystem, e.Err)
+}
+
+func (e *CollectionError) Unwrap() error {
+	return e.Err
+}
+
+// collectionErrors returns the subsystem failures in an error returned by
+// Collect, unwrapping joined errors
+func collectionErrors(err error) []*CollectionError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []*CollectionError
+		for _, err := range joined.Unwrap() {
+			errs = append(errs, collectionErrors(err)...)
+		}
+		return errs
+	}
+	var collectionErr *CollectionError
+	if errors.As(err, &collectionErr) {
+		return []*CollectionError{collectionErr}
+	}
+	return nil
+}
+
+// topProvider collects stats from the top command, the temperature
+// sensors and the df command. The three sources are read concurrently, so a
+// sample takes as long as the slowest of them.
+type topProvider struct {
+	log      *logrus.Logger
+	rescaled map[string]bool // Sensors whose unexpected scale was already logged
+
+	readTop         func(ctx context.Context) (*parser.SystemStats, error)
+	readTemperature func() (*temperature.TemperatureStats, error)
+	readFilesystem  func(ctx context.Context) (*filesystem.FilesystemStats, error)
+}
+
+func newTopProvider(topPath string, topArgs []string, dfPath string, log *logrus.Logger) *topProvider {
+	return &topProvider{
+		log:      log,
+		rescaled: make(map[string]bool),
+		readTop: func(ctx context.Context) (*parser.SystemStats, error) {
+			return readTop(ctx, topPath, topArgs, log)
+		},
+		readTemperature: func() (*temperature.TemperatureStats, error) {
+			return temperature.ReadTemperatureStatsWith(sensorFilter)
+		},
+		readFilesystem: func(ctx context.Context) (*filesystem.FilesystemStats, error) {
+			return filesystem.ReadFilesystemStatsWith(ctx, dfPath, fsThresholds)
+		},
+	}
+}
+
+// readTop runs top and parses its output
+func readTop(ctx context.Context, topPath string, topArgs []string, log *logrus.Logger) (*parser.SystemStats, error) {
+	cmd := exec.CommandContext(ctx, topPath, topArgs...)
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("top command did not finish in time: %w", ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run top command: %w", err)
+	}
+
+	// Debug logging for raw top output
+	log.Debugf("Raw top output:\n%s", string(output))
+
+	stats, err := parser.ParseTopOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse top output: %w", err)
+	}
+	return stats, nil
+}
+
+func (p *topProvider) Collect(ctx context.Context) (*parser.SystemStats, error) {
+	var (
+		wg  sync.WaitGroup
+		top struct {
+			stats *parser.SystemStats
+			err   error
+		}
+		temp struct {
+			stats *temperature.TemperatureStats
+			err   error
+		}
+		fs struct {
+			stats *filesystem.FilesystemStats
+			err   error
+		}
+		finished [3]atomic.Bool // Per subsystem, in the order of subsystems
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		top.stats, top.err = p.readTop(ctx)
+		finished[0].Store(true)
+	}()
+	go func() {
+		defer wg.Done()
+		temp.stats, temp.err = p.readTemperature()
+		finished[1].Store(true)
+	}()
+	go func() {
+		defer wg.Done()
+		fs.stats, fs.err = p.readFilesystem(ctx)
+		finished[2].Store(true)
+	}()
+
+	// The temperature reader can't be cancelled, so stop waiting on timeout
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	// A reader that didn't finish in time may still write its results, so
+	// only those of finished readers are used, keeping e.g. the top sample
+	// when df hangs
+	late := fmt.Errorf("did not finish in time: %w", ctx.Err())
+	var (
+		stats     *parser.SystemStats
+		tempStats *temperature.TemperatureStats
+		fsStats   *filesystem.FilesystemStats
+		topErr    = late
+		tempErr   = late
+		fsErr     = late
+	)
+	if finished[0].Load() {
+		stats, topErr = top.stats, top.err
+	}
+	if finished[1].Load() {
+		tempStats, tempErr = temp.stats, temp.err
+	}
+	if finished[2].Load() {
+		fsStats, fsErr = fs.stats, fs.err
+	}
+
+	var partial []error
+	if tempErr != nil {
+		partial = append(partial, &CollectionError{Subsystem: subsystemTemperature, Err: tempErr})
+	}
+	if fsErr != nil {
+		partial = append(partial, &CollectionError{Subsystem: subsystemFilesystem, Err: fsErr})
+	}
+	if topErr != nil {
+		return nil, errors.Join(append([]error{&CollectionError{Subsystem: subsystemTop, Err: topErr}}, partial...)...)
+	}
+	stats.Timestamp = time.Now()
+
+	if tempErr != nil {
+		p.log.Warnf("Failed to read temperature stats: %v", tempErr)
+		// Initialize empty temperature stats structure to avoid null in logs
+		tempStats = &temperature.TemperatureStats{
+			Sensors: make(map[string]float64),
+		}
+
+		// Try to set default values for known sensors for testing
+		if os.Getenv("MOCK_TEMP") == "1" {
+			tempStats.Sensors["cpu"] = 45.0
+			tempStats.Sensors["board"] = 40.0
+		}
+	}
+	stats.Temperature = *tempStats
+	for name := range tempStats.Rescaled {
+		if !p.rescaled[name] {
+			p.rescaled[name] = true
+			p.log.Infof("Sensor %s (%s) doesn't report in the usual scale of its source, read as %.1f°C", name, tempStats.Sources[name], tempStats.Sensors[name])
+		}
+	}
+
+	if fsErr != nil {
+		p.log.Warnf("Failed to read filesystem stats: %v", fsErr)
+		fsStats = &filesystem.FilesystemStats{
+			Filesystems: make(map[string]filesystem.Filesystem),
+		}
+	}
+
+	for _, dup := range fsStats.Duplicates {
+		kept := fsStats.Filesystems[dup.MountPoint]
+		p.log.Warnf("Duplicate mount point %s: ignoring %s (%d bytes), keeping %s (%d bytes)",
+			dup.MountPoint, dup.Device, dup.Size, kept.Device, kept.Size)
+	}
+
+	// Convert filesystem stats to parser format
+	stats.Filesystem = make(map[string]parser.FilesystemStats)
+	for mountPoint, fs := range fsStats.Filesystems {
+		stats.Filesystem[mountPoint] = parser.FilesystemStats{
+			Device:     fs.Device,
+			Size:       fs.Size,
+			Used:       fs.Used,
+			Available:  fs.Available,
+			UsedPct:    fs.UsedPct,
+			MountPoint: fs.MountPoint,
+			Critical:   fs.Critical,
+		}
+	}
+
+	// Debug info to track sensors detected
+	if len(tempStats.Sensors) > 0 {
+		p.log.Infof("Temperature sensors detected: %v", tempStats.String())
+	} else {
+		p.log.Warnf("No temperature sensors detected")
+	}
+
+	// Debug info for filesystem stats
+	if len(fsStats.Filesystems) > 0 {
+		p.log.Infof("Filesystem stats: %v", fsStats.String())
+	} else {
+		p.log.Warnf("No filesystem stats detected")
+	}
+
+	return stats, errors.Join(partial...)
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+	"github.com/sirupsen/logrus"
+)
+
+// busyBoxTop is a minimal busybox top output
+const busyBoxTop = `Mem: 1024K used, 1024K free, 0K shrd, 0K buff, 0K cached
+CPU:  10% usr   5% sys   0% nic  85% idle   0% io   0% irq   0% sirq
+Load average: 0.10 0.20 0.30 1/10 100
+  PID  PPID USER     STAT   VSZ %VSZ CPU %CPU COMMAND
+    1     0 root     S     1024  0.1   0  0.0 init
+`
+
+// dfOutput is a minimal df -B1 output
+const dfOutput = `Filesystem     1B-blocks       Used  Available Use% Mounted on
+/dev/sda1    10737418240 5368709120 5368709120  50% /
+`
+
+// fakeCommand writes an executable to dir that records its arguments, one
+// per line, to a file next to it and prints output. It returns the path of
+// the executable and of the arguments file.
+func fakeCommand(t *testing.T, dir, name, output string) (path, argsFile string) {
+	t.Helper()
+	path = filepath.Join(dir, name)
+	argsFile = path + ".args"
+	outputFile := path + ".out"
+	if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > '" + argsFile + "'\ncat '" + outputFile + "'\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, argsFile
+}
+
+// readArgs returns the arguments recorded by a fake command
+func readArgs(t *testing.T, argsFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("command was not run: %v", err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestTopProviderCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		topArgs     []string
+		wantTopArgs []string
+	}{
+		{name: "default arguments", topArgs: strings.Fields("-b -n 1"), wantTopArgs: []string{"-b", "-n", "1"}},
+		{name: "busybox memory detail", topArgs: strings.Fields("-b -n 1 -m"), wantTopArgs: []string{"-b", "-n", "1", "-m"}},
+		{name: "no arguments", wantTopArgs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			topPath, topArgsFile := fakeCommand(t, dir, "mytop", busyBoxTop)
+			dfPath, dfArgsFile := fakeCommand(t, dir, "mydf", dfOutput)
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			p := newTopProvider(topPath, tt.topArgs, dfPath, log)
+
+			stats, err := p.readTop(context.Background())
+			if err != nil {
+				t.Fatalf("readTop() error = %v", err)
+			}
+			if stats.CPU.User != 10 {
+				t.Errorf("CPU user = %v, want 10 from the configured top", stats.CPU.User)
+			}
+			if got := readArgs(t, topArgsFile); strings.Join(got, " ") != strings.Join(tt.wantTopArgs, " ") {
+				t.Errorf("top arguments = %q, want %q", got, tt.wantTopArgs)
+			}
+
+			fsStats, err := p.readFilesystem(context.Background())
+			if err != nil {
+				t.Fatalf("readFilesystem() error = %v", err)
+			}
+			if _, ok := fsStats.Filesystems["/"]; !ok {
+				t.Errorf("filesystems = %v, want / from the configured df", fsStats.Filesystems)
+			}
+			if got := readArgs(t, dfArgsFile); strings.Join(got, " ") != "-B1" {
+				t.Errorf("df arguments = %q, want [-B1]", got)
+			}
+		})
+	}
+}
+
+func TestHungCommandsCancelled(t *testing.T) {
+	dir := t.TempDir()
+	hung := filepath.Join(dir, "hung")
+	if err := os.WriteFile(hung, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	top, _ := fakeCommand(t, dir, "top", busyBoxTop)
+	df, _ := fakeCommand(t, dir, "df", dfOutput)
+
+	tests := []struct {
+		name          string
+		topPath       string
+		dfPath        string
+		tempHangs     bool
+		wantErr       string
+		wantSubsystem string
+		wantStats     bool // Whether the top sample survives
+	}{
+		{name: "top hangs", topPath: hung, dfPath: df, wantErr: "did not finish in time", wantSubsystem: subsystemTop},
+		{name: "df hangs", topPath: top, dfPath: hung, wantErr: "did not finish in time", wantSubsystem: subsystemFilesystem, wantStats: true},
+		{name: "temperature hangs", topPath: top, dfPath: df, tempHangs: true, wantErr: "did not finish in time", wantSubsystem: subsystemTemperature, wantStats: true},
+		{name: "neither hangs", topPath: top, dfPath: df, wantStats: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			p := newTopProvider(tt.topPath, nil, tt.dfPath, log)
+			release := make(chan struct{})
+			defer close(release)
+			p.readTemperature = func() (*temperature.TemperatureStats, error) {
+				if tt.tempHangs {
+					<-release
+				}
+				return &temperature.TemperatureStats{Sensors: map[string]float64{}}, nil
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			stats, err := p.Collect(ctx)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Collect() took %v, want it cancelled at the timeout", elapsed)
+			}
+			if got := stats != nil; got != tt.wantStats {
+				t.Errorf("Collect() stats = %v, want stats %v", stats, tt.wantStats)
+			} else if stats != nil && stats.CPU.Idle == 0 {
+				t.Errorf("Collect() cpu = %+v, want the top sample", stats.CPU)
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Collect() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Collect() error = %v, want %q", err, tt.wantErr)
+			}
+			var subsystems []string
+			for _, collectionErr := range collectionErrors(err) {
+				subsystems = append(subsystems, collectionErr.Subsystem)
+			}
+			if !slices.Contains(subsystems, tt.wantSubsystem) {
+				t.Errorf("failed subsystems = %v, want %s among them", subsystems, tt.wantSubsystem)
+			}
+		})
+	}
+}
+
+// slowCommand writes an executable to dir that prints output after delay
+func slowCommand(t *testing.T, dir, name, output string, delay time.Duration) string {
+	t.Helper()
+	path, _ := fakeCommand(t, dir, name+"-fast", output)
+	slow := filepath.Join(dir, name)
+	script := fmt.Sprintf("#!/bin/sh\nsleep %g\nexec '%s'\n", delay.Seconds(), path)
+	if err := os.WriteFile(slow, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return slow
+}
+
+func TestCollectConcurrently(t *testing.T) {
+	tests := []struct {
+		name      string
+		topDelay  time.Duration
+		tempDelay time.Duration
+		dfDelay   time.Duration
+	}{
+		{name: "df slowest", topDelay: 200 * time.Millisecond, tempDelay: 200 * time.Millisecond, dfDelay: 400 * time.Millisecond},
+		{name: "top slowest", topDelay: 400 * time.Millisecond, tempDelay: 200 * time.Millisecond, dfDelay: 200 * time.Millisecond},
+		{name: "temperature slowest", topDelay: 200 * time.Millisecond, tempDelay: 400 * time.Millisecond, dfDelay: 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			top := slowCommand(t, dir, "top", busyBoxTop, tt.topDelay)
+			df := slowCommand(t, dir, "df", dfOutput, tt.dfDelay)
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			p := newTopProvider(top, nil, df, log)
+			p.readTemperature = func() (*temperature.TemperatureStats, error) {
+				time.Sleep(tt.tempDelay)
+				return &temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 40}}, nil
+			}
+
+			start := time.Now()
+			stats, err := p.Collect(context.Background())
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if stats.CPU.User != 10 || len(stats.Filesystem) == 0 || len(stats.Temperature.Sensors) == 0 {
+				t.Errorf("Collect() = %+v, want top, filesystem and temperature combined", stats)
+			}
+
+			slowest := max(tt.topDelay, tt.tempDelay, tt.dfDelay)
+			sum := tt.topDelay + tt.tempDelay + tt.dfDelay
+			if elapsed < slowest || elapsed >= sum-100*time.Millisecond {
+				t.Errorf("Collect() took %v, want near the slowest %v, well below the sum %v", elapsed, slowest, sum)
+			}
+		})
+	}
+}
+
+func TestCollectionErrorSubsystem(t *testing.T) {
+	sensorsErr := errors.New("no sensors found")
+
+	tests := []struct {
+		name           string
+		topFails       bool
+		dfFails        bool
+		tempErr        error
+		wantStats      bool
+		wantSubsystems []string
+	}{
+		{name: "temperature fails", tempErr: sensorsErr, wantStats: true, wantSubsystems: []string{subsystemTemperature}},
+		{name: "filesystem fails", dfFails: true, wantStats: true, wantSubsystems: []string{subsystemFilesystem}},
+		{name: "temperature and filesystem fail", dfFails: true, tempErr: sensorsErr, wantStats: true, wantSubsystems: []string{subsystemTemperature, subsystemFilesystem}},
+		{name: "top fails", topFails: true, wantSubsystems: []string{subsystemTop}},
+		{name: "nothing fails", wantStats: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			top, _ := fakeCommand(t, dir, "top", busyBoxTop)
+			df, _ := fakeCommand(t, dir, "df", dfOutput)
+			if tt.topFails {
+				top = filepath.Join(dir, "missing-top")
+			}
+			if tt.dfFails {
+				df = filepath.Join(dir, "missing-df")
+			}
+
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			p := newTopProvider(top, nil, df, log)
+			p.readTemperature = func() (*temperature.TemperatureStats, error) {
+				if tt.tempErr != nil {
+					return nil, tt.tempErr
+				}
+				return &temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 40}}, nil
+			}
+
+			stats, err := p.Collect(context.Background())
+			if got := stats != nil; got != tt.wantStats {
+				t.Errorf("Collect() returned stats = %v, want %v", got, tt.wantStats)
+			}
+			var subsystems []string
+			for _, collectionErr := range collectionErrors(err) {
+				subsystems = append(subsystems, collectionErr.Subsystem)
+			}
+			if !slices.Equal(subsystems, tt.wantSubsystems) {
+				t.Errorf("failed subsystems = %q, want %q", subsystems, tt.wantSubsystems)
+			}
+			if tt.tempErr != nil && !errors.Is(err, tt.tempErr) {
+				t.Errorf("Collect() error = %v, want it to wrap %v", err, tt.tempErr)
+			}
+		})
+	}
+}
+
+func TestDuplicateMountPointLogged(t *testing.T) {
+	const bindMount = dfOutput + "/dev/sdb1     1073741824  536870912  536870912  50% /\n"
+
+	tests := []struct {
+		name    string
+		df      string
+		wantLog string
+	}{
+		{name: "bind mount over /", df: bindMount, wantLog: "Duplicate mount point /: ignoring /dev/sdb1 (1073741824 bytes), keeping /dev/sda1 (10737418240 bytes)"},
+		{name: "no duplicates", df: dfOutput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			top, _ := fakeCommand(t, dir, "top", busyBoxTop)
+			df, _ := fakeCommand(t, dir, "df", tt.df)
+
+			var out bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&out)
+			p := newTopProvider(top, nil, df, log)
+			p.readTemperature = func() (*temperature.TemperatureStats, error) {
+				return &temperature.TemperatureStats{Sensors: map[string]float64{}}, nil
+			}
+
+			stats, err := p.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if got := stats.Filesystem["/"].Device; got != "/dev/sda1" {
+				t.Errorf("/ device = %q, want /dev/sda1", got)
+			}
+			logged := strings.Contains(out.String(), "Duplicate mount point")
+			if logged != (tt.wantLog != "") || !strings.Contains(out.String(), tt.wantLog) {
+				t.Errorf("log = %q, want %q", out.String(), tt.wantLog)
+			}
+		})
+	}
+}
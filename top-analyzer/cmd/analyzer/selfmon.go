@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
+)
+
+// selfTrimKeep is the number of samples kept when the analyzer trims its
+// own history after exceeding -self-mem-limit
+const selfTrimKeep = 2
+
+// readSelfRSS returns the resident memory of the analyzer process in bytes
+func readSelfRSS() (int64, error) {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open /proc/self/status: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Example: VmRSS:	   12345 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid VmRSS value %q: %w", fields[1], err)
+			}
+			return kb * 1024, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read /proc/self/status: %w", err)
+	}
+	return 0, fmt.Errorf("VmRSS not found in /proc/self/status")
+}
+
+// checkSelfMemory warns when the analyzer's own resident memory exceeds
+// -self-mem-limit and, with -self-trim, trims the analyzer history
+func (m *monitor) checkSelfMemory() {
+	if *selfMemLimit <= 0 {
+		return
+	}
+
+	rss, err := m.readSelfRSS()
+	if err != nil {
+		m.log.Debugf("Failed to read own memory usage: %v", err)
+		return
+	}
+
+	limit := int64(*selfMemLimit) * 1024 * 1024
+	if rss <= limit {
+		return
+	}
+
+	m.log.Warnf("Analyzer memory usage %s exceeds limit of %s", bytesize.Format(rss, bytesize.Mega), bytesize.Format(limit, bytesize.Mega))
+	if *selfTrim {
+		m.analyzer.TrimHistory(selfTrimKeep)
+		m.log.Warnf("Trimmed analyzer history to the last %d samples", selfTrimKeep)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestCheckSelfMemory(t *testing.T) {
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name        string
+		limit       int
+		trim        bool
+		rss         int64
+		rssErr      error
+		wantWarning bool
+		wantHistory int
+	}{
+		{name: "below the limit", limit: 100, trim: true, rss: 50 * mb, wantHistory: 5},
+		{name: "above the limit", limit: 100, rss: 500 * mb, wantWarning: true, wantHistory: 5},
+		{name: "above the limit with trim", limit: 100, trim: true, rss: 500 * mb, wantWarning: true, wantHistory: selfTrimKeep},
+		{name: "limit disabled", limit: 0, trim: true, rss: 500 * mb, wantHistory: 5},
+		{name: "unreadable", limit: 100, trim: true, rssErr: errors.New("no /proc"), wantHistory: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, selfMemLimit, tt.limit)
+			setFlag(t, selfTrim, tt.trim)
+			m := newTestMonitor(t, &scriptedProvider{})
+			var logged bytes.Buffer
+			m.log.SetOutput(&logged)
+			m.readSelfRSS = func() (int64, error) { return tt.rss, tt.rssErr }
+			for i := 0; i < 5; i++ {
+				m.analyzer.AddStats(&parser.SystemStats{})
+			}
+
+			m.checkSelfMemory()
+
+			if got := strings.Contains(logged.String(), "exceeds limit"); got != tt.wantWarning {
+				t.Errorf("warning logged = %v, want %v, log:\n%s", got, tt.wantWarning, logged.String())
+			}
+			if got := len(m.analyzer.GetHistory()); got != tt.wantHistory {
+				t.Errorf("analyzer history = %d samples, want %d", got, tt.wantHistory)
+			}
+		})
+	}
+}
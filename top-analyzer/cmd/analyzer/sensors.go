@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+// runListSensors prints the discovered temperature sensors with their current
+// value and source path to w. It returns the process exit code.
+func runListSensors(w io.Writer) int {
+	stats, err := temperature.ReadTemperatureStats()
+	if err != nil {
+		fmt.Fprintf(w, "No temperature sensors found: %v\n", err)
+		return 1
+	}
+
+	names := make([]string, 0, len(stats.Sensors))
+	for name := range stats.Sensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "%-20s %6.1f°C  %s\n", name, stats.Sensors[name], stats.Sources[name])
+	}
+
+	return 0
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/fleet"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/prometheus"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sanitize"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+	"github.com/sirupsen/logrus"
+)
+
+// apiServer serves the latest summary, and the fleet view when peers are
+// configured, over HTTP. The sampling loop publishes pre-marshaled JSON so
+// handlers never touch the live summary, and saves the snapshots requested
+// on the snapshots channel.
+type apiServer struct {
+	mu        sync.RWMutex
+	stats     []byte
+	health    []byte
+	metrics   []byte
+	fleet     []byte
+	snapshots chan snapshotRequest
+}
+
+// snapshotRequest asks the sampling loop for a snapshot annotated with note.
+// The loop sends the snapshot filename on done, or an empty string on failure.
+type snapshotRequest struct {
+	note string
+	done chan string
+}
+
+// snapshotResponse is the body of the /snapshot endpoint
+type snapshotResponse struct {
+	File string `json:"file"`
+}
+
+func newAPIServer() *apiServer {
+	return &apiServer{snapshots: make(chan snapshotRequest)}
+}
+
+// healthResponse is the body of the /health endpoint
+type healthResponse struct {
+	Score int    `json:"score"`
+	Band  string `json:"band"`
+}
+
+// publishStats stores the current summary for the /stats endpoint, its
+// health score for the /health endpoint and its gauges for the /metrics
+// endpoint
+func (a *apiServer) publishStats(s *summary.SystemSummary) error {
+	// A NaN or Inf anywhere would make the marshal fail and /stats a 500
+	sanitize.Floats(s)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	health, err := json.Marshal(healthResponse{Score: s.HealthScore(), Band: s.HealthBand()})
+	if err != nil {
+		return err
+	}
+	var metrics bytes.Buffer
+	if err := prometheus.Write(&metrics, summaryGauges(s)); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.stats = data
+	a.health = health
+	a.metrics = metrics.Bytes()
+	a.mu.Unlock()
+	return nil
+}
+
+// publishFleet stores the merged peer summary for the /fleet endpoint
+func (a *apiServer) publishFleet(f *fleet.Summary) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.fleet = data
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		a.mu.RLock()
+		data := a.stats
+		a.mu.RUnlock()
+		writeJSON(w, data)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		a.mu.RLock()
+		data := a.health
+		a.mu.RUnlock()
+		writeJSON(w, data)
+	})
+	mux.HandleFunc("/fleet", func(w http.ResponseWriter, r *http.Request) {
+		a.mu.RLock()
+		data := a.fleet
+		a.mu.RUnlock()
+		writeJSON(w, data)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		a.mu.RLock()
+		data := a.metrics
+		a.mu.RUnlock()
+		if data == nil {
+			http.Error(w, "no data collected yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", prometheus.ContentType)
+		w.Write(data)
+	})
+	mux.HandleFunc("/snapshot", a.handleSnapshot)
+	return mux
+}
+
+// handleSnapshot has the sampling loop save a snapshot annotated with the
+// note query parameter and returns its filename
+func (a *apiServer) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := snapshotRequest{note: r.URL.Query().Get("note"), done: make(chan string, 1)}
+	select {
+	case a.snapshots <- req:
+	case <-r.Context().Done():
+		return
+	}
+
+	var filename string
+	select {
+	case filename = <-req.done:
+	case <-r.Context().Done():
+		return
+	}
+	if filename == "" {
+		http.Error(w, "failed to save snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(snapshotResponse{File: filename})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, data)
+}
+
+func writeJSON(w http.ResponseWriter, data []byte) {
+	if data == nil {
+		http.Error(w, "no data collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// startAPI serves the REST API on -listen and starts polling -peers
+func startAPI(api *apiServer, log *logrus.Logger) {
+	if *listenAddr != "" {
+		go func() {
+			log.Infof("Serving REST API on %s", *listenAddr)
+			if err := http.ListenAndServe(*listenAddr, api.handler()); err != nil {
+				log.Errorf("REST API stopped: %v", err)
+			}
+		}()
+	}
+
+	var peerURLs []string
+	for _, peer := range strings.Split(*peers, ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			peerURLs = append(peerURLs, peer)
+		}
+	}
+	if len(peerURLs) == 0 {
+		return
+	}
+
+	aggregator := fleet.New(peerURLs, *interval)
+	go func() {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			f := aggregator.Collect(context.Background())
+			if err := api.publishFleet(f); err != nil {
+				log.Errorf("Failed to publish fleet summary: %v", err)
+			}
+			log.Debugf("Fleet: %d reachable, %d unreachable, worst stress %.1f%% (%s)",
+				f.Reachable, f.Unreachable, f.WorstStress, f.WorstHost)
+		}
+	}()
+}
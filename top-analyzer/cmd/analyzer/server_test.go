@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSnapshotNote(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		note       string
+		wantStatus int
+	}{
+		{name: "note", method: http.MethodPost, note: "investigating INC-42: fan noise", wantStatus: http.StatusOK},
+		{name: "multi-line note", method: http.MethodPost, note: "disk swapped\nrebooted at 14:00", wantStatus: http.StatusOK},
+		{name: "no note", method: http.MethodPost, wantStatus: http.StatusOK},
+		{name: "GET", method: http.MethodGet, note: "ignored", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, snapshotDir, t.TempDir())
+			log := logrus.New()
+			log.SetOutput(io.Discard)
+			analyzer := trend.New(10)
+			analyzer.AddStats(&parser.SystemStats{CPU: parser.CPU{User: 10}})
+
+			// Stand in for the sampling loop
+			api := newAPIServer()
+			go func() {
+				for req := range api.snapshots {
+					req.done <- saveSnapshot(analyzer, req.note, log)
+				}
+			}()
+			defer close(api.snapshots)
+			server := httptest.NewServer(api.handler())
+			defer server.Close()
+
+			req, err := http.NewRequest(tt.method, server.URL+"/snapshot?note="+url.QueryEscape(tt.note), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body snapshotResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			snapshot, err := trend.LoadSnapshot(body.File)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+			if snapshot.Note != tt.note {
+				t.Errorf("snapshot note = %q, want %q", snapshot.Note, tt.note)
+			}
+		})
+	}
+}
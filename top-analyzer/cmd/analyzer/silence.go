@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dailyWindow is a recurring time-of-day window, possibly spanning midnight
+type dailyWindow struct {
+	start time.Duration // offset from midnight
+	end   time.Duration
+}
+
+// silencer decides whether alerts and crash dumps are suppressed because
+// the system is in a planned maintenance window
+type silencer struct {
+	until   time.Time
+	windows []dailyWindow
+}
+
+// newSilencer parses the -silence-until timestamp (RFC3339) and the
+// comma separated -silence-window list of daily "HH:MM-HH:MM" windows
+func newSilencer(until string, windows string) (*silencer, error) {
+	s := &silencer{}
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -silence-until %q: %w", until, err)
+		}
+		s.until = t
+	}
+
+	for _, w := range strings.Split(windows, ",") {
+		w = strings.TrimSpace(w)
+		if w == "" {
+			continue
+		}
+		bounds := strings.Split(w, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid -silence-window %q: expected HH:MM-HH:MM", w)
+		}
+		start, err := parseTimeOfDay(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid -silence-window %q: %w", w, err)
+		}
+		end, err := parseTimeOfDay(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid -silence-window %q: %w", w, err)
+		}
+		s.windows = append(s.windows, dailyWindow{start: start, end: end})
+	}
+
+	return s, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Silenced reports whether alerts are suppressed at the given time
+func (s *silencer) Silenced(now time.Time) bool {
+	if now.Before(s.until) {
+		return true
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+	for _, w := range s.windows {
+		if w.start <= w.end {
+			if offset >= w.start && offset < w.end {
+				return true
+			}
+		} else if offset >= w.start || offset < w.end {
+			// Window spans midnight
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+func TestSilenced(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name    string
+		until   string
+		windows string
+		now     time.Time
+		want    bool
+	}{
+		{name: "no silence", now: at(12, 0), want: false},
+		{name: "before silence-until", until: "2024-03-01T13:00:00Z", now: at(12, 0), want: true},
+		{name: "after silence-until", until: "2024-03-01T11:00:00Z", now: at(12, 0), want: false},
+		{name: "inside daily window", windows: "02:00-04:00", now: at(3, 0), want: true},
+		{name: "at the end of a daily window", windows: "02:00-04:00", now: at(4, 0), want: false},
+		{name: "outside daily window", windows: "02:00-04:00", now: at(5, 0), want: false},
+		{name: "window spanning midnight before it", windows: "23:00-01:00", now: at(23, 30), want: true},
+		{name: "window spanning midnight after it", windows: "23:00-01:00", now: at(0, 30), want: true},
+		{name: "second of several windows", windows: "02:00-03:00, 12:00-12:30", now: at(12, 15), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newSilencer(tt.until, tt.windows)
+			if err != nil {
+				t.Fatalf("newSilencer() error = %v", err)
+			}
+			if got := s.Silenced(tt.now); got != tt.want {
+				t.Errorf("Silenced(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSilencerInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		until   string
+		windows string
+	}{
+		{name: "until not RFC3339", until: "2024-03-01 13:00"},
+		{name: "window without end", windows: "02:00"},
+		{name: "window with invalid time", windows: "02:00-25:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newSilencer(tt.until, tt.windows); err == nil {
+				t.Errorf("newSilencer(%q, %q) error = nil, want an error", tt.until, tt.windows)
+			}
+		})
+	}
+}
+
+func TestSilenceWindowSuppressesCrashDumps(t *testing.T) {
+	setFlag(t, calibration, 0)
+	// A sensor over the absolute threshold requires a crash dump
+	hot := func() collection {
+		return collection{stats: &parser.SystemStats{Temperature: temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 95}}}}
+	}
+	m := newTestMonitor(t, &scriptedProvider{collections: []collection{hot(), hot(), hot()}})
+	// The trend needs two samples
+	m.silence.until = time.Now().Add(time.Hour)
+	m.sample()
+
+	tests := []struct {
+		name         string
+		until        time.Time
+		wantNewDumps bool
+	}{
+		{name: "inside the silence window", until: time.Now().Add(time.Hour), wantNewDumps: false},
+		{name: "after the silence window ended", until: time.Now().Add(-time.Second), wantNewDumps: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := crashDumps(t)
+			m.silence.until = tt.until
+			m.sample()
+			if got := crashDumps(t) > before; got != tt.wantNewDumps {
+				t.Errorf("crash dump written = %v, want %v", got, tt.wantNewDumps)
+			}
+		})
+	}
+}
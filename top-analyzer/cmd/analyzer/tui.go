@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+)
+
+// ANSI escape sequences used by the dashboard
+const (
+	ansiClear  = "\033[H\033[2J"
+	ansiReset  = "\033[0m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiRed    = "\033[31m"
+)
+
+// dashboardWarnStress is the system stress from which the dashboard turns
+// yellow. It turns red at -stress-crash-threshold.
+const dashboardWarnStress = 50
+
+// dashboard redraws a compact view of the summary every interval. Escape
+// codes are only emitted when the output is a terminal.
+type dashboard struct {
+	w    io.Writer
+	ansi bool
+}
+
+// newDashboard creates a dashboard writing to f, using ANSI escapes when f
+// is a terminal
+func newDashboard(f *os.File) *dashboard {
+	return &dashboard{w: f, ansi: isTerminal(f)}
+}
+
+// isTerminal reports whether f is a character device such as a TTY
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// color wraps text in the escape code when colors are enabled
+func (d *dashboard) color(code, text string) string {
+	if !d.ansi {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// stressColor returns the color of a system stress value
+func stressColor(stress float64) string {
+	switch {
+	case stress >= *stressCrash:
+		return ansiRed
+	case stress >= dashboardWarnStress:
+		return ansiYellow
+	default:
+		return ansiGreen
+	}
+}
+
+// render draws the dashboard for the summary s
+func (d *dashboard) render(s *summary.SystemSummary) {
+	var sb strings.Builder
+	if d.ansi {
+		sb.WriteString(ansiClear)
+	}
+
+	stressCode := stressColor(s.SystemStress)
+	sb.WriteString(fmt.Sprintf("top-analyzer  %s\n", s.Timestamp.Format("2006-01-02 15:04:05")))
+	sb.WriteString(fmt.Sprintf("Stress %s  Health %s\n",
+		d.color(stressCode, fmt.Sprintf("%5.1f%%", s.SystemStress)),
+		d.color(stressCode, fmt.Sprintf("%d (%s)", s.HealthScore(), s.HealthBand()))))
+	sb.WriteString(fmt.Sprintf("CPU    %5.1f%% user %5.1f%% sys   Load %.2f %.2f %.2f\n",
+		s.CPU.User, s.CPU.System, s.CPU.Load1, s.CPU.Load5, s.CPU.Load15))
+	sb.WriteString(fmt.Sprintf("Memory %5.1f%% of %s\n", s.Memory.UsedPc, formatMemory(s.Memory.Total, *memUnit)))
+	sb.WriteString(fmt.Sprintf("Procs  %d total, %d running, %d D, %d Z\n",
+		s.Processes.Total, s.Processes.Running, s.Processes.Uninterr, s.Processes.Zombie))
+
+	if len(s.Temperature.Sensors) > 0 {
+		sb.WriteString(fmt.Sprintf("Temp   max %.1f°C avg %.1f°C\n", s.Temperature.MaxTemp, s.Temperature.AvgTemp))
+	}
+
+	mounts := make([]string, 0, len(s.Filesystem.Partitions))
+	for mount := range s.Filesystem.Partitions {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+	for _, mount := range mounts {
+		fs := s.Filesystem.Partitions[mount]
+		line := fmt.Sprintf("Disk   %-12s %5.1f%% used", mount, fs.UsedPct)
+		if fs.Critical {
+			line = d.color(ansiRed, line+" CRITICAL")
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	io.WriteString(d.w, sb.String())
+}
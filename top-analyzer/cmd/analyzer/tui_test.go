@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+)
+
+func TestDashboardNonTTY(t *testing.T) {
+	setFlag(t, stressCrash, 85)
+
+	tests := []struct {
+		name     string
+		stress   float64
+		critical bool
+		want     string
+	}{
+		{name: "healthy", stress: 10, want: "Stress  10.0%"},
+		{name: "warning", stress: 60, want: "Stress  60.0%"},
+		{name: "critical stress and partition", stress: 95, critical: true, want: "CRITICAL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			s := summary.New()
+			s.SystemStress = tt.stress
+			s.Filesystem.Partitions["/"] = struct {
+				Device     string  `json:"device"`
+				Size       int64   `json:"size"`
+				Used       int64   `json:"used"`
+				Available  int64   `json:"available"`
+				UsedPct    float64 `json:"used_percent"`
+				FreeSpace  float64 `json:"free_space_percent"`
+				MountPoint string  `json:"mount_point"`
+				Critical   bool    `json:"critical"`
+			}{UsedPct: 95, MountPoint: "/", Critical: tt.critical}
+
+			d := newDashboard(f)
+			if d.ansi {
+				t.Fatal("a regular file was taken for a terminal")
+			}
+			d.render(s)
+
+			out, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(out), "\033") {
+				t.Errorf("output contains escape codes:\n%q", out)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("output = %q, want it to contain %q", out, tt.want)
+			}
+		})
+	}
+}
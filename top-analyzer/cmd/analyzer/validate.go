@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+)
+
+// runValidateSnapshot loads the snapshot file at path, reports its problems
+// to w and returns the process exit code: 0 when it is valid, 1 otherwise
+func runValidateSnapshot(w io.Writer, path string) int {
+	snapshot, err := trend.LoadSnapshot(path)
+	if err != nil {
+		fmt.Fprintf(w, "FAIL %s: %v\n", path, err)
+		return 1
+	}
+
+	problems := snapshot.Validate()
+	for _, problem := range problems {
+		fmt.Fprintf(w, "FAIL %s: %s\n", path, problem)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+
+	fmt.Fprintf(w, "OK   %s: schema version %d, %d samples\n", path, snapshot.SchemaVersion, len(snapshot.Stats))
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
+)
+
+func TestRunValidateSnapshot(t *testing.T) {
+	analyzer := trend.New(10)
+	for _, user := range []float64{10, 20} {
+		analyzer.AddStats(&parser.SystemStats{
+			CPU:         parser.CPU{User: user},
+			Processes:   []parser.Process{{PID: 1, Command: "init", CPUPercent: user}},
+			Filesystem:  map[string]parser.FilesystemStats{"/": {Device: "/dev/sda1", Size: 10 << 30, UsedPct: 50, MountPoint: "/"}},
+			Temperature: temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 45}},
+		})
+	}
+	valid := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := analyzer.SaveSnapshot(valid); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	data, err := os.ReadFile(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		wantCode int
+		wantOut  string
+	}{
+		{name: "valid snapshot", content: string(data), wantCode: 0, wantOut: "OK"},
+		{name: "truncated snapshot", content: string(data[:len(data)/2]), wantCode: 1, wantOut: "failed to parse snapshot"},
+		{name: "empty file", content: "", wantCode: 1, wantOut: "failed to parse snapshot"},
+		{
+			name:     "unsupported schema version",
+			content:  strings.Replace(string(data), `"SchemaVersion": 1`, `"SchemaVersion": 99`, 1),
+			wantCode: 1,
+			wantOut:  "unsupported schema version 99",
+		},
+		{name: "no samples", content: `{"SchemaVersion": 1, "Timestamp": "2026-01-01T00:00:00Z"}`, wantCode: 1, wantOut: "no samples"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if code := runValidateSnapshot(&out, path); code != tt.wantCode {
+				t.Errorf("runValidateSnapshot() = %d, want %d\n%s", code, tt.wantCode, out.String())
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
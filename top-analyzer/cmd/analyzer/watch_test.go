@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestCriticalProcessExitDumps(t *testing.T) {
+	processes := func(commands ...string) *parser.SystemStats {
+		stats := &parser.SystemStats{}
+		for i, command := range commands {
+			stats.Processes = append(stats.Processes, parser.Process{PID: 100 + i, Command: command})
+		}
+		return stats
+	}
+	running := processes("init", "/usr/sbin/watchdog", "sshd")
+	exited := processes("init", "sshd")
+
+	tests := []struct {
+		name          string
+		watch         string
+		watchCritical string
+		samples       []*parser.SystemStats
+		wantDumps     []bool // Whether each sample wrote a crash dump
+	}{
+		{name: "critical process exits", watchCritical: "watchdog", samples: []*parser.SystemStats{running, exited}, wantDumps: []bool{false, true}},
+		{name: "critical process keeps running", watchCritical: "watchdog", samples: []*parser.SystemStats{running, running}, wantDumps: []bool{false, false}},
+		{name: "critical process never ran", watchCritical: "watchdog", samples: []*parser.SystemStats{exited, exited}, wantDumps: []bool{false, false}},
+		// Missing watched processes only alert once calibrated
+		{name: "watched process exits", watch: "watchdog", samples: []*parser.SystemStats{running, exited}, wantDumps: []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, watch, tt.watch)
+			setFlag(t, watchCritical, tt.watchCritical)
+			var collections []collection
+			for _, stats := range tt.samples {
+				collections = append(collections, collection{stats: stats})
+			}
+			m := newTestMonitor(t, &scriptedProvider{collections: collections})
+
+			for i, want := range tt.wantDumps {
+				before := crashDumps(t)
+				m.sample()
+				if got := crashDumps(t) > before; got != want {
+					t.Errorf("sample %d wrote a crash dump = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
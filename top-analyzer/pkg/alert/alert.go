@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Severity of an alert item
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Critical:
+		return "critical"
+	case Warning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// MarshalText encodes the severity by name in JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Item is a single condition detected in an evaluation
+type Item struct {
+	Severity Severity `json:"severity"`
+	Source   string   `json:"source"` // Subsystem the condition was detected in, e.g. "filesystem"
+	Message  string   `json:"message"`
+}
+
+func (i Item) String() string {
+	return fmt.Sprintf("[%s] %s: %s", i.Severity, i.Source, i.Message)
+}
+
+// Alert bundles all conditions detected in one evaluation, so a single root
+// cause firing several checks results in one notification
+type Alert struct {
+	Time      time.Time `json:"time"`
+	Host      string    `json:"host"`
+	Severity  Severity  `json:"severity"` // Highest severity of the items
+	Stress    float64   `json:"system_stress"`
+	CrashFile string    `json:"crash_file,omitempty"`
+	Items     []Item    `json:"items"`
+}
+
+// Group bundles the items of one evaluation into an alert. It returns nil
+// when there are no items.
+func Group(now time.Time, host string, stress float64, items []Item) *Alert {
+	if len(items) == 0 {
+		return nil
+	}
+	a := &Alert{
+		Time:   now,
+		Host:   host,
+		Stress: stress,
+		Items:  items,
+	}
+	for _, item := range items {
+		if item.Severity > a.Severity {
+			a.Severity = item.Severity
+		}
+	}
+	return a
+}
+
+// Title is a one line description of the alert
+func (a *Alert) Title() string {
+	return fmt.Sprintf("%s: %d condition(s) detected on %s (stress %.1f%%)", a.Severity, len(a.Items), a.Host, a.Stress)
+}
+
+// Notifier delivers alerts to an external system
+type Notifier interface {
+	Notify(ctx context.Context, a *Alert) error
+}
@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Types of the events
+const (
+	EventAlert = "alert" // Conditions detected in an evaluation
+	EventCrash = "crash" // Panic recovered by the analyzer
+)
+
+// Event is a line of the event log
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	Severity  Severity  `json:"severity"`
+	Value     float64   `json:"value"` // System stress when the event occurred
+	CrashFile string    `json:"crash_file,omitempty"`
+	Message   string    `json:"message,omitempty"` // Panic value of a crash
+	Items     []Item    `json:"items,omitempty"`   // Conditions of an alert
+}
+
+// AlertEvent returns the event of an alert
+func AlertEvent(a *Alert) Event {
+	return Event{
+		Time:      a.Time,
+		Type:      EventAlert,
+		Severity:  a.Severity,
+		Value:     a.Stress,
+		CrashFile: a.CrashFile,
+		Items:     a.Items,
+	}
+}
+
+// EventLog appends one JSON line per event to a file, apart from the general
+// log, so the timeline of an incident can be extracted without sifting
+// through debug and info lines. The file is opened for every event, so it
+// can be moved away by logrotate at any time.
+type EventLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewEventLog creates an event log appending to path
+func NewEventLog(path string) *EventLog {
+	return &EventLog{path: path}
+}
+
+// Record appends the event
+func (l *EventLog) Record(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,82 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventLogRecord(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	a := Group(now, "host", 72, []Item{
+		{Severity: Warning, Source: "cpu", Message: "CPU anomaly detected"},
+		{Severity: Critical, Source: "filesystem", Message: "Low disk space on /"},
+	})
+	a.CrashFile = "/var/lib/top-analyzer/crashes/crash-2026-10-15-12-00-00.json"
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   []map[string]any // Fields of each line
+	}{
+		{
+			name:   "alert",
+			events: []Event{AlertEvent(a)},
+			want: []map[string]any{{
+				"time":       "2026-10-15T12:00:00Z",
+				"type":       "alert",
+				"severity":   "critical",
+				"value":      72.0,
+				"crash_file": a.CrashFile,
+				"items": []any{
+					map[string]any{"severity": "warning", "source": "cpu", "message": "CPU anomaly detected"},
+					map[string]any{"severity": "critical", "source": "filesystem", "message": "Low disk space on /"},
+				},
+			}},
+		},
+		{
+			name: "alert and crash appended",
+			events: []Event{
+				{Time: now, Type: EventAlert, Severity: Warning, Value: 40},
+				{Time: now.Add(time.Second), Type: EventCrash, Severity: Critical, Value: 40, CrashFile: "crash.json", Message: "injected panic"},
+			},
+			want: []map[string]any{
+				{"time": "2026-10-15T12:00:00Z", "type": "alert", "severity": "warning", "value": 40.0},
+				{"time": "2026-10-15T12:00:01Z", "type": "crash", "severity": "critical", "value": 40.0, "crash_file": "crash.json", "message": "injected panic"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.log")
+			log := NewEventLog(path)
+			for _, e := range tt.events {
+				if err := log.Record(e); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			var got []map[string]any
+			for scanner := bufio.NewScanner(file); scanner.Scan(); {
+				var fields map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+					t.Fatalf("event line %q isn't JSON: %v", scanner.Text(), err)
+				}
+				got = append(got, fields)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("event log = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Slack attachment colors by severity
+var slackColors = map[Severity]string{
+	Info:     "#36a64f",
+	Warning:  "#daa038",
+	Critical: "#d00000",
+}
+
+// Slack posts alerts to a Slack incoming webhook as an attachment colored by
+// severity, listing the alert items and a short metrics summary
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack creates a notifier posting to the Slack incoming webhook url
+func NewSlack(url string, timeout time.Duration) *Slack {
+	return &Slack{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields"`
+	Footer string       `json:"footer,omitempty"`
+	Ts     int64        `json:"ts"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Notify posts the alert
+func (s *Slack) Notify(ctx context.Context, a *Alert) error {
+	body, err := json.Marshal(slackPayload(a))
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	return postJSON(ctx, s.client, s.url, body)
+}
+
+// slackPayload formats an alert as a Slack message
+func slackPayload(a *Alert) slackMessage {
+	var text strings.Builder
+	for _, item := range a.Items {
+		fmt.Fprintf(&text, "• *%s* %s\n", item.Severity, item.Message)
+	}
+
+	attachment := slackAttachment{
+		Color: slackColors[a.Severity],
+		Title: fmt.Sprintf("%d condition(s) detected on %s", len(a.Items), a.Host),
+		Text:  text.String(),
+		Fields: []slackField{
+			{Title: "Severity", Value: a.Severity.String(), Short: true},
+			{Title: "System stress", Value: fmt.Sprintf("%.1f%%", a.Stress), Short: true},
+		},
+		Ts: a.Time.Unix(),
+	}
+	if a.CrashFile != "" {
+		attachment.Footer = "Crash dump: " + a.CrashFile
+	}
+
+	return slackMessage{
+		Text:        a.Title(),
+		Attachments: []slackAttachment{attachment},
+	}
+}
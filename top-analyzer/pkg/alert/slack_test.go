@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotify(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []Item
+		wantColor string
+	}{
+		{
+			name: "critical",
+			items: []Item{
+				{Severity: Warning, Source: "cpu", Message: "CPU anomaly detected"},
+				{Severity: Critical, Source: "filesystem", Message: "Low disk space on /"},
+			},
+			wantColor: "#d00000",
+		},
+		{name: "warning", items: []Item{{Severity: Warning, Source: "cpu", Message: "CPU anomaly detected"}}, wantColor: "#daa038"},
+		{name: "info", items: []Item{{Severity: Info, Source: "power", Message: "On battery"}}, wantColor: "#36a64f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies := make(chan []byte, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				body, _ := io.ReadAll(r.Body)
+				bodies <- body
+			}))
+			defer server.Close()
+
+			a := Group(time.Unix(1700000000, 0), "edge-01", 72.5, tt.items)
+			a.CrashFile = "/var/log/crash/crash_1.json"
+			if err := NewSlack(server.URL, time.Second).Notify(context.Background(), a); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+
+			var payload struct {
+				Text        string `json:"text"`
+				Attachments []struct {
+					Color  string `json:"color"`
+					Text   string `json:"text"`
+					Footer string `json:"footer"`
+					Ts     int64  `json:"ts"`
+					Fields []struct {
+						Title string `json:"title"`
+						Value string `json:"value"`
+					} `json:"fields"`
+				} `json:"attachments"`
+			}
+			if err := json.Unmarshal(<-bodies, &payload); err != nil {
+				t.Fatalf("payload is not JSON: %v", err)
+			}
+			if len(payload.Attachments) != 1 {
+				t.Fatalf("payload has %d attachments, want 1", len(payload.Attachments))
+			}
+			attachment := payload.Attachments[0]
+			if attachment.Color != tt.wantColor {
+				t.Errorf("attachment color = %q, want %q", attachment.Color, tt.wantColor)
+			}
+			for _, item := range tt.items {
+				if !strings.Contains(attachment.Text, item.Message) {
+					t.Errorf("attachment text = %q, want it to list %q", attachment.Text, item.Message)
+				}
+			}
+			if attachment.Footer != "Crash dump: /var/log/crash/crash_1.json" {
+				t.Errorf("attachment footer = %q, want the crash dump", attachment.Footer)
+			}
+			if attachment.Ts != 1700000000 {
+				t.Errorf("attachment ts = %d, want 1700000000", attachment.Ts)
+			}
+			if !strings.Contains(payload.Text, "edge-01") {
+				t.Errorf("text = %q, want the host", payload.Text)
+			}
+		})
+	}
+}
+
+func TestSlackNotifyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	a := Group(time.Now(), "edge-01", 0, []Item{{Severity: Critical, Source: "stress", Message: "High system stress"}})
+	if err := NewSlack(server.URL, time.Second).Notify(context.Background(), a); err == nil {
+		t.Error("Notify() error = nil, want the rejected post reported")
+	}
+}
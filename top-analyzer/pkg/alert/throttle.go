@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cooldowns are the minimum durations between two notifications of the same
+// source while its condition persists
+type Cooldowns struct {
+	Default time.Duration            // Applies to the sources not listed
+	Sources map[string]time.Duration // Overrides by source, e.g. "filesystem"
+}
+
+// Of returns the cool-down of a source
+func (c Cooldowns) Of(source string) time.Duration {
+	if cooldown, ok := c.Sources[source]; ok {
+		return cooldown
+	}
+	return c.Default
+}
+
+// ParseCooldowns parses a comma separated default cool-down and cool-downs
+// by source, e.g. "10m,filesystem=1h,temperature=5m". Either part may be
+// omitted; the default is then 0, which never throttles.
+func ParseCooldowns(s string) (Cooldowns, error) {
+	var cooldowns Cooldowns
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		source, value, bySource := strings.Cut(entry, "=")
+		if !bySource {
+			value = source
+		}
+		cooldown, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || cooldown < 0 {
+			return Cooldowns{}, fmt.Errorf("invalid alert cool-down %q: must be a non-negative duration", entry)
+		}
+		if !bySource {
+			cooldowns.Default = cooldown
+			continue
+		}
+		if cooldowns.Sources == nil {
+			cooldowns.Sources = make(map[string]time.Duration)
+		}
+		cooldowns.Sources[strings.TrimSpace(source)] = cooldown
+	}
+	return cooldowns, nil
+}
+
+// notified is the last notification of a source
+type notified struct {
+	time     time.Time
+	severity Severity
+}
+
+// Throttle spaces out the notifications of conditions that persist or keep
+// coming back across evaluations. The items of a source notified less than
+// its cool-down ago are held back, unless their severity is higher than the
+// one notified.
+type Throttle struct {
+	cooldowns Cooldowns
+	last      map[string]notified
+}
+
+// NewThrottle creates a throttle applying the cool-downs
+func NewThrottle(cooldowns Cooldowns) *Throttle {
+	return &Throttle{cooldowns: cooldowns, last: make(map[string]notified)}
+}
+
+// Filter returns the items of an evaluation that are due for notification
+// and records them as notified at now
+func (t *Throttle) Filter(now time.Time, items []Item) []Item {
+	// Highest severity of each source in this evaluation
+	current := make(map[string]Severity)
+	for _, item := range items {
+		if severity, ok := current[item.Source]; !ok || item.Severity > severity {
+			current[item.Source] = item.Severity
+		}
+	}
+
+	due := make(map[string]bool, len(current))
+	for source, severity := range current {
+		last, ok := t.last[source]
+		if !ok || severity > last.severity || now.Sub(last.time) >= t.cooldowns.Of(source) {
+			due[source] = true
+			t.last[source] = notified{time: now, severity: severity}
+		}
+	}
+
+	var result []Item
+	for _, item := range items {
+		if due[item.Source] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
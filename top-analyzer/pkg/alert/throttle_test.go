@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCooldowns(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Cooldowns
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "default", value: "10m", want: Cooldowns{Default: 10 * time.Minute}},
+		{
+			name:  "default and sources",
+			value: "10m, filesystem=1h,temperature=5m",
+			want:  Cooldowns{Default: 10 * time.Minute, Sources: map[string]time.Duration{"filesystem": time.Hour, "temperature": 5 * time.Minute}},
+		},
+		{name: "source only", value: "cpu=30s", want: Cooldowns{Sources: map[string]time.Duration{"cpu": 30 * time.Second}}},
+		{name: "not a duration", value: "often", wantErr: true},
+		{name: "negative", value: "cpu=-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCooldowns(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCooldowns(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCooldowns(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottleFilter(t *testing.T) {
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	cpu := Item{Severity: Warning, Source: "cpu", Message: "CPU anomaly detected"}
+	cpuCritical := Item{Severity: Critical, Source: "cpu", Message: "CPU anomaly detected"}
+	disk := Item{Severity: Critical, Source: "filesystem", Message: "Low disk space on /"}
+
+	tests := []struct {
+		name      string
+		cooldowns Cooldowns
+		every     time.Duration // Time between evaluations
+		items     [][]Item      // Items of each evaluation
+		want      []int         // Items notified at each evaluation
+	}{
+		{
+			name:      "continuous anomaly within the cooldown",
+			cooldowns: Cooldowns{Default: 10 * time.Minute},
+			every:     5 * time.Second,
+			items:     [][]Item{{cpu}, {cpu}, {cpu}, {cpu}, {cpu}, {cpu}},
+			want:      []int{1, 0, 0, 0, 0, 0},
+		},
+		{
+			name:      "notified again after the cooldown",
+			cooldowns: Cooldowns{Default: 10 * time.Minute},
+			every:     5 * time.Minute,
+			items:     [][]Item{{cpu}, {cpu}, {cpu}, {cpu}, {cpu}},
+			want:      []int{1, 0, 1, 0, 1},
+		},
+		{
+			name:      "higher severity bypasses the cooldown",
+			cooldowns: Cooldowns{Default: 10 * time.Minute},
+			every:     5 * time.Second,
+			items:     [][]Item{{cpu}, {cpuCritical}, {cpuCritical}, {cpu}},
+			want:      []int{1, 1, 0, 0},
+		},
+		{
+			name:      "cooldown by source",
+			cooldowns: Cooldowns{Sources: map[string]time.Duration{"filesystem": time.Hour}},
+			every:     5 * time.Second,
+			items:     [][]Item{{cpu, disk}, {cpu, disk}, {cpu, disk}},
+			want:      []int{2, 1, 1},
+		},
+		{
+			name:  "no cooldown",
+			every: 5 * time.Second,
+			items: [][]Item{{cpu}, {cpu}, {cpu}},
+			want:  []int{1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := NewThrottle(tt.cooldowns)
+			for i, items := range tt.items {
+				now := start.Add(time.Duration(i) * tt.every)
+				if got := len(throttle.Filter(now, items)); got != tt.want[i] {
+					t.Errorf("evaluation %d notified %d items, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -4,43 +4,57 @@ import (
 	"fmt"
 	"runtime"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/ring"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/trend"
 )
 
 type Insight struct {
-	Type        string
-	Description string
-	Severity    string
-	Timestamp   time.Time
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Severity    string    `json:"severity"`
+	Timestamp   time.Time `json:"timestamp"`
 }
 
 type Analyzer struct {
-	history []*parser.SystemStats
+	history    *ring.Buffer[*parser.SystemStats]
 	maxHistory int
+	thresholds parser.ProcessThresholds
+	interval   time.Duration // Time between samples, 0 uses trend.DefaultInterval
 }
 
 func New(maxHistory int) *Analyzer {
 	return &Analyzer{
-		history: make([]*parser.SystemStats, 0),
+		history:    ring.New[*parser.SystemStats](maxHistory),
 		maxHistory: maxHistory,
+		thresholds: parser.DefaultProcessThresholds,
 	}
 }
 
+// SetProcessThresholds sets the thresholds above which top processes are
+// reported as high CPU or high memory processes
+func (a *Analyzer) SetProcessThresholds(thresholds parser.ProcessThresholds) {
+	a.thresholds = thresholds
+}
+
+// SetInterval sets the time between samples, so the rates of the insights
+// mean the same whatever the sampling interval
+func (a *Analyzer) SetInterval(interval time.Duration) {
+	a.interval = interval
+}
+
 func (a *Analyzer) AddStats(stats *parser.SystemStats) {
-	a.history = append(a.history, stats)
-	if len(a.history) > a.maxHistory {
-		a.history = a.history[1:]
-	}
+	a.history.Add(stats)
 }
 
 func (a *Analyzer) GetInsights() []Insight {
-	if len(a.history) == 0 {
+	current, ok := a.history.Last()
+	if !ok {
 		return nil
 	}
-
-	current := a.history[len(a.history)-1]
 	insights := make([]Insight, 0)
 
 	// CPU Usage Insights
@@ -78,7 +92,7 @@ func (a *Analyzer) GetInsights() []Insight {
 	// Process Insights
 	topProcesses := a.getTopProcesses(current, 5)
 	for _, proc := range topProcesses {
-		if proc.CPUPercent > 50 {
+		if a.thresholds.HighCPU(proc) {
 			insights = append(insights, Insight{
 				Type:        "High CPU Process",
 				Description: fmt.Sprintf("Process %s (PID: %d) using %.1f%% CPU", proc.Command, proc.PID, proc.CPUPercent),
@@ -86,19 +100,65 @@ func (a *Analyzer) GetInsights() []Insight {
 				Timestamp:   time.Now(),
 			})
 		}
-		if proc.VSZPercent > 10 {
+		if a.thresholds.HighMemory(proc) {
 			insights = append(insights, Insight{
 				Type:        "High Memory Process",
-				Description: fmt.Sprintf("Process %s (PID: %d) using %.1f%% memory", proc.Command, proc.PID, proc.VSZPercent),
+				Description: fmt.Sprintf("Process %s (PID: %d) using %.1f%% memory", proc.Command, proc.PID, proc.MemoryPercent()),
 				Severity:    "Info",
 				Timestamp:   time.Now(),
 			})
 		}
 	}
 
+	// Processes blocked on I/O: name the commands behind the D state count
+	if commands := parser.UninterruptibleCommands(current.Processes); len(commands) > 0 {
+		severity := "Info"
+		if current.CPU.IO > ioWaitHigh {
+			severity = "Warning"
+		}
+		insights = append(insights, Insight{
+			Type:        "Uninterruptible Processes",
+			Description: fmt.Sprintf("Processes in uninterruptible sleep (iowait %.1f%%): %s", current.CPU.IO, strings.Join(commands, ", ")),
+			Severity:    severity,
+			Timestamp:   time.Now(),
+		})
+	}
+
+	// Temperature climbing without a matching rise in CPU usage points at a
+	// cooling failure rather than load
+	if thermal, ok := trend.CorrelateThermal(a.history.Slice(), a.interval); ok && thermal.TempSlope > thermalRiseRate && !thermal.FollowsCPU() {
+		insights = append(insights, Insight{
+			Type:        "Thermal Anomaly Unrelated To Load",
+			Description: fmt.Sprintf("Temperature rising %.1f°C/min while CPU usage trend is only %.1f%%/min, possible cooling failure", thermal.TempSlope*60, thermal.CPUSlope*60),
+			Severity:    "Warning",
+			Timestamp:   time.Now(),
+		})
+	}
+
+	// Fork storm: one parent spawning an unusual number of children
+	if ppid, children := a.TopParent(); children >= forkStormChildren {
+		insights = append(insights, Insight{
+			Type:        "Fork Storm",
+			Description: fmt.Sprintf("Process %s (PID: %d) has %d child processes", processCommand(current, ppid), ppid, children),
+			Severity:    "Warning",
+			Timestamp:   time.Now(),
+		})
+	}
+
+	// Single core saturation: one core pegged while the aggregate looks idle
+	// points at a single-threaded bottleneck
+	for _, core := range a.saturatedCores() {
+		insights = append(insights, Insight{
+			Type:        "Single Core Saturation",
+			Description: fmt.Sprintf("CPU core %d is %.1f%% busy while total CPU usage is only %.1f%%", core, 100-current.PerCore[core].Idle, current.CPU.User+current.CPU.Sys),
+			Severity:    "Warning",
+			Timestamp:   time.Now(),
+		})
+	}
+
 	// Trend Analysis
-	if len(a.history) > 1 {
-		prev := a.history[len(a.history)-2]
+	if a.history.Len() > 1 {
+		prev := a.history.At(a.history.Len() - 2)
 		cpuTrend := (current.CPU.User + current.CPU.Sys) - (prev.CPU.User + prev.CPU.Sys)
 		if cpuTrend > 20 {
 			insights = append(insights, Insight{
@@ -113,9 +173,43 @@ func (a *Analyzer) GetInsights() []Insight {
 	return insights
 }
 
+// saturatedCores returns the cores that stayed above 90% busy over the last
+// samples while the aggregate CPU usage stayed below 50%
+func (a *Analyzer) saturatedCores() []int {
+	samples := a.history.Len()
+	if samples > 3 {
+		samples = 3
+	}
+
+	var cores []int
+	current, _ := a.history.Last()
+	for core := range current.PerCore {
+		saturated := true
+		for i := a.history.Len() - samples; i < a.history.Len(); i++ {
+			stats := a.history.At(i)
+			if core >= len(stats.PerCore) ||
+				100-stats.PerCore[core].Idle <= 90 ||
+				stats.CPU.User+stats.CPU.Sys >= 50 {
+				saturated = false
+				break
+			}
+		}
+		if saturated {
+			cores = append(cores, core)
+		}
+	}
+	return cores
+}
+
+// getTopProcesses returns up to count processes with the highest CPU usage,
+// leaving out the excluded process
 func (a *Analyzer) getTopProcesses(stats *parser.SystemStats, count int) []parser.Process {
-	processes := make([]parser.Process, len(stats.Processes))
-	copy(processes, stats.Processes)
+	processes := make([]parser.Process, 0, len(stats.Processes))
+	for _, proc := range stats.Processes {
+		if !a.thresholds.Excluded(proc) {
+			processes = append(processes, proc)
+		}
+	}
 
 	// Sort by CPU usage
 	sort.Slice(processes, func(i, j int) bool {
@@ -127,3 +221,54 @@ func (a *Analyzer) getTopProcesses(stats *parser.SystemStats, count int) []parse
 	}
 	return processes
 } 
+
+// ioWaitHigh is the iowait percentage from which processes in uninterruptible
+// sleep are reported as a warning
+const ioWaitHigh = 20
+
+// forkStormChildren is the number of children of a single parent from which
+// it is reported as a possible fork storm
+const forkStormChildren = 20
+
+// ProcessTree returns the children of every parent PID in the latest sample.
+// Processes without a known parent (PPID 0) are left out.
+func (a *Analyzer) ProcessTree() map[int][]parser.Process {
+	current, ok := a.history.Last()
+	if !ok {
+		return nil
+	}
+
+	tree := make(map[int][]parser.Process)
+	for _, proc := range current.Processes {
+		if proc.PPID == 0 {
+			continue
+		}
+		tree[proc.PPID] = append(tree[proc.PPID], proc)
+	}
+	return tree
+}
+
+// TopParent returns the parent PID with the most children in the latest
+// sample and its number of children. Ties go to the lowest PID.
+func (a *Analyzer) TopParent() (ppid int, children int) {
+	for parent, procs := range a.ProcessTree() {
+		if len(procs) > children || (len(procs) == children && parent < ppid) {
+			ppid, children = parent, len(procs)
+		}
+	}
+	return ppid, children
+}
+
+// processCommand returns the command of pid in stats, or "unknown"
+func processCommand(stats *parser.SystemStats, pid int) string {
+	for _, proc := range stats.Processes {
+		if proc.PID == pid {
+			return proc.Command
+		}
+	}
+	return "unknown"
+}
+
+// thermalRiseRate is the average temperature change in °C per second
+// considered a climbing temperature
+const thermalRiseRate = 0.1
@@ -0,0 +1,274 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+// insightsOfType returns the descriptions of the insights of the given type
+func insightsOfType(insights []Insight, insightType string) []string {
+	var descriptions []string
+	for _, insight := range insights {
+		if insight.Type == insightType {
+			descriptions = append(descriptions, insight.Description)
+		}
+	}
+	return descriptions
+}
+
+// coreSample returns an 8-core sample whose cores are idle but for pegged,
+// which is busy percent, with the aggregate CPU usage at total percent
+func coreSample(pegged int, busy, total float64) *parser.SystemStats {
+	stats := &parser.SystemStats{
+		CPU:     parser.CPU{User: total, Idle: 100 - total},
+		PerCore: make([]parser.CPU, 8),
+		Memory:  parser.Memory{Used: 1, Free: 1},
+	}
+	for core := range stats.PerCore {
+		stats.PerCore[core].Idle = 100
+	}
+	stats.PerCore[pegged] = parser.CPU{User: busy, Idle: 100 - busy}
+	return stats
+}
+
+func TestSingleCoreSaturation(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []*parser.SystemStats
+		want    []string
+	}{
+		{
+			name:    "core 3 pegged while the aggregate is low",
+			samples: []*parser.SystemStats{coreSample(3, 98, 12), coreSample(3, 99, 12.5), coreSample(3, 97, 12)},
+			want:    []string{"CPU core 3 is 97.0% busy while total CPU usage is only 12.0%"},
+		},
+		{
+			name:    "core pegged only in the latest sample",
+			samples: []*parser.SystemStats{coreSample(3, 20, 12), coreSample(3, 20, 12), coreSample(3, 99, 12)},
+		},
+		{
+			name:    "aggregate busy",
+			samples: []*parser.SystemStats{coreSample(3, 99, 60), coreSample(3, 99, 60), coreSample(3, 99, 60)},
+		},
+		{
+			name:    "no core above 90%",
+			samples: []*parser.SystemStats{coreSample(3, 90, 12), coreSample(3, 90, 12), coreSample(3, 90, 12)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(10)
+			for _, stats := range tt.samples {
+				a.AddStats(stats)
+			}
+			if got := insightsOfType(a.GetInsights(), "Single Core Saturation"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("single core saturation insights = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// forkSample returns a sample where bash (PID 500) has the given number of
+// children and init has three
+func forkSample(children int) *parser.SystemStats {
+	stats := &parser.SystemStats{
+		Memory: parser.Memory{Used: 1, Free: 1},
+		Processes: []parser.Process{
+			{PID: 1, PPID: 0, Command: "init"},
+			{PID: 2, PPID: 1, Command: "sshd"},
+			{PID: 3, PPID: 1, Command: "crond"},
+			{PID: 500, PPID: 1, Command: "bash"},
+		},
+	}
+	for i := 0; i < children; i++ {
+		stats.Processes = append(stats.Processes, parser.Process{PID: 1000 + i, PPID: 500, Command: "worker"})
+	}
+	return stats
+}
+
+func TestTopParent(t *testing.T) {
+	tests := []struct {
+		name         string
+		stats        *parser.SystemStats
+		wantPPID     int
+		wantChildren int
+		wantInsight  []string
+	}{
+		{
+			name:         "fork storm",
+			stats:        forkSample(25),
+			wantPPID:     500,
+			wantChildren: 25,
+			wantInsight:  []string{"Process bash (PID: 500) has 25 child processes"},
+		},
+		{name: "few children", stats: forkSample(4), wantPPID: 500, wantChildren: 4},
+		{name: "init has the most children", stats: forkSample(1), wantPPID: 1, wantChildren: 3},
+		{name: "no processes", stats: &parser.SystemStats{Memory: parser.Memory{Used: 1, Free: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(10)
+			a.AddStats(tt.stats)
+
+			ppid, children := a.TopParent()
+			if ppid != tt.wantPPID || children != tt.wantChildren {
+				t.Errorf("TopParent() = (%d, %d), want (%d, %d)", ppid, children, tt.wantPPID, tt.wantChildren)
+			}
+			if got := len(a.ProcessTree()[tt.wantPPID]); tt.wantPPID != 0 && got != tt.wantChildren {
+				t.Errorf("ProcessTree()[%d] has %d children, want %d", tt.wantPPID, got, tt.wantChildren)
+			}
+			if got := insightsOfType(a.GetInsights(), "Fork Storm"); !reflect.DeepEqual(got, tt.wantInsight) {
+				t.Errorf("fork storm insights = %q, want %q", got, tt.wantInsight)
+			}
+		})
+	}
+}
+
+func TestUninterruptibleProcesses(t *testing.T) {
+	blocked := []parser.Process{
+		{PID: 1, State: "S", Command: "init"},
+		{PID: 200, State: "D", Command: "rsync"},
+		{PID: 300, State: "D", Command: "jbd2/sda1-8"},
+	}
+
+	tests := []struct {
+		name         string
+		processes    []parser.Process
+		iowait       float64
+		wantInsight  []string
+		wantSeverity string
+	}{
+		{
+			name:         "high iowait",
+			processes:    blocked,
+			iowait:       35,
+			wantInsight:  []string{"Processes in uninterruptible sleep (iowait 35.0%): jbd2/sda1-8, rsync"},
+			wantSeverity: "Warning",
+		},
+		{
+			name:         "low iowait",
+			processes:    blocked,
+			iowait:       2,
+			wantInsight:  []string{"Processes in uninterruptible sleep (iowait 2.0%): jbd2/sda1-8, rsync"},
+			wantSeverity: "Info",
+		},
+		{name: "no D state process", processes: blocked[:1], iowait: 35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(10)
+			a.AddStats(&parser.SystemStats{
+				CPU:       parser.CPU{IO: tt.iowait},
+				Memory:    parser.Memory{Used: 1, Free: 1},
+				Processes: tt.processes,
+			})
+
+			insights := a.GetInsights()
+			if got := insightsOfType(insights, "Uninterruptible Processes"); !reflect.DeepEqual(got, tt.wantInsight) {
+				t.Errorf("uninterruptible insights = %q, want %q", got, tt.wantInsight)
+			}
+			for _, insight := range insights {
+				if insight.Type == "Uninterruptible Processes" && insight.Severity != tt.wantSeverity {
+					t.Errorf("severity = %s, want %s", insight.Severity, tt.wantSeverity)
+				}
+			}
+		})
+	}
+}
+
+func TestThermalAnomalyUnrelatedToLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  int
+		tempStep float64 // °C per 5s sample
+		cpuStep  float64 // CPU percent per 5s sample
+		want     []string
+	}{
+		{
+			name:     "rising temperature with flat CPU",
+			samples:  6,
+			tempStep: 1,
+			want:     []string{"Temperature rising 12.0°C/min while CPU usage trend is only 0.0%/min, possible cooling failure"},
+		},
+		{name: "temperature following the CPU", samples: 6, tempStep: 1, cpuStep: 10},
+		{name: "flat temperature", samples: 6},
+		{name: "slowly rising temperature", samples: 6, tempStep: 0.25},
+		{name: "too few samples", samples: 4, tempStep: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(10)
+			for i := 0; i < tt.samples; i++ {
+				a.AddStats(&parser.SystemStats{
+					CPU:         parser.CPU{User: 10 + tt.cpuStep*float64(i)},
+					Memory:      parser.Memory{Used: 1, Free: 1},
+					Temperature: temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 45 + tt.tempStep*float64(i)}},
+				})
+			}
+			if got := insightsOfType(a.GetInsights(), "Thermal Anomaly Unrelated To Load"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("thermal insights = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludedPID(t *testing.T) {
+	const selfPID = 4242
+	stats := &parser.SystemStats{
+		Memory: parser.Memory{Used: 1, Free: 1},
+		Processes: []parser.Process{
+			{PID: 1, Command: "init", CPUPercent: 0.1},
+			{PID: selfPID, Command: "analyzer", CPUPercent: 90, VSZPercent: 30},
+			{PID: 700, Command: "ffmpeg", CPUPercent: 40},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		excludePID int
+		wantCPU    []string
+		wantMemory []string
+	}{
+		{
+			name:       "self excluded",
+			excludePID: selfPID,
+			wantCPU:    []string{"Process ffmpeg (PID: 700) using 40.0% CPU"},
+		},
+		{
+			name:       "nothing excluded",
+			wantCPU:    []string{"Process analyzer (PID: 4242) using 90.0% CPU", "Process ffmpeg (PID: 700) using 40.0% CPU"},
+			wantMemory: []string{"Process analyzer (PID: 4242) using 30.0% memory"},
+		},
+		{
+			name:       "another process excluded",
+			excludePID: 700,
+			wantCPU:    []string{"Process analyzer (PID: 4242) using 90.0% CPU"},
+			wantMemory: []string{"Process analyzer (PID: 4242) using 30.0% memory"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := parser.DefaultProcessThresholds
+			thresholds.ExcludePID = tt.excludePID
+			a := New(10)
+			a.SetProcessThresholds(thresholds)
+			a.AddStats(stats)
+
+			insights := a.GetInsights()
+			if got := insightsOfType(insights, "High CPU Process"); !reflect.DeepEqual(got, tt.wantCPU) {
+				t.Errorf("high CPU insights = %q, want %q", got, tt.wantCPU)
+			}
+			if got := insightsOfType(insights, "High Memory Process"); !reflect.DeepEqual(got, tt.wantMemory) {
+				t.Errorf("high memory insights = %q, want %q", got, tt.wantMemory)
+			}
+		})
+	}
+}
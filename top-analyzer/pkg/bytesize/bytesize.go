@@ -0,0 +1,92 @@
+package bytesize
+
+import "fmt"
+
+// System is how byte counts are scaled and labeled
+type System string
+
+// Systems of units
+const (
+	Binary  System = "binary"  // Powers of 1024: KiB, MiB, GiB, TiB
+	Decimal System = "decimal" // Powers of 1000: kB, MB, GB, TB
+)
+
+// ParseSystem parses a system of units, binary or decimal
+func ParseSystem(s string) (System, error) {
+	switch System(s) {
+	case Binary, Decimal:
+		return System(s), nil
+	default:
+		return "", fmt.Errorf("invalid byte units %q: must be %s or %s", s, Binary, Decimal)
+	}
+}
+
+// Magnitude is a unit as a power of the base of a system
+type Magnitude int
+
+// Magnitudes
+const (
+	Kilo Magnitude = iota + 1
+	Mega
+	Giga
+	Tera
+)
+
+var (
+	binaryUnits  = [...]string{"B", "KiB", "MiB", "GiB", "TiB"}
+	decimalUnits = [...]string{"B", "kB", "MB", "GB", "TB"}
+)
+
+// Unit returns the label of a magnitude, e.g. GiB in binary and GB in
+// decimal
+func (s System) Unit(m Magnitude) string {
+	if s == Decimal {
+		return decimalUnits[m]
+	}
+	return binaryUnits[m]
+}
+
+// Size returns the number of bytes of a magnitude, e.g. 1024³ for binary
+// giga and 1000³ for decimal giga
+func (s System) Size(m Magnitude) float64 {
+	base := 1024.0
+	if s == Decimal {
+		base = 1000
+	}
+	size := 1.0
+	for i := Magnitude(0); i < m; i++ {
+		size *= base
+	}
+	return size
+}
+
+// In returns bytes in a magnitude
+func (s System) In(bytes float64, m Magnitude) float64 {
+	return bytes / s.Size(m)
+}
+
+// Format formats bytes in a magnitude with two decimals, e.g. 1e9 bytes as
+// "1.00 GB" in decimal and "0.93 GiB" in binary
+func (s System) Format(bytes float64, m Magnitude) string {
+	return fmt.Sprintf("%.2f %s", s.In(bytes, m), s.Unit(m))
+}
+
+// current is the system set by SetSystem
+var current = Binary
+
+// SetSystem sets the system Format and Current use, so bytes are printed
+// the same way everywhere
+func SetSystem(s System) {
+	current = s
+}
+
+// Current returns the system set by SetSystem, binary by default as top
+// and free report
+func Current() System {
+	return current
+}
+
+// Format formats bytes in a magnitude of the current system
+func Format[T int64 | uint64 | float64](bytes T, m Magnitude) string {
+	return current.Format(float64(bytes), m)
+}
@@ -0,0 +1,61 @@
+package bytesize
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		system System
+		bytes  float64
+		m      Magnitude
+		want   string
+	}{
+		{name: "1e9 decimal", system: Decimal, bytes: 1e9, m: Giga, want: "1.00 GB"},
+		{name: "1e9 binary", system: Binary, bytes: 1e9, m: Giga, want: "0.93 GiB"},
+		{name: "1GiB binary", system: Binary, bytes: 1 << 30, m: Giga, want: "1.00 GiB"},
+		{name: "1.5MB decimal", system: Decimal, bytes: 1.5e6, m: Mega, want: "1.50 MB"},
+		{name: "kilo decimal", system: Decimal, bytes: 2048, m: Kilo, want: "2.05 kB"},
+		{name: "kilo binary", system: Binary, bytes: 2048, m: Kilo, want: "2.00 KiB"},
+		{name: "tera", system: Decimal, bytes: 2e12, m: Tera, want: "2.00 TB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.system.Format(tt.bytes, tt.m); got != tt.want {
+				t.Errorf("Format(%v, %d) = %q, want %q", tt.bytes, tt.m, got, tt.want)
+			}
+
+			old := Current()
+			SetSystem(tt.system)
+			t.Cleanup(func() { SetSystem(old) })
+			if got := Format(tt.bytes, tt.m); got != tt.want {
+				t.Errorf("Format(%v, %d) with %s units = %q, want %q", tt.bytes, tt.m, tt.system, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSystem(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    System
+		wantErr bool
+	}{
+		{value: "binary", want: Binary},
+		{value: "decimal", want: Decimal},
+		{value: "SI", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSystem(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSystem(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSystem(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
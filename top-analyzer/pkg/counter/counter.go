@@ -0,0 +1,18 @@
+package counter
+
+import "time"
+
+// Rate returns the per second rate of a monotonic counter read as prev and
+// then cur, elapsed apart. A counter going backwards was reset, e.g. by a
+// driver reload or a wrap; the rate is then 0 rather than a huge negative
+// value, and reset is true so the caller can note it. Rate is also 0 when no
+// time elapsed.
+func Rate(prev, cur uint64, elapsed time.Duration) (rate float64, reset bool) {
+	if cur < prev {
+		return 0, true
+	}
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(cur-prev) / elapsed.Seconds(), false
+}
@@ -0,0 +1,33 @@
+package counter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      uint64
+		cur       uint64
+		elapsed   time.Duration
+		want      float64
+		wantReset bool
+	}{
+		{name: "increasing", prev: 1000, cur: 6000, elapsed: 5 * time.Second, want: 1000},
+		{name: "unchanged", prev: 1000, cur: 1000, elapsed: 5 * time.Second, want: 0},
+		{name: "reset", prev: 1 << 40, cur: 1000, elapsed: 5 * time.Second, want: 0, wantReset: true},
+		{name: "decreased by one", prev: 1001, cur: 1000, elapsed: 5 * time.Second, want: 0, wantReset: true},
+		{name: "no time elapsed", prev: 1000, cur: 6000, want: 0},
+		{name: "sub-second interval", prev: 0, cur: 500, elapsed: 500 * time.Millisecond, want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reset := Rate(tt.prev, tt.cur, tt.elapsed)
+			if got != tt.want || reset != tt.wantReset {
+				t.Errorf("Rate(%d, %d, %v) = (%v, %v), want (%v, %v)", tt.prev, tt.cur, tt.elapsed, got, reset, tt.want, tt.wantReset)
+			}
+		})
+	}
+}
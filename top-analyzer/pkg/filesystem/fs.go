@@ -1,15 +1,27 @@
 package filesystem
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
 )
 
 // FilesystemStats represents statistics about a filesystem
 type FilesystemStats struct {
 	Filesystems map[string]Filesystem
+
+	// Entries dropped because another entry had the same mount point, e.g. a
+	// bind mount or overlay. Of the entries sharing a mount point, the
+	// largest, or the first listed among equally large ones, is kept.
+	Duplicates []Filesystem
+
+	// Thresholds the partitions were evaluated with
+	thresholds Thresholds
 }
 
 // Filesystem represents a single filesystem
@@ -20,22 +32,79 @@ type Filesystem struct {
 	Available  int64 // in bytes
 	UsedPct    float64
 	MountPoint string
-	Critical   bool // when free space < 10%
+	Critical   bool // when free space < the critical free percent
+}
+
+// Thresholds decide which partitions are reported as critical or low on space
+type Thresholds struct {
+	CriticalFreePercent float64 // Free space percentage below which a partition is critical
+	MinSize             int64   // Partitions smaller than this many bytes are never evaluated
+
+	// Horizons within which a partition projected to fill up raises a
+	// warning or critical alert, 0 disables the tier
+	FullWarning  time.Duration
+	FullCritical time.Duration
+
+	// Change of the free space percentage over the window, in percentage
+	// points, below which a partition is never anomalous. Temp files
+	// coming and going make free space jitter by fractions of a percent.
+	AnomalyDeadband float64
+
+	// Weights of the partitions whose low space adds more system stress,
+	// nil for the built-in ones. Weighted partitions are monitored even when
+	// not a main partition.
+	Weights PartitionWeights
+}
+
+// DefaultThresholds are the thresholds used unless configured otherwise
+var DefaultThresholds = Thresholds{
+	CriticalFreePercent: 10,
+	FullWarning:         24 * time.Hour,
+	FullCritical:        time.Hour,
+	AnomalyDeadband:     1,
+}
+
+// Evaluated reports whether a partition of size bytes is large enough to be
+// evaluated for critical or low space
+func (t Thresholds) Evaluated(size int64) bool {
+	return size >= t.MinSize
+}
+
+// Significant reports whether free space ranging from minPct to maxPct
+// percent over the window changed enough to be judged anomalous
+func (t Thresholds) Significant(minPct, maxPct float64) bool {
+	return maxPct-minPct >= t.AnomalyDeadband
+}
+
+// IsCritical reports whether a partition of size bytes with freePct percent
+// free space is critical
+func (t Thresholds) IsCritical(freePct float64, size int64) bool {
+	return t.Evaluated(size) && freePct < t.CriticalFreePercent
 }
 
 // ReadFilesystemStats reads filesystem statistics using df command
 func ReadFilesystemStats() (*FilesystemStats, error) {
-	cmd := exec.Command("df", "-B1") // Get sizes in bytes for precision
+	return ReadFilesystemStatsWith(context.Background(), "df", DefaultThresholds)
+}
+
+// ReadFilesystemStatsWith reads filesystem statistics using the df binary at
+// dfPath. The command is killed when ctx is done, e.g. on a hung NFS mount.
+// Partitions are flagged critical according to thresholds.
+func ReadFilesystemStatsWith(ctx context.Context, dfPath string, thresholds Thresholds) (*FilesystemStats, error) {
+	cmd := exec.CommandContext(ctx, dfPath, "-B1") // Get sizes in bytes for precision
 	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("df command cancelled: %w", ctx.Err())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute df command: %w", err)
 	}
 
-	return parseFilesystemStats(string(output))
+	return parseFilesystemStats(string(output), thresholds)
 }
 
 // parseFilesystemStats parses the output of df command
-func parseFilesystemStats(output string) (*FilesystemStats, error) {
+func parseFilesystemStats(output string, thresholds Thresholds) (*FilesystemStats, error) {
 	lines := strings.Split(output, "\n")
 	if len(lines) < 2 {
 		return nil, fmt.Errorf("invalid df output format")
@@ -43,6 +112,7 @@ func parseFilesystemStats(output string) (*FilesystemStats, error) {
 
 	stats := &FilesystemStats{
 		Filesystems: make(map[string]Filesystem),
+		thresholds:  thresholds,
 	}
 
 	// Skip header line
@@ -67,7 +137,7 @@ func parseFilesystemStats(output string) (*FilesystemStats, error) {
 		mountPoint := fields[5]
 
 		// Filter out filesystems we care about (main storage partitions)
-		if !isMainPartition(mountPoint) {
+		if _, weighted := thresholds.Weights[mountPoint]; !weighted && !isMainPartition(mountPoint) {
 			continue
 		}
 
@@ -87,9 +157,16 @@ func parseFilesystemStats(output string) (*FilesystemStats, error) {
 			Available:  available,
 			UsedPct:    usedPct,
 			MountPoint: mountPoint,
-			Critical:   usedPct > 90, // Critical when used space > 90% (free space < 10%)
+			Critical:   thresholds.IsCritical(100-usedPct, size),
 		}
 
+		if existing, ok := stats.Filesystems[mountPoint]; ok {
+			if fs.Size <= existing.Size {
+				stats.Duplicates = append(stats.Duplicates, fs)
+				continue
+			}
+			stats.Duplicates = append(stats.Duplicates, existing)
+		}
 		stats.Filesystems[mountPoint] = fs
 	}
 
@@ -121,15 +198,15 @@ func (fs *FilesystemStats) String() string {
 		status := "OK"
 		if stats.Critical {
 			status = "CRITICAL"
-		} else if free < 20 {
+		} else if free < 20 && fs.thresholds.Evaluated(stats.Size) {
 			status = "WARNING"
 		}
 
-		sb.WriteString(fmt.Sprintf("%s (%s): %.1f%% used, %.2f GB free [%s]\n",
+		sb.WriteString(fmt.Sprintf("%s (%s): %.1f%% used, %s free [%s]\n",
 			mount,
 			stats.Device,
 			stats.UsedPct,
-			float64(stats.Available)/1024.0/1024.0/1024.0,
+			bytesize.Format(stats.Available, bytesize.Giga),
 			status))
 	}
 	return sb.String()
@@ -0,0 +1,131 @@
+package filesystem
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilesystemStatsCritical(t *testing.T) {
+	// 12% free on the root partition
+	const df = `Filesystem     1B-blocks       Used  Available Use% Mounted on
+/dev/sda1    10737418240 9448928051 1288490189  88% /
+`
+
+	tests := []struct {
+		name         string
+		criticalFree float64
+		minSize      int64
+		want         bool
+	}{
+		{name: "default 10% critical free", criticalFree: 10, want: false},
+		{name: "15% critical free", criticalFree: 15, want: true},
+		{name: "12% critical free", criticalFree: 12, want: false},
+		{name: "below the minimum size", criticalFree: 15, minSize: 20 << 30, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := DefaultThresholds
+			thresholds.CriticalFreePercent = tt.criticalFree
+			thresholds.MinSize = tt.minSize
+
+			stats, err := parseFilesystemStats(df, thresholds)
+			if err != nil {
+				t.Fatalf("parseFilesystemStats() error = %v", err)
+			}
+			root, ok := stats.Filesystems["/"]
+			if !ok {
+				t.Fatalf("Filesystems = %v, want /", stats.Filesystems)
+			}
+			if root.Critical != tt.want {
+				t.Errorf("Critical = %v, want %v", root.Critical, tt.want)
+			}
+			if got := thresholds.IsCritical(100-root.UsedPct, root.Size); got != tt.want {
+				t.Errorf("IsCritical() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinPartitionSize(t *testing.T) {
+	// A full 64MB tmpfs next to a 50GB root with 5% free
+	const df = `Filesystem     1B-blocks        Used  Available Use% Mounted on
+/dev/sda1    53687091200 51002736640 2684354560  95% /
+tmpfs           67108864    67108864          0 100% /mnt/config
+`
+
+	tests := []struct {
+		name         string
+		minSize      int64
+		wantCritical map[string]bool
+	}{
+		{name: "no minimum size", wantCritical: map[string]bool{"/": true, "/mnt/config": true}},
+		{name: "1GB minimum size", minSize: 1 << 30, wantCritical: map[string]bool{"/": true, "/mnt/config": false}},
+		{name: "minimum size above the root", minSize: 100 << 30, wantCritical: map[string]bool{"/": false, "/mnt/config": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := DefaultThresholds
+			thresholds.MinSize = tt.minSize
+
+			stats, err := parseFilesystemStats(df, thresholds)
+			if err != nil {
+				t.Fatalf("parseFilesystemStats() error = %v", err)
+			}
+			for mountPoint, want := range tt.wantCritical {
+				fs, ok := stats.Filesystems[mountPoint]
+				if !ok {
+					t.Fatalf("Filesystems = %v, want %s", stats.Filesystems, mountPoint)
+				}
+				if fs.Critical != want {
+					t.Errorf("%s Critical = %v, want %v", mountPoint, fs.Critical, want)
+				}
+				if got := thresholds.Evaluated(fs.Size); got != want {
+					t.Errorf("%s Evaluated() = %v, want %v", mountPoint, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateMountPoints(t *testing.T) {
+	const header = "Filesystem     1B-blocks       Used  Available Use% Mounted on\n"
+	const (
+		disk    = "/dev/sda1    10737418240 5368709120 5368709120  50% /\n"
+		bind    = "/dev/sdb1     1073741824  536870912  536870912  50% /\n"
+		overlay = "/dev/sdc1     1073741824  107374182  966367642  10% /\n"
+	)
+
+	tests := []struct {
+		name           string
+		rows           string
+		wantDevice     string
+		wantDuplicates []string
+	}{
+		{name: "largest listed first", rows: disk + bind, wantDevice: "/dev/sda1", wantDuplicates: []string{"/dev/sdb1"}},
+		{name: "largest listed last", rows: bind + disk, wantDevice: "/dev/sda1", wantDuplicates: []string{"/dev/sdb1"}},
+		{name: "equally large", rows: bind + overlay, wantDevice: "/dev/sdb1", wantDuplicates: []string{"/dev/sdc1"}},
+		{name: "three entries", rows: overlay + disk + bind, wantDevice: "/dev/sda1", wantDuplicates: []string{"/dev/sdc1", "/dev/sdb1"}},
+		{name: "no duplicates", rows: disk, wantDevice: "/dev/sda1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := parseFilesystemStats(header+tt.rows, DefaultThresholds)
+			if err != nil {
+				t.Fatalf("parseFilesystemStats() error = %v", err)
+			}
+			if got := stats.Filesystems["/"].Device; got != tt.wantDevice {
+				t.Errorf("/ device = %q, want %q", got, tt.wantDevice)
+			}
+			var duplicates []string
+			for _, fs := range stats.Duplicates {
+				duplicates = append(duplicates, fs.Device)
+			}
+			if !reflect.DeepEqual(duplicates, tt.wantDuplicates) {
+				t.Errorf("Duplicates = %q, want %q", duplicates, tt.wantDuplicates)
+			}
+		})
+	}
+}
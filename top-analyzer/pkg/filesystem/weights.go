@@ -0,0 +1,92 @@
+package filesystem
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PartitionWeights multiply the stress a critical or low space partition
+// adds to the system stress, by mount point. Partitions not listed weigh 1.
+// Without weights, each stress calculator uses its built-in stress for the
+// partitions that usually take the whole system down, root and boot.
+type PartitionWeights map[string]float64
+
+// DefaultCriticalWeight is the weight of a partition listed without one
+const DefaultCriticalWeight = 2.0
+
+// PartitionStress is the system stress a partition adds when critical or low
+// on space
+type PartitionStress struct {
+	Critical float64
+	Low      float64
+}
+
+// Of returns the weight of the partition mounted at mountPoint
+func (w PartitionWeights) Of(mountPoint string) float64 {
+	if weight, ok := w[mountPoint]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Stress returns the stress of the partition mounted at mountPoint, given
+// the stress of an ordinary partition: ordinary multiplied by its weight, or
+// without weights its builtin stress when listed there
+func (w PartitionWeights) Stress(mountPoint string, ordinary PartitionStress, builtin map[string]PartitionStress) PartitionStress {
+	if w == nil {
+		if stress, ok := builtin[mountPoint]; ok {
+			return stress
+		}
+		return ordinary
+	}
+	weight := w.Of(mountPoint)
+	return PartitionStress{Critical: ordinary.Critical * weight, Low: ordinary.Low * weight}
+}
+
+// String formats the weights the way ParsePartitionWeights reads them
+func (w PartitionWeights) String() string {
+	mounts := make([]string, 0, len(w))
+	for mount := range w {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+
+	entries := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		entries = append(entries, mount+"="+strconv.FormatFloat(w[mount], 'g', -1, 64))
+	}
+	return strings.Join(entries, ",")
+}
+
+// ParsePartitionWeights parses comma separated mount points with an optional
+// weight, e.g. "/data=2,/boot=1.5,/var". A mount point without a weight gets
+// DefaultCriticalWeight. An empty list returns no weights, keeping the
+// built-in stress.
+func ParsePartitionWeights(s string) (PartitionWeights, error) {
+	weights := make(PartitionWeights)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		mount, value, hasWeight := strings.Cut(entry, "=")
+		mount = strings.TrimSpace(mount)
+		if !strings.HasPrefix(mount, "/") {
+			return nil, fmt.Errorf("invalid critical partition %q: mount point must be absolute", entry)
+		}
+		weight := DefaultCriticalWeight
+		if hasWeight {
+			var err error
+			if weight, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || weight < 0 {
+				return nil, fmt.Errorf("invalid critical partition %q: weight must be a non-negative number", entry)
+			}
+		}
+		weights[mount] = weight
+	}
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	return weights, nil
+}
@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePartitionWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    PartitionWeights
+		wantErr bool
+	}{
+		{name: "root and boot", value: "/=2,/boot=1.5", want: PartitionWeights{"/": 2, "/boot": 1.5}},
+		{name: "custom weight", value: "/data=3", want: PartitionWeights{"/data": 3}},
+		{name: "mount point without a weight", value: "/data, /var=1.5", want: PartitionWeights{"/data": DefaultCriticalWeight, "/var": 1.5}},
+		{name: "empty", value: "", want: nil},
+		{name: "only separators", value: " , ", want: nil},
+		{name: "relative mount point", value: "data=2", wantErr: true},
+		{name: "negative weight", value: "/data=-1", wantErr: true},
+		{name: "weight not a number", value: "/data=high", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePartitionWeights(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePartitionWeights(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePartitionWeights(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionWeightsOf(t *testing.T) {
+	weights := PartitionWeights{"/data": 2}
+
+	tests := []struct {
+		mountPoint string
+		want       float64
+	}{
+		{mountPoint: "/data", want: 2},
+		{mountPoint: "/", want: 1},
+		{mountPoint: "/boot", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mountPoint, func(t *testing.T) {
+			if got := weights.Of(tt.mountPoint); got != tt.want {
+				t.Errorf("Of(%q) = %v, want %v", tt.mountPoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionWeightsStress(t *testing.T) {
+	ordinary := PartitionStress{Critical: 15, Low: 5}
+	builtin := map[string]PartitionStress{"/": {Critical: 30, Low: 15}}
+
+	tests := []struct {
+		name       string
+		weights    PartitionWeights
+		mountPoint string
+		want       PartitionStress
+	}{
+		{name: "built-in partition", mountPoint: "/", want: PartitionStress{Critical: 30, Low: 15}},
+		{name: "ordinary partition", mountPoint: "/data", want: ordinary},
+		{name: "weighted partition", weights: PartitionWeights{"/data": 2}, mountPoint: "/data", want: PartitionStress{Critical: 30, Low: 10}},
+		{name: "built-in partition not weighted", weights: PartitionWeights{"/data": 2}, mountPoint: "/", want: ordinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.weights.Stress(tt.mountPoint, ordinary, builtin); got != tt.want {
+				t.Errorf("Stress(%q) = %+v, want %+v", tt.mountPoint, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/summary"
+)
+
+// HostSummary is the rollup of a single peer in the fleet view
+type HostSummary struct {
+	URL           string    `json:"url"`
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	SystemStress  float64   `json:"system_stress"`
+	CPUUsage      float64   `json:"cpu_usage"`
+	MemoryUsedPct float64   `json:"memory_used_percent"`
+	MaxTemp       float64   `json:"max_temp"`
+	LastCrashFile string    `json:"last_crash_file,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Summary is the combined health view of all peers
+type Summary struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	Hosts       []HostSummary `json:"hosts"`
+	WorstStress float64       `json:"worst_stress"`
+	WorstHost   string        `json:"worst_host,omitempty"`
+	Reachable   int           `json:"reachable"`
+	Unreachable int           `json:"unreachable"`
+}
+
+// Aggregator polls the /stats endpoint of a list of peers
+type Aggregator struct {
+	peers  []string
+	client *http.Client
+}
+
+// New creates an aggregator for the given peer base URLs
+func New(peers []string, timeout time.Duration) *Aggregator {
+	return &Aggregator{
+		peers:  peers,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Collect fetches the summary of every peer concurrently and merges them.
+// Unreachable peers are listed with their error and don't affect the
+// worst-case stress.
+func (a *Aggregator) Collect(ctx context.Context) *Summary {
+	hosts := make([]HostSummary, len(a.peers))
+	var wg sync.WaitGroup
+	for i, peer := range a.peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			hosts[i] = a.fetch(ctx, peer)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	return Merge(hosts)
+}
+
+// Merge combines host rollups into a fleet summary
+func Merge(hosts []HostSummary) *Summary {
+	fleet := &Summary{
+		Timestamp: time.Now(),
+		Hosts:     hosts,
+	}
+	for _, host := range hosts {
+		if host.Error != "" {
+			fleet.Unreachable++
+			continue
+		}
+		fleet.Reachable++
+		if fleet.WorstHost == "" || host.SystemStress > fleet.WorstStress {
+			fleet.WorstStress = host.SystemStress
+			fleet.WorstHost = host.URL
+		}
+	}
+	return fleet
+}
+
+func (a *Aggregator) fetch(ctx context.Context, peer string) HostSummary {
+	host := HostSummary{URL: peer}
+
+	s, err := a.fetchSummary(ctx, strings.TrimSuffix(peer, "/")+"/stats")
+	if err != nil {
+		host.Error = err.Error()
+		return host
+	}
+
+	host.Timestamp = s.Timestamp
+	host.SystemStress = s.SystemStress
+	host.CPUUsage = s.CPU.User + s.CPU.System
+	host.MemoryUsedPct = s.Memory.UsedPc
+	host.MaxTemp = s.Temperature.MaxTemp
+	host.LastCrashFile = s.LastCrashFile
+	return host
+}
+
+func (a *Aggregator) fetchSummary(ctx context.Context, url string) (*summary.SystemSummary, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	var s summary.SystemSummary
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode summary from %s: %w", url, err)
+	}
+	return &s, nil
+}
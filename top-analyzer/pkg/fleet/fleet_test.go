@@ -0,0 +1,69 @@
+package fleet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// peer serves a fixture summary on /stats
+func peer(t *testing.T, stats string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(stats))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAggregatorCollect(t *testing.T) {
+	calm := peer(t, `{"system_stress": 22.5, "cpu": {"user": 10, "system": 5}, "memory": {"used_percent": 40}}`)
+	busy := peer(t, `{"system_stress": 91.2, "cpu": {"user": 80, "system": 15}, "memory": {"used_percent": 70}}`)
+	broken := peer(t, `not json`)
+
+	tests := []struct {
+		name            string
+		peers           []string
+		wantWorstStress float64
+		wantWorstHost   string
+		wantReachable   int
+		wantUnreachable int
+	}{
+		{name: "two peers", peers: []string{calm.URL, busy.URL}, wantWorstStress: 91.2, wantWorstHost: busy.URL, wantReachable: 2},
+		{name: "worst first", peers: []string{busy.URL, calm.URL}, wantWorstStress: 91.2, wantWorstHost: busy.URL, wantReachable: 2},
+		{name: "trailing slash", peers: []string{calm.URL + "/"}, wantWorstStress: 22.5, wantWorstHost: calm.URL + "/", wantReachable: 1},
+		{name: "unreachable peer", peers: []string{calm.URL, broken.URL}, wantWorstStress: 22.5, wantWorstHost: calm.URL, wantReachable: 1, wantUnreachable: 1},
+		{name: "no reachable peer", peers: []string{broken.URL}, wantUnreachable: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fleet := New(tt.peers, time.Second).Collect(context.Background())
+
+			if fleet.WorstStress != tt.wantWorstStress {
+				t.Errorf("WorstStress = %v, want %v", fleet.WorstStress, tt.wantWorstStress)
+			}
+			if fleet.WorstHost != tt.wantWorstHost {
+				t.Errorf("WorstHost = %q, want %q", fleet.WorstHost, tt.wantWorstHost)
+			}
+			if fleet.Reachable != tt.wantReachable || fleet.Unreachable != tt.wantUnreachable {
+				t.Errorf("reachable/unreachable = %d/%d, want %d/%d", fleet.Reachable, fleet.Unreachable, tt.wantReachable, tt.wantUnreachable)
+			}
+			if len(fleet.Hosts) != len(tt.peers) {
+				t.Fatalf("len(Hosts) = %d, want %d", len(fleet.Hosts), len(tt.peers))
+			}
+			for i, host := range fleet.Hosts {
+				if host.URL != tt.peers[i] {
+					t.Errorf("Hosts[%d].URL = %q, want %q", i, host.URL, tt.peers[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package instance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// config is the effective configuration of this run, set by SetConfig
+var config map[string]string
+
+// SetConfig records the effective configuration of this run, option name to
+// value, so the files it produces tell which thresholds were in effect. The
+// metadata carries its hash from then on.
+func SetConfig(c map[string]string) {
+	config = c
+	current.ConfigHash = HashConfig(c)
+}
+
+// Config returns the configuration recorded by SetConfig
+func Config() map[string]string {
+	return config
+}
+
+// HashConfig returns a short hash of a configuration, the same whatever the
+// order of its options, so two files can be checked for the same settings
+// at a glance
+func HashConfig(c map[string]string) string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name + "=" + c[name] + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,44 @@
+package instance
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Metadata identifies the device and the run that produced a file
+type Metadata struct {
+	Hostname   string    `json:"hostname"`
+	InstanceID string    `json:"instance_id"` // Random UUID generated once per process start
+	StartTime  time.Time `json:"start_time"`
+	ConfigHash string    `json:"config_hash,omitempty"` // Hash of the effective configuration, see SetConfig
+}
+
+// current is the metadata of this run
+var current = newMetadata()
+
+// Current returns the metadata of this run
+func Current() Metadata {
+	return current
+}
+
+func newMetadata() Metadata {
+	hostname, _ := os.Hostname()
+	return Metadata{
+		Hostname:   hostname,
+		InstanceID: newUUID(),
+		StartTime:  time.Now(),
+	}
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "00000000-0000-4000-8000-000000000000"
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,63 @@
+package instance
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// uuidV4 matches a random version 4 UUID
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestCurrent(t *testing.T) {
+	first := Current()
+	if first.Hostname == "" {
+		t.Error("Hostname is empty")
+	}
+	if !uuidV4.MatchString(first.InstanceID) {
+		t.Errorf("InstanceID = %q, want a version 4 UUID", first.InstanceID)
+	}
+	if first.StartTime.IsZero() || first.StartTime.After(time.Now()) {
+		t.Errorf("StartTime = %v, want the process start", first.StartTime)
+	}
+
+	if second := Current(); second.InstanceID != first.InstanceID || !second.StartTime.Equal(first.StartTime) {
+		t.Errorf("Current() = %+v, then %+v, want it stable within a run", first, second)
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := newUUID()
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("newUUID() = %q, want a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("newUUID() returned %q twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestHashConfig(t *testing.T) {
+	base := map[string]string{"interval": "5s", "stress-crash-threshold": "85"}
+
+	tests := []struct {
+		name     string
+		config   map[string]string
+		wantSame bool
+	}{
+		{name: "same options", config: map[string]string{"stress-crash-threshold": "85", "interval": "5s"}, wantSame: true},
+		{name: "changed value", config: map[string]string{"interval": "5s", "stress-crash-threshold": "90"}},
+		{name: "extra option", config: map[string]string{"interval": "5s", "stress-crash-threshold": "85", "quiet": "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashConfig(tt.config) == HashConfig(base); got != tt.wantSame {
+				t.Errorf("same hash = %v, want %v", got, tt.wantSame)
+			}
+		})
+	}
+}
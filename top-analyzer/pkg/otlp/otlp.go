@@ -0,0 +1,129 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// serviceName identifies the analyzer in the exported resource
+const serviceName = "top-analyzer"
+
+// Gauge is a single gauge data point
+type Gauge struct {
+	Name       string
+	Unit       string
+	Value      float64
+	Attributes map[string]string
+}
+
+// Exporter records gauges with the OpenTelemetry metrics SDK. The reader it
+// is created with decides when they are exported: New pushes them to a
+// collector using OTLP over HTTP every interval.
+type Exporter struct {
+	provider *sdkmetric.MeterProvider
+	meter    metric.Meter
+
+	mu     sync.Mutex
+	gauges map[string]metric.Float64Gauge // by gauge name
+}
+
+// New creates an exporter pushing to the collector at endpoint, e.g.
+// http://collector:4318, every interval. Metrics are posted to its
+// /v1/metrics path.
+func New(ctx context.Context, endpoint string, interval time.Duration) (*Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+
+	exporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/metrics"),
+		otlpmetrichttp.WithTimeout(interval),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return NewWithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))), nil
+}
+
+// NewWithReader creates an exporter whose gauges are collected by reader
+func NewWithReader(reader sdkmetric.Reader) *Exporter {
+	hostname, _ := os.Hostname()
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("host.name", hostname),
+		)),
+	)
+	return &Exporter{
+		provider: provider,
+		meter:    provider.Meter(serviceName),
+		gauges:   make(map[string]metric.Float64Gauge),
+	}
+}
+
+// Record sets the gauges to their latest value, exported on the next
+// collection
+func (e *Exporter) Record(ctx context.Context, gauges []Gauge) error {
+	for _, g := range gauges {
+		instrument, err := e.instrument(g.Name, g.Unit)
+		if err != nil {
+			return err
+		}
+		instrument.Record(ctx, g.Value, metric.WithAttributes(attributes(g.Attributes)...))
+	}
+	return nil
+}
+
+// instrument returns the gauge instrument of name, creating it on first use
+func (e *Exporter) instrument(name, unit string) (metric.Float64Gauge, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if instrument, ok := e.gauges[name]; ok {
+		return instrument, nil
+	}
+	instrument, err := e.meter.Float64Gauge(name, metric.WithUnit(unit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gauge %s: %w", name, err)
+	}
+	e.gauges[name] = instrument
+	return instrument, nil
+}
+
+// Shutdown exports the gauges recorded since the last export and stops the
+// exporter
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
+
+// attributes converts a map into OpenTelemetry attributes sorted by key
+func attributes(attrs map[string]string) []attribute.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, attribute.String(key, attrs[key]))
+	}
+	return values
+}
@@ -0,0 +1,140 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// point is a collected gauge data point, with its attributes as a map
+type point struct {
+	Unit       string
+	Value      float64
+	Attributes map[string]string
+}
+
+// collect reads every gauge data point from reader, by metric name, and the
+// resource attributes
+func collect(t *testing.T, reader sdkmetric.Reader) (map[string][]point, map[string]string) {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	resource := make(map[string]string)
+	for _, kv := range rm.Resource.Attributes() {
+		resource[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	points := make(map[string][]point)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			gauge, ok := m.Data.(metricdata.Gauge[float64])
+			if !ok {
+				t.Fatalf("metric %s is a %T, want a float64 gauge", m.Name, m.Data)
+			}
+			for _, dp := range gauge.DataPoints {
+				attrs := make(map[string]string)
+				for _, kv := range dp.Attributes.ToSlice() {
+					attrs[string(kv.Key)] = kv.Value.Emit()
+				}
+				points[m.Name] = append(points[m.Name], point{Unit: m.Unit, Value: dp.Value, Attributes: attrs})
+			}
+		}
+	}
+	// The SDK keeps no particular order between attribute sets
+	for _, ps := range points {
+		sort.Slice(ps, func(i, j int) bool { return fmt.Sprint(ps[i].Attributes) < fmt.Sprint(ps[j].Attributes) })
+	}
+	return points, resource
+}
+
+func TestExporterRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record [][]Gauge // Gauges recorded in turn before collecting
+		want   map[string][]point
+	}{
+		{
+			name:   "single gauge",
+			record: [][]Gauge{{{Name: "top_analyzer.cpu.usage", Unit: "%", Value: 42.5}}},
+			want:   map[string][]point{"top_analyzer.cpu.usage": {{Unit: "%", Value: 42.5, Attributes: map[string]string{}}}},
+		},
+		{
+			name: "gauge per attribute set",
+			record: [][]Gauge{{
+				{Name: "top_analyzer.filesystem.free", Unit: "%", Value: 40, Attributes: map[string]string{"mount_point": "/"}},
+				{Name: "top_analyzer.filesystem.free", Unit: "%", Value: 90, Attributes: map[string]string{"mount_point": "/boot"}},
+			}},
+			want: map[string][]point{"top_analyzer.filesystem.free": {
+				{Unit: "%", Value: 40, Attributes: map[string]string{"mount_point": "/"}},
+				{Unit: "%", Value: 90, Attributes: map[string]string{"mount_point": "/boot"}},
+			}},
+		},
+		{
+			name: "latest value kept",
+			record: [][]Gauge{
+				{{Name: "top_analyzer.temperature", Unit: "Cel", Value: 45, Attributes: map[string]string{"sensor": "cpu"}}},
+				{{Name: "top_analyzer.temperature", Unit: "Cel", Value: 51, Attributes: map[string]string{"sensor": "cpu"}}},
+			},
+			want: map[string][]point{"top_analyzer.temperature": {{Unit: "Cel", Value: 51, Attributes: map[string]string{"sensor": "cpu"}}}},
+		},
+		{name: "nothing recorded", want: map[string][]point{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			exporter := NewWithReader(reader)
+			defer exporter.Shutdown(context.Background())
+
+			for _, gauges := range tt.record {
+				if err := exporter.Record(context.Background(), gauges); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			got, resource := collect(t, reader)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collected = %+v, want %+v", got, tt.want)
+			}
+			if resource["service.name"] != serviceName {
+				t.Errorf("resource service.name = %q, want %q", resource["service.name"], serviceName)
+			}
+		})
+	}
+}
+
+func TestNewInvalidEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "collector URL", endpoint: "http://collector:4318"},
+		{name: "trailing slash", endpoint: "http://collector:4318/"},
+		{name: "no scheme", endpoint: "collector:4318", wantErr: true},
+		{name: "not a URL", endpoint: "://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := New(context.Background(), tt.endpoint, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if exporter != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+				exporter.Shutdown(ctx)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import "github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+
+// Aggregate combines the samples collected between two evaluations into a
+// single sample. CPU, memory, swap, load and the temperature of the sensors
+// in the latest sample are averaged; processes, filesystems and the
+// timestamp are those of the latest sample, since averaging them has no
+// meaning.
+func Aggregate(samples []*SystemStats) *SystemStats {
+	if len(samples) == 0 {
+		return nil
+	}
+	latest := samples[len(samples)-1]
+	if len(samples) == 1 {
+		return latest
+	}
+
+	agg := *latest
+	agg.CPU = CPU{}
+	agg.Memory = Memory{}
+	agg.Swap = Swap{}
+	agg.LoadAverage = LoadAverage{}
+	if latest.PerCore != nil {
+		agg.PerCore = make([]CPU, len(latest.PerCore))
+	}
+	agg.Temperature = temperature.TemperatureStats{
+		Sensors:    make(map[string]float64, len(latest.Temperature.Sensors)),
+		Sources:    latest.Temperature.Sources,
+		Thresholds: latest.Temperature.Thresholds,
+		Rescaled:   latest.Temperature.Rescaled,
+	}
+
+	// Sections are averaged over the samples that have them, and present in
+	// the aggregate when any sample has them
+	if latest.ParsedSections != nil {
+		agg.ParsedSections = make(map[Section]bool)
+	}
+	counts := make(map[Section]int)
+	readings := make(map[string]int)
+	coreSamples := 0
+	for _, s := range samples {
+		for _, section := range Sections {
+			if s.Has(section) {
+				counts[section]++
+				if agg.ParsedSections != nil {
+					agg.ParsedSections[section] = true
+				}
+			}
+		}
+		if s.Has(SectionCPU) {
+			addCPU(&agg.CPU, s.CPU)
+			if len(s.PerCore) == len(agg.PerCore) {
+				for i, core := range s.PerCore {
+					addCPU(&agg.PerCore[i], core)
+				}
+				coreSamples++
+			}
+		}
+		if s.Has(SectionMemory) {
+			agg.Memory.Total += s.Memory.Total
+			agg.Memory.Used += s.Memory.Used
+			agg.Memory.Free += s.Memory.Free
+			agg.Memory.Shared += s.Memory.Shared
+			agg.Memory.Buffers += s.Memory.Buffers
+			agg.Memory.Cached += s.Memory.Cached
+		}
+		if s.Has(SectionSwap) {
+			agg.Swap.Total += s.Swap.Total
+			agg.Swap.Used += s.Swap.Used
+			agg.Swap.Free += s.Swap.Free
+		}
+		if s.Has(SectionLoad) {
+			agg.LoadAverage.One += s.LoadAverage.One
+			agg.LoadAverage.Five += s.LoadAverage.Five
+			agg.LoadAverage.Fifteen += s.LoadAverage.Fifteen
+		}
+		for name, temp := range s.Temperature.Sensors {
+			// A sensor missing from the latest sample stays missing
+			if _, ok := latest.Temperature.Sensors[name]; ok {
+				agg.Temperature.Sensors[name] += temp
+				readings[name]++
+			}
+		}
+	}
+
+	if n := counts[SectionCPU]; n > 0 {
+		scaleCPU(&agg.CPU, float64(n))
+		for i := range agg.PerCore {
+			scaleCPU(&agg.PerCore[i], float64(coreSamples))
+		}
+	}
+	if n := int64(counts[SectionMemory]); n > 0 {
+		agg.Memory.Total /= n
+		agg.Memory.Used /= n
+		agg.Memory.Free /= n
+		agg.Memory.Shared /= n
+		agg.Memory.Buffers /= n
+		agg.Memory.Cached /= n
+	}
+	if n := int64(counts[SectionSwap]); n > 0 {
+		agg.Swap.Total /= n
+		agg.Swap.Used /= n
+		agg.Swap.Free /= n
+	}
+	if n := float64(counts[SectionLoad]); n > 0 {
+		agg.LoadAverage.One /= n
+		agg.LoadAverage.Five /= n
+		agg.LoadAverage.Fifteen /= n
+	}
+	if agg.ParsedSections != nil {
+		// Processes are those of the latest sample, so is their presence
+		agg.ParsedSections[SectionProcesses] = latest.Has(SectionProcesses)
+	}
+	for name, sum := range agg.Temperature.Sensors {
+		agg.Temperature.Sensors[name] = sum / float64(readings[name])
+	}
+	return &agg
+}
+
+func addCPU(sum *CPU, c CPU) {
+	sum.User += c.User
+	sum.Sys += c.Sys
+	sum.Nice += c.Nice
+	sum.Idle += c.Idle
+	sum.IO += c.IO
+	sum.IRQ += c.IRQ
+	sum.SIRQ += c.SIRQ
+}
+
+func scaleCPU(c *CPU, n float64) {
+	c.User /= n
+	c.Sys /= n
+	c.Nice /= n
+	c.Idle /= n
+	c.IO /= n
+	c.IRQ /= n
+	c.SIRQ /= n
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DedupStrategy selects how duplicate process entries are collapsed
+type DedupStrategy string
+
+const (
+	DedupNone    DedupStrategy = "none"    // Keep every process entry
+	DedupCommand DedupStrategy = "command" // Keep one entry per command
+	DedupBase    DedupStrategy = "base"    // Keep one entry per base command, see BaseCommand
+	DedupPID     DedupStrategy = "pid"     // Keep one entry per PID
+)
+
+// ParseDedupStrategy validates a deduplication strategy name
+func ParseDedupStrategy(s string) (DedupStrategy, error) {
+	switch strategy := DedupStrategy(s); strategy {
+	case DedupNone, DedupCommand, DedupBase, DedupPID:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown dedup strategy %q (expected none, command, base or pid)", s)
+}
+
+// BaseCommand returns the part of a command that identifies the program, so
+// that variants such as "postgres: writer process" and "postgres:
+// checkpointer" group together: the first token before any ':'. Kernel
+// threads such as "[kworker/0:1-events]" are reduced to "[kworker]".
+func BaseCommand(command string) string {
+	if strings.HasPrefix(command, "[") {
+		name := command[1:]
+		if i := strings.IndexAny(name, "/]"); i >= 0 {
+			name = name[:i]
+		}
+		return "[" + name + "]"
+	}
+	if i := strings.IndexByte(command, ':'); i >= 0 {
+		command = command[:i]
+	}
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return command
+}
+
+// DedupProcesses collapses duplicate processes according to strategy. When
+// deduplicating by command or base command the entry with the highest memory
+// or CPU usage is kept, with its full command. The order of first appearance
+// is preserved.
+func DedupProcesses(procs []Process, strategy DedupStrategy) []Process {
+	result := make([]Process, 0, len(procs))
+	if strategy != DedupCommand && strategy != DedupBase && strategy != DedupPID {
+		return append(result, procs...)
+	}
+
+	index := make(map[string]int)
+	for _, proc := range procs {
+		key := proc.Command
+		switch strategy {
+		case DedupBase:
+			key = BaseCommand(proc.Command)
+		case DedupPID:
+			key = fmt.Sprint(proc.PID)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(result)
+			result = append(result, proc)
+			continue
+		}
+
+		// Keep the process with higher memory or CPU usage
+		existing := result[i]
+		if strategy != DedupPID &&
+			(proc.MemoryPercent() > existing.MemoryPercent() || proc.CPUPercent > existing.CPUPercent) {
+			result[i] = proc
+		}
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupProcesses(t *testing.T) {
+	procs := []Process{
+		{PID: 10, Command: "nginx: worker process", CPUPercent: 1, VSZPercent: 2},
+		{PID: 11, Command: "nginx: worker process", CPUPercent: 5, VSZPercent: 2},
+		{PID: 12, Command: "nginx: worker process", CPUPercent: 3, VSZPercent: 2},
+		{PID: 20, Command: "postgres: writer process", CPUPercent: 1, VSZPercent: 8},
+		{PID: 21, Command: "postgres: checkpointer", CPUPercent: 2, VSZPercent: 9},
+		{PID: 10, Command: "nginx: worker process", CPUPercent: 4, VSZPercent: 2},
+	}
+
+	tests := []struct {
+		strategy DedupStrategy
+		wantPIDs []int
+	}{
+		{strategy: DedupNone, wantPIDs: []int{10, 11, 12, 20, 21, 10}},
+		{strategy: DedupCommand, wantPIDs: []int{11, 20, 21}},
+		{strategy: DedupBase, wantPIDs: []int{11, 21}},
+		{strategy: DedupPID, wantPIDs: []int{10, 11, 12, 20, 21}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			var pids []int
+			for _, proc := range DedupProcesses(procs, tt.strategy) {
+				pids = append(pids, proc.PID)
+			}
+			if !reflect.DeepEqual(pids, tt.wantPIDs) {
+				t.Errorf("DedupProcesses(%s) PIDs = %v, want %v", tt.strategy, pids, tt.wantPIDs)
+			}
+		})
+	}
+}
+
+func TestParseDedupStrategy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    DedupStrategy
+		wantErr bool
+	}{
+		{input: "none", want: DedupNone},
+		{input: "command", want: DedupCommand},
+		{input: "base", want: DedupBase},
+		{input: "pid", want: DedupPID},
+		{input: "name", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDedupStrategy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDedupStrategy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDedupStrategy(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "postgres: writer process", want: "postgres"},
+		{command: "postgres: checkpointer", want: "postgres"},
+		{command: "/usr/bin/python3 app.py", want: "/usr/bin/python3"},
+		{command: "nginx", want: "nginx"},
+		{command: "[kworker/0:1-events]", want: "[kworker]"},
+		{command: "[kthreadd]", want: "[kthreadd]"},
+		{command: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := BaseCommand(tt.command); got != tt.want {
+				t.Errorf("BaseCommand(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupBaseKeepsFullCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		procs        []Process
+		wantCommands []string
+	}{
+		{
+			name: "postgres subprocesses",
+			procs: []Process{
+				{PID: 20, Command: "postgres: writer process", CPUPercent: 1, VSZPercent: 8},
+				{PID: 21, Command: "postgres: checkpointer", CPUPercent: 2, VSZPercent: 9},
+				{PID: 22, Command: "postgres: autovacuum launcher", CPUPercent: 0, VSZPercent: 1},
+			},
+			wantCommands: []string{"postgres: checkpointer"},
+		},
+		{
+			name: "kernel threads",
+			procs: []Process{
+				{PID: 5, Command: "[kworker/0:1-events]", CPUPercent: 0.5},
+				{PID: 6, Command: "[kworker/1:0-mm_percpu_wq]", CPUPercent: 1.5},
+				{PID: 7, Command: "[ksoftirqd/0]"},
+			},
+			wantCommands: []string{"[kworker/1:0-mm_percpu_wq]", "[ksoftirqd/0]"},
+		},
+		{
+			name: "distinct programs",
+			procs: []Process{
+				{PID: 20, Command: "postgres: writer process"},
+				{PID: 30, Command: "nginx: worker process"},
+			},
+			wantCommands: []string{"postgres: writer process", "nginx: worker process"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			for _, proc := range DedupProcesses(tt.procs, DedupBase) {
+				commands = append(commands, proc.Command)
+			}
+			if !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("DedupProcesses(base) commands = %q, want %q", commands, tt.wantCommands)
+			}
+		})
+	}
+}
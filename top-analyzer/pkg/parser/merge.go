@@ -0,0 +1,72 @@
+package parser
+
+// Merge fills the sections s lacks with those of other, so stats collected
+// from several sources, e.g. CPU from top and filesystems from df, form a
+// single sample. A section counts as lacking when it wasn't parsed or is
+// all zero. On conflict s wins: a section both have keeps the values of s,
+// and so does a sensor or mount point both report. The timestamp becomes
+// the later of the two.
+func (s *SystemStats) Merge(other *SystemStats) {
+	if other == nil {
+		return
+	}
+
+	take := func(section Section, ownZero, otherZero bool) bool {
+		if (s.Has(section) && !ownZero) || !other.Has(section) || otherZero {
+			return false
+		}
+		if s.ParsedSections != nil {
+			s.ParsedSections[section] = true
+		}
+		return true
+	}
+	if take(SectionCPU, s.CPU == CPU{}, other.CPU == CPU{}) {
+		s.CPU = other.CPU
+		s.PerCore = append([]CPU(nil), other.PerCore...)
+		s.CPUScale = other.CPUScale
+		s.CPUClamped = other.CPUClamped
+	}
+	if take(SectionMemory, s.Memory == Memory{}, other.Memory == Memory{}) {
+		s.Memory = other.Memory
+	}
+	if take(SectionSwap, s.Swap == Swap{}, other.Swap == Swap{}) {
+		s.Swap = other.Swap
+	}
+	if take(SectionLoad, s.LoadAverage == LoadAverage{}, other.LoadAverage == LoadAverage{}) {
+		s.LoadAverage = other.LoadAverage
+	}
+	if take(SectionProcesses, s.ProcessCount() == 0, other.ProcessCount() == 0) {
+		s.Processes = append([]Process(nil), other.Processes...)
+		s.Tasks = other.Tasks
+		s.Truncated = other.Truncated
+	}
+
+	// Sensors and mount points are combined one by one
+	s.Temperature.Sensors = mergeMap(s.Temperature.Sensors, other.Temperature.Sensors)
+	s.Temperature.Sources = mergeMap(s.Temperature.Sources, other.Temperature.Sources)
+	s.Temperature.Thresholds = mergeMap(s.Temperature.Thresholds, other.Temperature.Thresholds)
+	s.Temperature.Rescaled = mergeMap(s.Temperature.Rescaled, other.Temperature.Rescaled)
+	s.Filesystem = mergeMap(s.Filesystem, other.Filesystem)
+
+	if s.Format == "" {
+		s.Format = other.Format
+	}
+	if other.Timestamp.After(s.Timestamp) {
+		s.Timestamp = other.Timestamp
+	}
+}
+
+// mergeMap adds the entries of other whose key isn't in m, allocating m when
+// needed so other is never shared
+func mergeMap[V any](m, other map[string]V) map[string]V {
+	for key, value := range other {
+		if _, exists := m[key]; exists {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]V, len(other))
+		}
+		m[key] = value
+	}
+	return m
+}
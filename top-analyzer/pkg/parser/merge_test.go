@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	earlier := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Second)
+
+	cpuOnly := func() *SystemStats {
+		s := &SystemStats{
+			Timestamp:      earlier,
+			CPU:            CPU{User: 30, Sys: 10, Idle: 60},
+			ParsedSections: map[Section]bool{SectionCPU: true},
+		}
+		s.Temperature.Sensors = map[string]float64{"cpu_thermal": 50}
+		return s
+	}
+	memoryOnly := func() *SystemStats {
+		s := &SystemStats{
+			Timestamp:      later,
+			Memory:         Memory{Total: 1000, Used: 400, Free: 600},
+			ParsedSections: map[Section]bool{SectionMemory: true},
+			Filesystem:     map[string]FilesystemStats{"/": {Device: "/dev/sda1", Size: 100, UsedPct: 40, MountPoint: "/"}},
+		}
+		s.Temperature.Sensors = map[string]float64{"cpu_thermal": 55, "nvme": 38}
+		return s
+	}
+
+	tests := []struct {
+		name         string
+		s            *SystemStats
+		other        *SystemStats
+		wantCPU      CPU
+		wantMemory   Memory
+		wantSections []Section
+		wantSensors  map[string]float64
+		wantMounts   int
+		wantTime     time.Time
+	}{
+		{
+			name:         "cpu and memory",
+			s:            cpuOnly(),
+			other:        memoryOnly(),
+			wantCPU:      CPU{User: 30, Sys: 10, Idle: 60},
+			wantMemory:   Memory{Total: 1000, Used: 400, Free: 600},
+			wantSections: []Section{SectionCPU, SectionMemory},
+			wantSensors:  map[string]float64{"cpu_thermal": 50, "nvme": 38},
+			wantMounts:   1,
+			wantTime:     later,
+		},
+		{
+			name:         "memory and cpu",
+			s:            memoryOnly(),
+			other:        cpuOnly(),
+			wantCPU:      CPU{User: 30, Sys: 10, Idle: 60},
+			wantMemory:   Memory{Total: 1000, Used: 400, Free: 600},
+			wantSections: []Section{SectionCPU, SectionMemory},
+			wantSensors:  map[string]float64{"cpu_thermal": 55, "nvme": 38},
+			wantMounts:   1,
+			wantTime:     later,
+		},
+		{
+			name:  "both have cpu",
+			s:     cpuOnly(),
+			other: &SystemStats{CPU: CPU{User: 90, Idle: 10}, ParsedSections: map[Section]bool{SectionCPU: true}},
+			// The receiver wins
+			wantCPU:      CPU{User: 30, Sys: 10, Idle: 60},
+			wantSections: []Section{SectionCPU},
+			wantSensors:  map[string]float64{"cpu_thermal": 50},
+			wantTime:     earlier,
+		},
+		{
+			name:         "nil",
+			s:            cpuOnly(),
+			wantCPU:      CPU{User: 30, Sys: 10, Idle: 60},
+			wantSections: []Section{SectionCPU},
+			wantSensors:  map[string]float64{"cpu_thermal": 50},
+			wantTime:     earlier,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var otherSensors map[string]float64
+			if tt.other != nil {
+				otherSensors = maps.Clone(tt.other.Temperature.Sensors)
+			}
+
+			tt.s.Merge(tt.other)
+
+			if tt.s.CPU != tt.wantCPU {
+				t.Errorf("CPU = %+v, want %+v", tt.s.CPU, tt.wantCPU)
+			}
+			if tt.s.Memory != tt.wantMemory {
+				t.Errorf("Memory = %+v, want %+v", tt.s.Memory, tt.wantMemory)
+			}
+			for _, section := range Sections {
+				if got, want := tt.s.Has(section), slices.Contains(tt.wantSections, section); got != want {
+					t.Errorf("Has(%s) = %v, want %v", section, got, want)
+				}
+			}
+			if !reflect.DeepEqual(tt.s.Temperature.Sensors, tt.wantSensors) {
+				t.Errorf("sensors = %v, want %v", tt.s.Temperature.Sensors, tt.wantSensors)
+			}
+			if len(tt.s.Filesystem) != tt.wantMounts {
+				t.Errorf("mount points = %d, want %d", len(tt.s.Filesystem), tt.wantMounts)
+			}
+			if !tt.s.Timestamp.Equal(tt.wantTime) {
+				t.Errorf("Timestamp = %v, want %v", tt.s.Timestamp, tt.wantTime)
+			}
+			if tt.other != nil && !reflect.DeepEqual(tt.other.Temperature.Sensors, otherSensors) {
+				t.Errorf("other sensors changed to %v, want %v", tt.other.Temperature.Sensors, otherSensors)
+			}
+		})
+	}
+}
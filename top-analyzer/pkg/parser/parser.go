@@ -1,9 +1,9 @@
 package parser
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,12 +14,67 @@ import (
 // SystemStats represents the system statistics
 type SystemStats struct {
 	Timestamp   time.Time
+	Format      Format // Flavor of the top output the stats were parsed from, empty when not parsed from top
 	CPU         CPU
+	PerCore     []CPU // Per-core CPU statistics, when top reports them
+	CPUScale    int   // Cores the summed CPU percentages were divided by, 0 when not divided by the cores
+	CPUClamped  bool  // The summed CPU percentages exceeded 100% per core and were scaled down to a 100% total
 	Memory      Memory
+	Swap        Swap
 	LoadAverage LoadAverage
+	Tasks       Tasks // Task counts of the top summary, zero when top doesn't print them
 	Processes   []Process
+	Truncated   bool // Process table holds far fewer rows than the Tasks total
 	Temperature temperature.TemperatureStats
 	Filesystem  map[string]FilesystemStats
+
+	// Sections of the top output that were parsed, nil when the stats
+	// didn't come from top output and every section counts as present
+	ParsedSections map[Section]bool
+}
+
+// Format is the flavor of top whose output was parsed
+type Format string
+
+const (
+	FormatBusyBox Format = "busybox"
+	FormatGNU     Format = "gnu"
+)
+
+// Section is a part of the top output a group of metrics comes from
+type Section string
+
+const (
+	SectionCPU       Section = "cpu"
+	SectionMemory    Section = "memory"
+	SectionSwap      Section = "swap"
+	SectionLoad      Section = "load"
+	SectionProcesses Section = "processes"
+)
+
+// Sections lists every section of the top output
+var Sections = []Section{SectionCPU, SectionMemory, SectionSwap, SectionLoad, SectionProcesses}
+
+// Has reports whether the metrics of section are present, as opposed to
+// zero because top's output was cut short before it
+func (s *SystemStats) Has(section Section) bool {
+	return s.ParsedSections == nil || s.ParsedSections[section]
+}
+
+// Missing returns the sections that were not parsed
+func (s *SystemStats) Missing() []Section {
+	var missing []Section
+	for _, section := range Sections {
+		if !s.Has(section) {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+// markParsed records that section was parsed
+func (s *SystemStats) markParsed(section Section) {
+	s.ParsedSections[section] = true
 }
 
 // CPU represents CPU statistics
@@ -43,6 +98,13 @@ type Memory struct {
 	Cached  int64
 }
 
+// Swap represents swap space statistics
+type Swap struct {
+	Total int64
+	Used  int64
+	Free  int64
+}
+
 // LoadAverage represents load averages for 1, 5, and 15 minutes
 type LoadAverage struct {
 	One     float64
@@ -50,6 +112,30 @@ type LoadAverage struct {
 	Fifteen float64
 }
 
+// Tasks represents the task counts of the GNU top summary line, which cover
+// every process even when top truncates the process table
+type Tasks struct {
+	Total    int
+	Running  int
+	Sleeping int
+	Stopped  int
+	Zombie   int
+}
+
+// truncatedRatio is the share of the Tasks total below which the process
+// table counts as truncated. Some slack is left since tasks come and go
+// while top prints.
+const truncatedRatio = 0.9
+
+// ProcessCount returns the number of processes, taken from the top summary
+// when present and from the process table otherwise
+func (s *SystemStats) ProcessCount() int {
+	if s.Tasks.Total > 0 {
+		return s.Tasks.Total
+	}
+	return len(s.Processes)
+}
+
 // Process represents a process
 type Process struct {
 	PID        int
@@ -68,6 +154,32 @@ type Process struct {
 	Command    string
 }
 
+// MemoryPercent returns the resident memory percentage when the top output
+// provides it and falls back to the virtual size percentage otherwise
+func (p Process) MemoryPercent() float64 {
+	if p.RSS > 0 || p.MemPercent > 0 {
+		return p.MemPercent
+	}
+	return p.VSZPercent
+}
+
+// UninterruptibleCommands returns the sorted, distinct commands of the
+// processes in uninterruptible sleep (D state), which are usually the ones
+// waiting on I/O
+func UninterruptibleCommands(procs []Process) []string {
+	seen := make(map[string]bool)
+	commands := make([]string, 0)
+	for _, proc := range procs {
+		if !strings.HasPrefix(proc.State, "D") || seen[proc.Command] {
+			continue
+		}
+		seen[proc.Command] = true
+		commands = append(commands, proc.Command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
 // FilesystemStats represents statistics for a filesystem
 type FilesystemStats struct {
 	Device     string
@@ -80,12 +192,8 @@ type FilesystemStats struct {
 }
 
 func ParseTopOutput(output []byte) (*SystemStats, error) {
-	stats := &SystemStats{}
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-	lines := []string{}
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
+	stats := &SystemStats{ParsedSections: make(map[Section]bool)}
+	lines := splitLines(output)
 	if len(lines) == 0 {
 		return stats, nil
 	}
@@ -103,12 +211,15 @@ func ParseTopOutput(output []byte) (*SystemStats, error) {
 	}
 
 	if isBusyBox {
+		stats.Format = FormatBusyBox
 		parseBusyBoxTop(lines, stats)
 	} else if isGNUTop {
+		stats.Format = FormatGNU
 		parseGNUTop(lines, stats)
 	} else {
 		return nil, fmt.Errorf("unknown top output format")
 	}
+	stats.Truncated = float64(len(stats.Processes)) < float64(stats.Tasks.Total)*truncatedRatio
 	return stats, nil
 }
 
@@ -124,43 +235,52 @@ func parseBusyBoxTop(lines []string, stats *SystemStats) {
 				stats.Memory.Buffers = parseKValue(parts[7])
 				stats.Memory.Cached = parseKValue(parts[9])
 				stats.Memory.Total = stats.Memory.Used + stats.Memory.Free + stats.Memory.Shared + stats.Memory.Buffers + stats.Memory.Cached
+				stats.markParsed(SectionMemory)
 			}
 		}
 		if strings.HasPrefix(line, "CPU:") {
-			parts := strings.Fields(line)
-			if len(parts) >= 14 {
-				stats.CPU.User = parsePercent(parts[1])
-				stats.CPU.Sys = parsePercent(parts[3])
-				stats.CPU.Nice = parsePercent(parts[5])
-				stats.CPU.Idle = parsePercent(parts[7])
-				stats.CPU.IO = parsePercent(parts[9])
-				stats.CPU.IRQ = parsePercent(parts[11])
-				stats.CPU.SIRQ = parsePercent(parts[13])
-			}
+			// Example: CPU:  10% usr   5% sys   0% nic  80% idle   2% io   0% irq   3% sirq
+			parseBusyBoxCPUFields(strings.Fields(line)[1:], &stats.CPU)
+			normalizeCPU(stats, numCPU())
+			stats.markParsed(SectionCPU)
 		}
-		if strings.HasPrefix(line, "Load average:") {
-			parts := strings.Fields(line)
-			if len(parts) >= 5 {
-				stats.LoadAverage.One = parseFloat(parts[2])
-				stats.LoadAverage.Five = parseFloat(parts[3])
-				stats.LoadAverage.Fifteen = parseFloat(parts[4])
-			}
+		if load, ok := parseLoadAverage(line); ok {
+			// Example: Load average: 0.10 0.24 0.20 1/123 4567, possibly
+			// after other tokens or with different casing on some builds
+			stats.LoadAverage = load
+			stats.markParsed(SectionLoad)
 		}
 		if strings.HasPrefix(line, "  PID") {
-			// Process table header
+			// Process table header. Locate the columns by name since the
+			// memory-detail option adds an RSS column and shifts the rest.
+			columns := busyBoxColumns(line)
+			pidCol, ppidCol, userCol, statCol := columns["PID"], columns["PPID"], columns["USER"], columns["STAT"]
+			vszCol, vszPctCol, cpuCol, cpuPctCol := columns["VSZ"], columns["%VSZ"], columns["CPU"], columns["%CPU"]
+			rssCol, hasRSS := columns["RSS"]
+			commandCol := columns["COMMAND"]
+
+			stats.Processes = make([]Process, 0, len(lines)-i-1)
+			stats.markParsed(SectionProcesses)
+			var parts []string
 			for j := i + 1; j < len(lines); j++ {
-				parts := strings.Fields(lines[j])
-				if len(parts) >= 8 {
+				parts = appendFields(parts[:0], lines[j])
+				if len(parts) >= len(columns) {
 					proc := Process{}
-					proc.PID = parseInt(parts[0])
-					proc.PPID = parseInt(parts[1])
-					proc.User = parts[2]
-					proc.State = parts[3]
-					proc.VSZ = parseKValue(parts[4])
-					proc.VSZPercent = parsePercent(parts[5])
-					proc.CPU = parseInt(parts[6])
-					proc.CPUPercent = parsePercent(parts[7])
-					proc.Command = strings.Join(parts[8:], " ")
+					proc.PID = parseInt(parts[pidCol])
+					proc.PPID = parseInt(parts[ppidCol])
+					proc.User = parts[userCol]
+					proc.State = parts[statCol]
+					proc.VSZ = parseKValue(parts[vszCol])
+					proc.VSZPercent = parsePercent(parts[vszPctCol])
+					proc.CPU = parseInt(parts[cpuCol])
+					proc.CPUPercent = parsePercent(parts[cpuPctCol])
+					if hasRSS {
+						proc.RSS = parseKValue(parts[rssCol])
+						if stats.Memory.Total > 0 {
+							proc.MemPercent = float64(proc.RSS) / float64(stats.Memory.Total) * 100
+						}
+					}
+					proc.Command = joinCommand(parts[commandCol:])
 					stats.Processes = append(stats.Processes, proc)
 				}
 			}
@@ -169,40 +289,109 @@ func parseBusyBoxTop(lines []string, stats *SystemStats) {
 	}
 }
 
+// parseBusyBoxCPUFields parses the "value% label" pairs of a busybox CPU line
+// by label, since busybox variants differ in which fields they print. Fields
+// that are absent stay 0.
+func parseBusyBoxCPUFields(fields []string, cpu *CPU) {
+	for i := 0; i+1 < len(fields); i++ {
+		if !strings.HasSuffix(fields[i], "%") {
+			continue
+		}
+		val := parsePercent(fields[i])
+		switch fields[i+1] {
+		case "usr":
+			cpu.User = val
+		case "sys":
+			cpu.Sys = val
+		case "nic":
+			cpu.Nice = val
+		case "idle":
+			cpu.Idle = val
+		case "io", "iow":
+			cpu.IO = val
+		case "irq":
+			cpu.IRQ = val
+		case "sirq":
+			cpu.SIRQ = val
+		}
+		i++
+	}
+}
+
+// numCPU returns the number of cores the CPU percentages are summed over
+var numCPU = runtime.NumCPU
+
+// normalizeCPU rescales CPU percentages that some busybox builds sum across
+// cores, so that User+Sys exceeds 100%. They are divided by the number of
+// cores, or clamped to 100% when that is not enough.
+func normalizeCPU(stats *SystemStats, cores int) {
+	total := stats.CPU.User + stats.CPU.Sys
+	if total <= 100 {
+		return
+	}
+
+	scale := float64(cores)
+	if cores <= 1 || total/scale > 100 {
+		scale = total / 100
+		stats.CPUClamped = true
+	} else {
+		stats.CPUScale = cores
+	}
+	stats.CPU.User /= scale
+	stats.CPU.Sys /= scale
+	stats.CPU.Nice /= scale
+	stats.CPU.Idle /= scale
+	stats.CPU.IO /= scale
+	stats.CPU.IRQ /= scale
+	stats.CPU.SIRQ /= scale
+}
+
+// busyBoxColumns maps the busybox process table column names to their index,
+// falling back to the default layout for columns missing from the header
+func busyBoxColumns(header string) map[string]int {
+	columns := map[string]int{
+		"PID":     0,
+		"PPID":    1,
+		"USER":    2,
+		"STAT":    3,
+		"VSZ":     4,
+		"%VSZ":    5,
+		"CPU":     6,
+		"%CPU":    7,
+		"COMMAND": 8,
+	}
+	for i, name := range strings.Fields(header) {
+		columns[name] = i
+	}
+	return columns
+}
+
 func parseGNUTop(lines []string, stats *SystemStats) {
+	hasAggregateCPU := false
 	for i := 0; i < len(lines); i++ {
 		line := lines[i]
 		if strings.HasPrefix(line, "%Cpu(s):") {
 			// Example: %Cpu(s):  0.0 us,  0.0 sy,  0.0 ni,100.0 id,  0.0 wa,  0.0 hi,  0.0 si,  0.0 st
-			cpuFields := strings.Split(line, ":")[1]
-			cpuParts := strings.Split(cpuFields, ",")
-			for _, part := range cpuParts {
-				fields := strings.Fields(strings.TrimSpace(part))
-				if len(fields) == 2 {
-					val := parseFloat(fields[0])
-					switch fields[1] {
-					case "us":
-						stats.CPU.User = val
-					case "sy":
-						stats.CPU.Sys = val
-					case "ni":
-						stats.CPU.Nice = val
-					case "id":
-						stats.CPU.Idle = val
-					case "wa":
-						stats.CPU.IO = val
-					case "hi":
-						stats.CPU.IRQ = val
-					case "si":
-						stats.CPU.SIRQ = val
-					}
+			parseGNUCPUFields(strings.SplitN(line, ":", 2)[1], &stats.CPU)
+			hasAggregateCPU = true
+			stats.markParsed(SectionCPU)
+		} else if strings.HasPrefix(line, "%Cpu") && strings.Contains(line, ":") {
+			// Per-core line, shown when top is configured to display each CPU
+			// Example: %Cpu3  : 98.0 us,  2.0 sy,  0.0 ni,  0.0 id,  0.0 wa,  0.0 hi,  0.0 si,  0.0 st
+			parts := strings.SplitN(line, ":", 2)
+			core, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(parts[0], "%Cpu")))
+			if err == nil && core >= 0 {
+				for len(stats.PerCore) <= core {
+					stats.PerCore = append(stats.PerCore, CPU{})
 				}
+				parseGNUCPUFields(parts[1], &stats.PerCore[core])
 			}
 		}
 		if strings.HasPrefix(line, "MiB Mem :") {
 			// Example: MiB Mem :   2017.4 total,    348.5 free,    447.2 used,   1284.3 buff/cache
 			memFields := strings.Split(line, ":")[1]
 			memParts := strings.Split(memFields, ",")
+			hasTotal := false
 			for _, part := range memParts {
 				fields := strings.Fields(strings.TrimSpace(part))
 				if len(fields) == 2 {
@@ -210,6 +399,7 @@ func parseGNUTop(lines []string, stats *SystemStats) {
 					switch fields[1] {
 					case "total":
 						stats.Memory.Total = int64(val * 1024 * 1024)
+						hasTotal = true
 					case "free":
 						stats.Memory.Free = int64(val * 1024 * 1024)
 					case "used":
@@ -219,23 +409,64 @@ func parseGNUTop(lines []string, stats *SystemStats) {
 					}
 				}
 			}
+			// A zero total is still parsed, so it can be reported as such
+			if hasTotal {
+				stats.markParsed(SectionMemory)
+			}
 		}
-		if strings.Contains(line, "load average:") {
-			// Example: top - 07:32:26 up 4 days, 23:37,  1 user,  load average: 0.10, 0.24, 0.20
-			idx := strings.Index(line, "load average:")
-			if idx != -1 {
-				loads := strings.Split(line[idx+len("load average:"):], ",")
-				if len(loads) >= 3 {
-					stats.LoadAverage.One = parseFloat(strings.TrimSpace(loads[0]))
-					stats.LoadAverage.Five = parseFloat(strings.TrimSpace(loads[1]))
-					stats.LoadAverage.Fifteen = parseFloat(strings.TrimSpace(loads[2]))
+		if strings.HasPrefix(line, "MiB Swap:") {
+			// Example: MiB Swap:   2048.0 total,   1024.0 free,   1024.0 used.    812.3 avail Mem
+			fields := strings.Fields(strings.SplitN(line, ":", 2)[1])
+			for j := 0; j+1 < len(fields); j++ {
+				val := int64(parseFloat(fields[j]) * 1024 * 1024)
+				switch strings.TrimRight(fields[j+1], ",.") {
+				case "total":
+					stats.Swap.Total = val
+				case "free":
+					stats.Swap.Free = val
+				case "used":
+					stats.Swap.Used = val
 				}
 			}
+			stats.markParsed(SectionSwap)
+		}
+		if strings.HasPrefix(line, "Tasks:") {
+			// Example: Tasks: 123 total,   2 running, 120 sleeping,   0 stopped,   1 zombie
+			for _, part := range strings.Split(strings.SplitN(line, ":", 2)[1], ",") {
+				fields := strings.Fields(part)
+				if len(fields) != 2 {
+					continue
+				}
+				count, err := strconv.Atoi(fields[0])
+				if err != nil {
+					continue
+				}
+				switch fields[1] {
+				case "total":
+					stats.Tasks.Total = count
+				case "running":
+					stats.Tasks.Running = count
+				case "sleeping":
+					stats.Tasks.Sleeping = count
+				case "stopped":
+					stats.Tasks.Stopped = count
+				case "zombie":
+					stats.Tasks.Zombie = count
+				}
+			}
+		}
+		if load, ok := parseLoadAverage(line); ok {
+			// Example: top - 07:32:26 up 4 days, 23:37,  1 user,  load average: 0.10, 0.24, 0.20
+			stats.LoadAverage = load
+			stats.markParsed(SectionLoad)
 		}
 		if strings.HasPrefix(line, "  PID") || strings.HasPrefix(line, "PID ") {
 			// Process table header
+			stats.Processes = make([]Process, 0, len(lines)-i-1)
+			stats.markParsed(SectionProcesses)
+			var parts []string
 			for j := i + 1; j < len(lines); j++ {
-				parts := strings.Fields(lines[j])
+				parts = appendFields(parts[:0], lines[j])
 				if len(parts) >= 12 {
 					proc := Process{}
 					proc.PID = parseInt(parts[0])
@@ -248,19 +479,114 @@ func parseGNUTop(lines []string, stats *SystemStats) {
 					proc.CPUPercent = parseFloat(parts[7])
 					proc.MemPercent = parseFloat(parts[8])
 					proc.Time = parts[9]
-					proc.Command = strings.Join(parts[10:], " ")
+					proc.Command = joinCommand(parts[10:])
 					stats.Processes = append(stats.Processes, proc)
 				}
 			}
 			break
 		}
 	}
+
+	// In per-core mode top omits the %Cpu(s) line, so derive the aggregate
+	if !hasAggregateCPU && len(stats.PerCore) > 0 {
+		n := float64(len(stats.PerCore))
+		for _, core := range stats.PerCore {
+			stats.CPU.User += core.User / n
+			stats.CPU.Sys += core.Sys / n
+			stats.CPU.Nice += core.Nice / n
+			stats.CPU.Idle += core.Idle / n
+			stats.CPU.IO += core.IO / n
+			stats.CPU.IRQ += core.IRQ / n
+			stats.CPU.SIRQ += core.SIRQ / n
+		}
+	}
+}
+
+// parseGNUCPUFields parses the comma separated "value label" pairs of a GNU top CPU line
+func parseGNUCPUFields(cpuFields string, cpu *CPU) {
+	for _, part := range strings.Split(cpuFields, ",") {
+		fields := strings.Fields(strings.TrimSpace(part))
+		if len(fields) == 2 {
+			val := parseFloat(fields[0])
+			switch fields[1] {
+			case "us":
+				cpu.User = val
+			case "sy":
+				cpu.Sys = val
+			case "ni":
+				cpu.Nice = val
+			case "id":
+				cpu.Idle = val
+			case "wa":
+				cpu.IO = val
+			case "hi":
+				cpu.IRQ = val
+			case "si":
+				cpu.SIRQ = val
+			}
+		}
+	}
+}
+
+// splitLines splits top output into lines without the line terminators,
+// converting the output to a string once instead of once per line
+func splitLines(output []byte) []string {
+	if len(output) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// appendFields appends the whitespace separated fields of s to dst, so the
+// process table loop can reuse one slice for every row
+func appendFields(dst []string, s string) []string {
+	start := -1
+	for i := 0; i < len(s); i++ {
+		if isSpace(s[i]) {
+			if start >= 0 {
+				dst = append(dst, s[start:i])
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		dst = append(dst, s[start:])
+	}
+	return dst
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// joinCommand joins the command fields of a process row, avoiding the copy
+// for the common single token command
+func joinCommand(fields []string) string {
+	if len(fields) == 1 {
+		return fields[0]
+	}
+	return strings.Join(fields, " ")
 }
 
 func parseKValue(s string) int64 {
+	multiplier := int64(1024)
+	switch {
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1024 * 1024
+		s = strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "g"):
+		multiplier = 1024 * 1024 * 1024
+		s = strings.TrimSuffix(s, "g")
+	}
 	s = strings.TrimSuffix(s, "K")
 	val, _ := strconv.ParseInt(s, 10, 64)
-	return val * 1024
+	return val * multiplier
 }
 
 func parsePercent(s string) float64 {
@@ -269,6 +595,33 @@ func parsePercent(s string) float64 {
 	return val
 }
 
+// parseLoadAverage parses the three load averages following "load average"
+// anywhere in the line, whatever its casing, with or without a colon, and
+// separated by commas or spaces. ok is false unless three numbers follow.
+func parseLoadAverage(line string) (load LoadAverage, ok bool) {
+	const label = "load average"
+	line = strings.ToLower(line)
+	idx := strings.Index(line, label)
+	if idx == -1 {
+		return load, false
+	}
+	rest := strings.TrimPrefix(strings.TrimSpace(line[idx+len(label):]), ":")
+	fields := strings.Fields(strings.ReplaceAll(rest, ",", " "))
+	if len(fields) < 3 {
+		return load, false
+	}
+
+	values := make([]float64, 3)
+	for i := range values {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return load, false
+		}
+		values[i] = value
+	}
+	return LoadAverage{One: values[0], Five: values[1], Fifteen: values[2]}, true
+}
+
 func parseFloat(s string) float64 {
 	val, _ := strconv.ParseFloat(s, 64)
 	return val
@@ -0,0 +1,382 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTopOutputFixtures(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          string
+		wantFormat    Format
+		wantProcesses int
+		wantMissing   []Section
+	}{
+		{
+			name:          "busybox with 5000 processes",
+			file:          "top_5000.txt",
+			wantFormat:    FormatBusyBox,
+			wantProcesses: 5000,
+			wantMissing:   []Section{SectionSwap},
+		},
+		{
+			name:          "gnu",
+			file:          "top_gnu.txt",
+			wantFormat:    FormatGNU,
+			wantProcesses: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats, err := ParseTopOutput(output)
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+			if stats.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", stats.Format, tt.wantFormat)
+			}
+			if got := len(stats.Processes); got != tt.wantProcesses {
+				t.Errorf("len(Processes) = %d, want %d", got, tt.wantProcesses)
+			}
+			if got := stats.Missing(); !reflect.DeepEqual(got, tt.wantMissing) {
+				t.Errorf("Missing() = %v, want %v", got, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	output, err := os.ReadFile(filepath.Join("testdata", "top_5000.txt"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseTopOutput(output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseBusyBoxRSS(t *testing.T) {
+	const summary = "Mem: 600000K used, 400000K free, 0K shrd, 0K buff, 0K cached\n" +
+		"CPU:  10% usr   5% sys   0% nic  85% idle   0% io   0% irq   0% sirq\n" +
+		"Load average: 0.10 0.20 0.30 1/10 100\n"
+
+	tests := []struct {
+		name           string
+		table          string
+		wantRSS        int64
+		wantMemPercent float64
+		wantMemory     float64
+	}{
+		{
+			name: "memory-detail option",
+			table: "  PID  PPID USER     STAT   VSZ %VSZ   RSS CPU %CPU COMMAND\n" +
+				"  100     1 app      S     800m 80.0 100000   0  2.0 java -jar app.jar\n",
+			wantRSS:        100000 * 1024,
+			wantMemPercent: 10,
+			wantMemory:     10,
+		},
+		{
+			name: "no RSS column",
+			table: "  PID  PPID USER     STAT   VSZ %VSZ CPU %CPU COMMAND\n" +
+				"  100     1 app      S     800m 80.0   0  2.0 java -jar app.jar\n",
+			wantMemory: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := ParseTopOutput([]byte(summary + tt.table))
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+			if len(stats.Processes) != 1 {
+				t.Fatalf("len(Processes) = %d, want 1", len(stats.Processes))
+			}
+			proc := stats.Processes[0]
+			if proc.RSS != tt.wantRSS {
+				t.Errorf("RSS = %d, want %d", proc.RSS, tt.wantRSS)
+			}
+			if proc.MemPercent != tt.wantMemPercent {
+				t.Errorf("MemPercent = %v, want %v", proc.MemPercent, tt.wantMemPercent)
+			}
+			if got := proc.MemoryPercent(); got != tt.wantMemory {
+				t.Errorf("MemoryPercent() = %v, want %v", got, tt.wantMemory)
+			}
+			if proc.Command != "java -jar app.jar" {
+				t.Errorf("Command = %q, want %q", proc.Command, "java -jar app.jar")
+			}
+		})
+	}
+}
+
+func TestUninterruptibleCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		procs []Process
+		want  []string
+	}{
+		{
+			name: "D state processes",
+			procs: []Process{
+				{PID: 1, State: "S", Command: "init"},
+				{PID: 200, State: "D", Command: "rsync -a /data /backup"},
+				{PID: 300, State: "D<", Command: "jbd2/sda1-8"},
+				{PID: 400, State: "R", Command: "top"},
+			},
+			want: []string{"jbd2/sda1-8", "rsync -a /data /backup"},
+		},
+		{
+			name: "same command twice",
+			procs: []Process{
+				{PID: 200, State: "D", Command: "kworker/u8:2"},
+				{PID: 201, State: "D", Command: "kworker/u8:2"},
+			},
+			want: []string{"kworker/u8:2"},
+		},
+		{name: "none in D state", procs: []Process{{PID: 1, State: "S", Command: "init"}}, want: []string{}},
+		{name: "no processes", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UninterruptibleCommands(tt.procs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UninterruptibleCommands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCPU(t *testing.T) {
+	tests := []struct {
+		name        string
+		cpu         CPU
+		cores       int
+		wantTotal   float64
+		wantScale   int
+		wantClamped bool
+	}{
+		{name: "350% on 4 cores", cpu: CPU{User: 300, Sys: 50, Idle: 50}, cores: 4, wantTotal: 87.5, wantScale: 4},
+		{name: "within 100%", cpu: CPU{User: 60, Sys: 20, Idle: 20}, cores: 4, wantTotal: 80},
+		{name: "beyond the cores", cpu: CPU{User: 400, Sys: 100}, cores: 4, wantTotal: 100, wantClamped: true},
+		{name: "single core", cpu: CPU{User: 150, Sys: 50}, cores: 1, wantTotal: 100, wantClamped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &SystemStats{CPU: tt.cpu}
+			normalizeCPU(stats, tt.cores)
+
+			if got := stats.CPU.User + stats.CPU.Sys; math.Abs(got-tt.wantTotal) > 0.01 {
+				t.Errorf("User+Sys = %v, want %v", got, tt.wantTotal)
+			}
+			if stats.CPUScale != tt.wantScale {
+				t.Errorf("CPUScale = %d, want %d", stats.CPUScale, tt.wantScale)
+			}
+			if stats.CPUClamped != tt.wantClamped {
+				t.Errorf("CPUClamped = %v, want %v", stats.CPUClamped, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestParseBusyBoxCPUFields(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want CPU
+	}{
+		{
+			name: "every field",
+			line: "CPU:  10% usr   5% sys   1% nic  80% idle   3% io   0% irq   1% sirq",
+			want: CPU{User: 10, Sys: 5, Nice: 1, Idle: 80, IO: 3, SIRQ: 1},
+		},
+		{
+			name: "no IO field",
+			line: "CPU:  10% usr   5% sys   1% nic  83% idle   0% irq   1% sirq",
+			want: CPU{User: 10, Sys: 5, Nice: 1, Idle: 83, SIRQ: 1},
+		},
+		{
+			name: "iow label",
+			line: "CPU:  10% usr   5% sys   0% nic  73% idle  12% iow   0% irq   0% sirq",
+			want: CPU{User: 10, Sys: 5, Idle: 73, IO: 12},
+		},
+		{
+			name: "fields reordered",
+			line: "CPU:  80% idle  10% usr   2% io   8% sys",
+			want: CPU{User: 10, Sys: 8, Idle: 80, IO: 2},
+		},
+		{
+			name: "decimal percentages",
+			line: "CPU:  12.5% usr   4.5% sys   0.0% nic  82.0% idle   1.0% io   0.0% irq   0.0% sirq",
+			want: CPU{User: 12.5, Sys: 4.5, Idle: 82, IO: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got CPU
+			parseBusyBoxCPUFields(strings.Fields(tt.line), &got)
+			if got != tt.want {
+				t.Errorf("parseBusyBoxCPUFields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTasksLine(t *testing.T) {
+	const header = `top - 07:32:26 up 4 days, 23:37,  1 user,  load average: 0.10, 0.24, 0.20
+%s
+%%Cpu(s):  2.0 us,  1.0 sy,  0.0 ni, 96.5 id,  0.5 wa,  0.0 hi,  0.0 si,  0.0 st
+MiB Mem :   2017.4 total,    348.5 free,    447.2 used,   1284.3 buff/cache
+MiB Swap:   2048.0 total,   2048.0 free,      0.0 used.   1412.3 avail Mem
+
+  PID USER      PR  NI    VIRT    RES    SHR S  %%CPU  %%MEM     TIME+ COMMAND
+      1 root      20   0  167744  11832   8456 S   0.0   0.6   0:04.12 systemd
+    842 postgres  20   0  215340  26384  24012 S   0.3   1.3   1:02.54 postgres
+   1207 nginx     20   0   55424   5800   4012 R   1.0   0.3   0:12.31 nginx
+`
+
+	tests := []struct {
+		name             string
+		tasks            string
+		wantTasks        Tasks
+		wantCount        int
+		wantTruncated    bool
+		wantProcessCount int
+	}{
+		{
+			name:             "truncated process table",
+			tasks:            "Tasks: 123 total,   2 running, 120 sleeping,   0 stopped,   1 zombie",
+			wantTasks:        Tasks{Total: 123, Running: 2, Sleeping: 120, Stopped: 0, Zombie: 1},
+			wantCount:        123,
+			wantTruncated:    true,
+			wantProcessCount: 3,
+		},
+		{
+			name:             "complete process table",
+			tasks:            "Tasks:   3 total,   1 running,   2 sleeping,   0 stopped,   0 zombie",
+			wantTasks:        Tasks{Total: 3, Running: 1, Sleeping: 2},
+			wantCount:        3,
+			wantProcessCount: 3,
+		},
+		{name: "no Tasks line", wantCount: 3, wantProcessCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := ParseTopOutput([]byte(fmt.Sprintf(header, tt.tasks)))
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+			if stats.Tasks != tt.wantTasks {
+				t.Errorf("Tasks = %+v, want %+v", stats.Tasks, tt.wantTasks)
+			}
+			if got := stats.ProcessCount(); got != tt.wantCount {
+				t.Errorf("ProcessCount() = %d, want %d", got, tt.wantCount)
+			}
+			if stats.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", stats.Truncated, tt.wantTruncated)
+			}
+			if got := len(stats.Processes); got != tt.wantProcessCount {
+				t.Errorf("len(Processes) = %d, want %d", got, tt.wantProcessCount)
+			}
+		})
+	}
+}
+
+func TestProcessTableTruncated(t *testing.T) {
+	output := func(total, rows int) []byte {
+		var b strings.Builder
+		b.WriteString("top - 07:32:26 up 4 days, 23:37,  1 user,  load average: 0.10, 0.24, 0.20\n")
+		if total > 0 {
+			fmt.Fprintf(&b, "Tasks: %d total,   1 running, %d sleeping,   0 stopped,   0 zombie\n", total, total-1)
+		}
+		b.WriteString("%Cpu(s):  2.0 us,  1.0 sy,  0.0 ni, 96.5 id,  0.5 wa,  0.0 hi,  0.0 si,  0.0 st\n\n")
+		b.WriteString("  PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND\n")
+		for pid := 1; pid <= rows; pid++ {
+			fmt.Fprintf(&b, "%5d root      20   0    1024    512    256 S   0.0   0.1   0:00.01 worker-%d\n", pid, pid)
+		}
+		return []byte(b.String())
+	}
+
+	tests := []struct {
+		name  string
+		total int
+		rows  int
+		want  bool
+	}{
+		{name: "a screenful of many tasks", total: 123, rows: 2, want: true},
+		{name: "just under 90% of the total", total: 100, rows: 89, want: true},
+		{name: "90% of the total", total: 100, rows: 90, want: false},
+		{name: "every task printed", total: 50, rows: 50, want: false},
+		{name: "more rows than the total", total: 10, rows: 12, want: false},
+		{name: "no Tasks line", rows: 5, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := ParseTopOutput(output(tt.total, tt.rows))
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+			if got := len(stats.Processes); got != tt.rows {
+				t.Fatalf("len(Processes) = %d, want %d", got, tt.rows)
+			}
+			if stats.Truncated != tt.want {
+				t.Errorf("Truncated = %v, want %v", stats.Truncated, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBusyBoxLoadAverage(t *testing.T) {
+	const (
+		mem   = "Mem: 600000K used, 400000K free, 0K shrd, 0K buff, 0K cached\n"
+		cpu   = "CPU:  10% usr   5% sys   0% nic  85% idle   0% io   0% irq   0% sirq\n"
+		table = "  PID  PPID USER     STAT   VSZ %VSZ CPU %CPU COMMAND\n" +
+			"    1     0 root     S     1500  0.1   0  0.0 init\n"
+	)
+
+	tests := []struct {
+		name     string
+		loadLine string
+		want     LoadAverage
+		wantLoad bool
+	}{
+		{name: "usual line", loadLine: "Load average: 0.52 0.31 0.20 2/123 4567\n", want: LoadAverage{One: 0.52, Five: 0.31, Fifteen: 0.20}, wantLoad: true},
+		{name: "process summary prefix", loadLine: "Procs: 3 running, LOAD AVERAGE 0.52 0.31 0.20 2/123 4567\n", want: LoadAverage{One: 0.52, Five: 0.31, Fifteen: 0.20}, wantLoad: true},
+		{name: "lower case without colon", loadLine: "load average 1.50, 1.25, 1.00\n", want: LoadAverage{One: 1.5, Five: 1.25, Fifteen: 1}, wantLoad: true},
+		{name: "extra spacing", loadLine: "Load average :  0.52   0.31   0.20\n", want: LoadAverage{One: 0.52, Five: 0.31, Fifteen: 0.20}, wantLoad: true},
+		{name: "cut short", loadLine: "Load average: 0.52 0.31\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := ParseTopOutput([]byte(mem + cpu + tt.loadLine + table))
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+			if stats.LoadAverage != tt.want {
+				t.Errorf("LoadAverage = %+v, want %+v", stats.LoadAverage, tt.want)
+			}
+			if got := stats.Has(SectionLoad); got != tt.wantLoad {
+				t.Errorf("Has(SectionLoad) = %v, want %v", got, tt.wantLoad)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+// ProcessThresholds are the usage percentages above which a process counts as
+// a high CPU or high memory process
+type ProcessThresholds struct {
+	CPUPercent    float64
+	MemoryPercent float64
+	ExcludePID    int // Never counted as a high CPU or high memory process, e.g. the analyzer itself, 0 excludes none
+}
+
+// DefaultProcessThresholds are the thresholds used unless configured otherwise
+var DefaultProcessThresholds = ProcessThresholds{
+	CPUPercent:    10,
+	MemoryPercent: 5,
+}
+
+// HighCPU reports whether proc uses more CPU than the threshold
+func (t ProcessThresholds) HighCPU(proc Process) bool {
+	return !t.Excluded(proc) && proc.CPUPercent > t.CPUPercent
+}
+
+// HighMemory reports whether proc uses more memory than the threshold
+func (t ProcessThresholds) HighMemory(proc Process) bool {
+	return !t.Excluded(proc) && proc.MemoryPercent() > t.MemoryPercent
+}
+
+// Excluded reports whether proc is the excluded process
+func (t ProcessThresholds) Excluded(proc Process) bool {
+	return t.ExcludePID != 0 && proc.PID == t.ExcludePID
+}
+
+// MemoryThresholds decide when free memory is too low. A percentage alone
+// doesn't fit every box: 5% free is plenty on 32GB but tight on 512MB.
+type MemoryThresholds struct {
+	MinFreePercent float64 // Free memory percentage below which memory is low, 0 disables
+	MinFreeBytes   int64   // Free memory in bytes below which memory is low, 0 disables
+}
+
+// DefaultMemoryThresholds are the thresholds used unless configured otherwise
+var DefaultMemoryThresholds = MemoryThresholds{
+	MinFreePercent: 5,
+}
+
+// Low reports whether the free memory of mem breaches either threshold
+func (t MemoryThresholds) Low(mem Memory) bool {
+	if mem.Total <= 0 {
+		return false
+	}
+	free := mem.Total - mem.Used
+	return float64(free)/float64(mem.Total)*100 < t.MinFreePercent || free < t.MinFreeBytes
+}
@@ -0,0 +1,34 @@
+package parser
+
+import "testing"
+
+func TestMemoryThresholdsLow(t *testing.T) {
+	const (
+		mb = int64(1) << 20
+		gb = int64(1) << 30
+	)
+
+	tests := []struct {
+		name       string
+		thresholds MemoryThresholds
+		mem        Memory
+		want       bool
+	}{
+		// 1.7GB (5.3%) free on a 32GB box
+		{name: "large box under the byte threshold", thresholds: MemoryThresholds{MinFreePercent: 5, MinFreeBytes: 2 * gb}, mem: Memory{Total: 32 * gb, Used: 32*gb - 1700*mb}, want: true},
+		{name: "large box percent threshold only", thresholds: MemoryThresholds{MinFreePercent: 5}, mem: Memory{Total: 32 * gb, Used: 32*gb - 1700*mb}},
+		// 100MB (19.5%) free on a 512MB board
+		{name: "small board under the percent threshold", thresholds: MemoryThresholds{MinFreePercent: 20, MinFreeBytes: 64 * mb}, mem: Memory{Total: 512 * mb, Used: 412 * mb}, want: true},
+		{name: "small board over both thresholds", thresholds: MemoryThresholds{MinFreePercent: 10, MinFreeBytes: 64 * mb}, mem: Memory{Total: 512 * mb, Used: 412 * mb}},
+		{name: "both thresholds disabled", mem: Memory{Total: 512 * mb, Used: 511 * mb}},
+		{name: "no memory total", thresholds: MemoryThresholds{MinFreePercent: 5, MinFreeBytes: 2 * gb}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.thresholds.Low(tt.mem); got != tt.want {
+				t.Errorf("Low(%+v) = %v, want %v", tt.mem, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package parser
+
+import "strings"
+
+// WatchStatus is the state of a watched process in a sample
+type WatchStatus struct {
+	Pattern       string  `json:"pattern"`        // Command substring being watched
+	Present       bool    `json:"present"`        // At least one process matches
+	Processes     int     `json:"processes"`      // Number of matching processes
+	CPUPercent    float64 `json:"cpu_percent"`    // Summed over the matching processes
+	MemoryPercent float64 `json:"memory_percent"` // Summed over the matching processes
+}
+
+// ParseWatchlist splits a comma separated list of command substrings
+func ParseWatchlist(s string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(s, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Watch returns the status of every watched pattern among procs, in the
+// order of patterns
+func Watch(procs []Process, patterns []string) []WatchStatus {
+	statuses := make([]WatchStatus, len(patterns))
+	for i, pattern := range patterns {
+		statuses[i].Pattern = pattern
+		for _, proc := range procs {
+			if !strings.Contains(proc.Command, pattern) {
+				continue
+			}
+			statuses[i].Present = true
+			statuses[i].Processes++
+			statuses[i].CPUPercent += proc.CPUPercent
+			statuses[i].MemoryPercent += proc.MemoryPercent()
+		}
+	}
+	return statuses
+}
+
+// Unexpected returns the high CPU processes whose command contains none of
+// the expected substrings, in their order in procs. On an appliance running
+// a known set of services such a process may be a rogue job or a compromise.
+func Unexpected(procs []Process, expected []string, thresholds ProcessThresholds) []Process {
+	var unexpected []Process
+	for _, proc := range procs {
+		if !thresholds.HighCPU(proc) || matchesAny(proc.Command, expected) {
+			continue
+		}
+		unexpected = append(unexpected, proc)
+	}
+	return unexpected
+}
+
+// matchesAny reports whether command contains any of the patterns
+func matchesAny(command string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(command, pattern) {
+			return true
+		}
+	}
+	return false
+}
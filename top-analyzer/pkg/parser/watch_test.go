@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	procs := []Process{
+		{PID: 1, Command: "init", CPUPercent: 0.1, VSZPercent: 0.2},
+		{PID: 300, Command: "postgres: checkpointer", CPUPercent: 2, VSZPercent: 5},
+		{PID: 301, Command: "postgres: walwriter", CPUPercent: 1, VSZPercent: 4},
+	}
+
+	tests := []struct {
+		name      string
+		watchlist string
+		want      []WatchStatus
+	}{
+		{
+			name:      "present and missing",
+			watchlist: "postgres, my-app",
+			want: []WatchStatus{
+				{Pattern: "postgres", Present: true, Processes: 2, CPUPercent: 3, MemoryPercent: 9},
+				{Pattern: "my-app"},
+			},
+		},
+		{name: "empty entries skipped", watchlist: ",init,,", want: []WatchStatus{{Pattern: "init", Present: true, Processes: 1, CPUPercent: 0.1, MemoryPercent: 0.2}}},
+		{name: "no watchlist", want: []WatchStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Watch(procs, ParseWatchlist(tt.watchlist)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnexpected(t *testing.T) {
+	procs := []Process{
+		{PID: 1, Command: "init", CPUPercent: 0.1},
+		{PID: 100, Command: "nginx: worker process", CPUPercent: 40},
+		{PID: 200, Command: "sshd: admin", CPUPercent: 0.5},
+		{PID: 300, Command: "bash", CPUPercent: 0.2},
+		{PID: 400, Command: "./xmrig --donate-level 1", CPUPercent: 80},
+	}
+
+	tests := []struct {
+		name       string
+		expected   string
+		thresholds ProcessThresholds
+		want       []int // PIDs
+	}{
+		{name: "busy unexpected process", expected: "nginx,sshd", thresholds: DefaultProcessThresholds, want: []int{400}},
+		{name: "every busy process expected", expected: "nginx,sshd,xmrig", thresholds: DefaultProcessThresholds},
+		{name: "excluded process", expected: "nginx,sshd", thresholds: ProcessThresholds{CPUPercent: 10, ExcludePID: 400}},
+		{name: "lower threshold", expected: "sshd,xmrig", thresholds: ProcessThresholds{CPUPercent: 0.15}, want: []int{100, 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, proc := range Unexpected(procs, ParseWatchlist(tt.expected), tt.thresholds) {
+				got = append(got, proc.PID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected() = PIDs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
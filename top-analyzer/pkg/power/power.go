@@ -1,13 +1,216 @@
 package power
 
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sysfsRoot is where the kernel exposes the power supplies
+const sysfsRoot = "/sys/class/power_supply"
+
+// Supply types reported by the kernel
+const (
+	TypeBattery = "Battery"
+	TypeMains   = "Mains"
+	TypeUSB     = "USB"
+)
+
+// StatusDischarging is the status of a battery powering the machine
+const StatusDischarging = "Discharging"
+
+// Thresholds decide when a battery is reported as low or about to run out
+type Thresholds struct {
+	LowCapacity   int           // Capacity percentage below which a discharging battery is low, 0 disables
+	EmptyWarning  time.Duration // Warn when a battery is projected to run out within this duration, 0 disables
+	EmptyCritical time.Duration // Critical when a battery is projected to run out within this duration, 0 disables
+}
+
+// DefaultThresholds are the thresholds used unless configured otherwise
+var DefaultThresholds = Thresholds{
+	LowCapacity:   10,
+	EmptyWarning:  30 * time.Minute,
+	EmptyCritical: 10 * time.Minute,
+}
+
 type PowerStats struct {
-	// Empty struct since we can't reliably detect power variations
+	Supplies map[string]Supply // supply name (e.g. BAT0, AC) -> state
+}
+
+// Supply is the state of a single power supply
+type Supply struct {
+	Type     string  `json:"type"`             // Battery, Mains, USB, ...
+	Status   string  `json:"status,omitempty"` // Charging, Discharging, Full, ... for batteries
+	Online   bool    `json:"online"`           // Plugged in for mains and USB supplies, present for batteries
+	Capacity int     `json:"capacity"`         // Battery charge percentage, -1 when not reported
+	Power    float64 `json:"power_watts"`      // Power draw in watts as reported, negative on some drivers while discharging, 0 when not reported
+	Energy   float64 `json:"energy_wh"`        // Remaining energy in watt-hours, 0 when not reported
+}
+
+// Discharging reports whether the supply is a battery powering the machine
+func (s Supply) Discharging() bool {
+	return s.Type == TypeBattery && s.Status == StatusDischarging
+}
+
+// Low reports whether the supply is a discharging battery below the low
+// capacity threshold
+func (s Supply) Low(t Thresholds) bool {
+	return s.Discharging() && s.Capacity >= 0 && s.Capacity < t.LowCapacity
+}
+
+// Draw returns the power draw in watts. Some drivers report the draw of a
+// discharging battery as negative, only its magnitude counts then.
+func (s Supply) Draw() float64 {
+	if s.Discharging() {
+		return math.Abs(s.Power)
+	}
+	return s.Power
+}
+
+// TimeToEmpty projects how long a discharging battery lasts at its current
+// power draw, 0 when it isn't discharging or the draw is unknown
+func (s Supply) TimeToEmpty() time.Duration {
+	if !s.Discharging() || s.Draw() <= 0 || s.Energy <= 0 {
+		return 0
+	}
+	return time.Duration(s.Energy / s.Draw() * float64(time.Hour))
 }
 
+// ReadPowerStats reads the power supplies exposed in sysfs. A machine
+// without any, such as most servers, yields empty stats.
 func ReadPowerStats() (*PowerStats, error) {
-	return &PowerStats{}, nil
+	return ReadPowerStatsFrom(sysfsRoot)
+}
+
+// ReadPowerStatsFrom reads the power supplies under root, laid out like
+// /sys/class/power_supply
+func ReadPowerStatsFrom(root string) (*PowerStats, error) {
+	stats := &PowerStats{Supplies: make(map[string]Supply)}
+
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list power supplies: %w", err)
+	}
+
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		supplyType, ok := readString(dir, "type")
+		if !ok {
+			continue
+		}
+
+		supply := Supply{Type: supplyType, Capacity: -1}
+		supply.Status, _ = readString(dir, "status")
+		if online, ok := readInt(dir, "online"); ok {
+			supply.Online = online == 1
+		} else if present, ok := readInt(dir, "present"); ok {
+			supply.Online = present == 1
+		}
+		if capacity, ok := readInt(dir, "capacity"); ok {
+			supply.Capacity = int(capacity)
+		}
+
+		// power_now is in µW; without it, current_now (µA) times voltage_now (µV)
+		if power, ok := readInt(dir, "power_now"); ok {
+			supply.Power = float64(power) / 1e6
+		} else if current, ok := readInt(dir, "current_now"); ok {
+			if voltage, ok := readInt(dir, "voltage_now"); ok {
+				supply.Power = float64(current) * float64(voltage) / 1e12
+			}
+		}
+
+		// energy_now is in µWh; without it, charge_now (µAh) times voltage_now (µV)
+		if energy, ok := readInt(dir, "energy_now"); ok {
+			supply.Energy = float64(energy) / 1e6
+		} else if charge, ok := readInt(dir, "charge_now"); ok {
+			if voltage, ok := readInt(dir, "voltage_now"); ok {
+				supply.Energy = float64(charge) * float64(voltage) / 1e12
+			}
+		}
+
+		stats.Supplies[entry.Name()] = supply
+	}
+
+	return stats, nil
+}
+
+// OnAC reports whether the machine runs on external power: a mains or USB
+// supply is online, or there is no battery at all
+func (p *PowerStats) OnAC() bool {
+	hasBattery := false
+	for _, supply := range p.Supplies {
+		switch supply.Type {
+		case TypeMains, TypeUSB:
+			if supply.Online {
+				return true
+			}
+		case TypeBattery:
+			hasBattery = true
+		}
+	}
+	return !hasBattery
+}
+
+// Draw returns the total power drawn from the batteries in watts
+func (p *PowerStats) Draw() float64 {
+	draw := 0.0
+	for _, supply := range p.Supplies {
+		if supply.Type == TypeBattery {
+			draw += supply.Draw()
+		}
+	}
+	return draw
+}
+
+// readString reads a sysfs attribute of a supply
+func readString(dir, name string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// readInt reads an integer sysfs attribute of a supply
+func readInt(dir, name string) (int64, bool) {
+	value, ok := readString(dir, name)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
 }
 
 func (p *PowerStats) String() string {
-	return ""
+	names := make([]string, 0, len(p.Supplies))
+	for name := range p.Supplies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("Power Statistics:\n")
+	for _, name := range names {
+		supply := p.Supplies[name]
+		switch supply.Type {
+		case TypeBattery:
+			sb.WriteString(fmt.Sprintf("%s: %d%% %s, %.1f W\n", name, supply.Capacity, supply.Status, supply.Power))
+		default:
+			sb.WriteString(fmt.Sprintf("%s (%s): online %t\n", name, supply.Type, supply.Online))
+		}
+	}
+	return sb.String()
 }
@@ -0,0 +1,107 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree writes files, keyed by path relative to root, under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadPowerStatsFrom(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		missingRoot  bool
+		wantSupplies map[string]Supply
+		wantOnAC     bool
+		wantDraw     float64
+	}{
+		{
+			name: "laptop on battery",
+			files: map[string]string{
+				"BAT0/type": "Battery\n", "BAT0/status": "Discharging\n", "BAT0/present": "1\n",
+				"BAT0/capacity": "76\n", "BAT0/power_now": "12500000\n",
+				"BAT1/type": "Battery\n", "BAT1/status": "Discharging\n", "BAT1/present": "1\n",
+				"BAT1/current_now": "1000000\n", "BAT1/voltage_now": "12000000\n",
+				"AC/type": "Mains\n", "AC/online": "0\n",
+			},
+			wantSupplies: map[string]Supply{
+				"BAT0": {Type: TypeBattery, Status: StatusDischarging, Online: true, Capacity: 76, Power: 12.5},
+				"BAT1": {Type: TypeBattery, Status: StatusDischarging, Online: true, Capacity: -1, Power: 12},
+				"AC":   {Type: TypeMains, Online: false, Capacity: -1},
+			},
+			wantOnAC: false,
+			wantDraw: 24.5,
+		},
+		{
+			name: "negative draw while discharging",
+			files: map[string]string{
+				"BAT0/type": "Battery\n", "BAT0/status": "Discharging\n", "BAT0/present": "1\n",
+				"BAT0/capacity": "50\n", "BAT0/power_now": "-8000000\n",
+			},
+			wantSupplies: map[string]Supply{
+				"BAT0": {Type: TypeBattery, Status: StatusDischarging, Online: true, Capacity: 50, Power: -8},
+			},
+			wantOnAC: false,
+			wantDraw: 8,
+		},
+		{
+			name: "laptop plugged in",
+			files: map[string]string{
+				"BAT0/type": "Battery\n", "BAT0/status": "Charging\n", "BAT0/present": "1\n", "BAT0/capacity": "40\n",
+				"AC/type": "Mains\n", "AC/online": "1\n",
+			},
+			wantSupplies: map[string]Supply{
+				"BAT0": {Type: TypeBattery, Status: "Charging", Online: true, Capacity: 40},
+				"AC":   {Type: TypeMains, Online: true, Capacity: -1},
+			},
+			wantOnAC: true,
+		},
+		{
+			name:         "entry without a type",
+			files:        map[string]string{"hidpp_battery_0/capacity": "80\n"},
+			wantSupplies: map[string]Supply{},
+			wantOnAC:     true,
+		},
+		{name: "no power supplies", wantSupplies: map[string]Supply{}, wantOnAC: true},
+		{name: "no power_supply directory", missingRoot: true, wantSupplies: map[string]Supply{}, wantOnAC: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+			if tt.missingRoot {
+				root = filepath.Join(root, "power_supply")
+			}
+
+			stats, err := ReadPowerStatsFrom(root)
+			if err != nil {
+				t.Fatalf("ReadPowerStatsFrom() error = %v", err)
+			}
+			if !reflect.DeepEqual(stats.Supplies, tt.wantSupplies) {
+				t.Errorf("Supplies = %+v, want %+v", stats.Supplies, tt.wantSupplies)
+			}
+			if got := stats.OnAC(); got != tt.wantOnAC {
+				t.Errorf("OnAC() = %v, want %v", got, tt.wantOnAC)
+			}
+			if got := stats.Draw(); got != tt.wantDraw {
+				t.Errorf("Draw() = %v, want %v", got, tt.wantDraw)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/otlp"
+)
+
+// ContentType is the content type of the text exposition format Write emits
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// unitSuffixes maps the OTLP units of the gauges to the suffix Prometheus
+// conventions append to the metric name
+var unitSuffixes = map[string]string{
+	"By":  "bytes",
+	"%":   "percent",
+	"Cel": "celsius",
+}
+
+// MetricName converts the dotted OTLP name of a gauge into a Prometheus
+// metric name, suffixed with its unit, e.g. top_analyzer.memory.used with
+// unit By becomes top_analyzer_memory_used_bytes
+func MetricName(name, unit string) string {
+	name = sanitize(name, false)
+	if suffix, ok := unitSuffixes[unit]; ok && !strings.HasSuffix(name, "_"+suffix) {
+		name += "_" + suffix
+	}
+	return name
+}
+
+// LabelValue turns a value into a stable label value made of letters,
+// digits, '_', '-' and ':' only. Sensor names such as f10e4078.thermal or
+// coretemp-isa-0000/Core 0 are valid label values but awkward to match in
+// queries; every run of other characters becomes a single '_'.
+func LabelValue(value string) string {
+	return sanitize(value, true)
+}
+
+// sanitize replaces every run of characters not allowed in a metric name, or
+// with dashes in a label value, by a single '_'
+func sanitize(s string, dashes bool) string {
+	var b strings.Builder
+	replaced := false
+	for _, r := range s {
+		allowed := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '_' || r == ':' || (dashes && r == '-')
+		if !allowed {
+			if !replaced && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			replaced = true
+			continue
+		}
+		b.WriteRune(r)
+		replaced = false
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// labelEscaper escapes label values as the text exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Write writes the gauges in the Prometheus text exposition format, grouped
+// by metric in the order they first appear, with sorted labels. Label values
+// are escaped, not sanitized: pass them through LabelValue first where a
+// stable identifier is wanted.
+func Write(w io.Writer, gauges []otlp.Gauge) error {
+	var names []string
+	series := make(map[string][]otlp.Gauge)
+	for _, g := range gauges {
+		name := MetricName(g.Name, g.Unit)
+		if _, exists := series[name]; !exists {
+			names = append(names, name)
+		}
+		series[name] = append(series[name], g)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+		for _, g := range series[name] {
+			fmt.Fprintf(bw, "%s%s %s\n", name, labels(g.Attributes), strconv.FormatFloat(g.Value, 'g', -1, 64))
+		}
+	}
+	return bw.Flush()
+}
+
+// labels formats attributes as a label set sorted by name
+func labels(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, sanitize(key, false), labelEscaper.Replace(attrs[key])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,98 @@
+package prometheus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/otlp"
+)
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "f10e4078.thermal", want: "f10e4078_thermal"},
+		{value: "coretemp-isa-0000/Core 0", want: "coretemp-isa-0000_Core_0"},
+		{value: "nvme-pci-0100/Composite", want: "nvme-pci-0100_Composite"},
+		{value: "acpitz..zone//1", want: "acpitz_zone_1"},
+		{value: "cpu_thermal", want: "cpu_thermal"},
+		{value: "temp (°C)", want: "temp_C"},
+		{value: ".thermal", want: "thermal"},
+		{value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := LabelValue(tt.value); got != tt.want {
+				t.Errorf("LabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	tests := []struct {
+		name string
+		unit string
+		want string
+	}{
+		{name: "top_analyzer.memory.used", unit: "By", want: "top_analyzer_memory_used_bytes"},
+		{name: "top_analyzer.memory.usage", unit: "%", want: "top_analyzer_memory_usage_percent"},
+		{name: "top_analyzer.temperature", unit: "Cel", want: "top_analyzer_temperature_celsius"},
+		{name: "top_analyzer.collector.up", want: "top_analyzer_collector_up"},
+		{name: "top_analyzer.disk_bytes", unit: "By", want: "top_analyzer_disk_bytes"},
+		{name: "top_analyzer.uptime", unit: "s", want: "top_analyzer_uptime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetricName(tt.name, tt.unit); got != tt.want {
+				t.Errorf("MetricName(%q, %q) = %q, want %q", tt.name, tt.unit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		gauges []otlp.Gauge
+		want   string
+	}{
+		{
+			name: "temperature per sensor",
+			gauges: []otlp.Gauge{
+				{Name: "top_analyzer.temperature", Unit: "Cel", Value: 52.5, Attributes: map[string]string{"sensor": "f10e4078_thermal", "location": "CPU Core"}},
+				{Name: "top_analyzer.temperature", Unit: "Cel", Value: 38, Attributes: map[string]string{"sensor": "nvme-pci-0100_Composite"}},
+				{Name: "top_analyzer.temperature.overall", Unit: "Cel", Value: 52.5},
+			},
+			want: `# TYPE top_analyzer_temperature_celsius gauge
+top_analyzer_temperature_celsius{location="CPU Core",sensor="f10e4078_thermal"} 52.5
+top_analyzer_temperature_celsius{sensor="nvme-pci-0100_Composite"} 38
+# TYPE top_analyzer_temperature_overall_celsius gauge
+top_analyzer_temperature_overall_celsius 52.5
+`,
+		},
+		{
+			name:   "escaped label value",
+			gauges: []otlp.Gauge{{Name: "top_analyzer.filesystem.free", Unit: "%", Value: 40, Attributes: map[string]string{"mount_point": `/mnt/"quoted"\dir`}}},
+			want: `# TYPE top_analyzer_filesystem_free_percent gauge
+top_analyzer_filesystem_free_percent{mount_point="/mnt/\"quoted\"\\dir"} 40
+`,
+		},
+		{name: "no gauges", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, tt.gauges); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
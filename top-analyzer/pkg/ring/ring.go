@@ -0,0 +1,86 @@
+package ring
+
+// Buffer is a fixed capacity FIFO buffer. Once full, adding an item
+// overwrites the oldest one without reallocating the backing array.
+type Buffer[T any] struct {
+	items []T
+	start int
+	size  int
+}
+
+// New creates a ring buffer holding at most capacity items
+func New[T any](capacity int) *Buffer[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Buffer[T]{
+		items: make([]T, capacity),
+	}
+}
+
+// Add appends an item, evicting the oldest item when the buffer is full
+func (b *Buffer[T]) Add(item T) {
+	if b.size < len(b.items) {
+		b.items[(b.start+b.size)%len(b.items)] = item
+		b.size++
+		return
+	}
+	b.items[b.start] = item
+	b.start = (b.start + 1) % len(b.items)
+}
+
+// Len returns the number of items in the buffer
+func (b *Buffer[T]) Len() int {
+	return b.size
+}
+
+// Cap returns the maximum number of items the buffer holds
+func (b *Buffer[T]) Cap() int {
+	return len(b.items)
+}
+
+// At returns the i-th item in chronological order, 0 being the oldest
+func (b *Buffer[T]) At(i int) T {
+	if i < 0 || i >= b.size {
+		panic("ring: index out of range")
+	}
+	return b.items[(b.start+i)%len(b.items)]
+}
+
+// Last returns the most recently added item
+func (b *Buffer[T]) Last() (T, bool) {
+	if b.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return b.At(b.size - 1), true
+}
+
+// Slice returns a copy of the items in chronological order
+func (b *Buffer[T]) Slice() []T {
+	items := make([]T, b.size)
+	for i := range items {
+		items[i] = b.At(i)
+	}
+	return items
+}
+
+// Do calls f for each item in chronological order
+func (b *Buffer[T]) Do(f func(T)) {
+	for i := 0; i < b.size; i++ {
+		f(b.At(i))
+	}
+}
+
+// Trim drops the oldest items, keeping at most the keep most recent ones
+func (b *Buffer[T]) Trim(keep int) {
+	if keep < 0 {
+		keep = 0
+	}
+	var zero T
+	for b.size > keep {
+		b.items[b.start] = zero
+		b.start = (b.start + 1) % len(b.items)
+		b.size--
+	}
+}
@@ -0,0 +1,80 @@
+package ring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		add      []int
+		trim     int // Trim(trim) after adding, when at least 0
+		wantCap  int
+		want     []int
+	}{
+		{name: "empty", capacity: 3, trim: -1, wantCap: 3, want: []int{}},
+		{name: "partially filled", capacity: 3, add: []int{1, 2}, trim: -1, wantCap: 3, want: []int{1, 2}},
+		{name: "exactly full", capacity: 3, add: []int{1, 2, 3}, trim: -1, wantCap: 3, want: []int{1, 2, 3}},
+		{name: "oldest evicted", capacity: 3, add: []int{1, 2, 3, 4, 5}, trim: -1, wantCap: 3, want: []int{3, 4, 5}},
+		{name: "wrapped several times", capacity: 3, add: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, trim: -1, wantCap: 3, want: []int{8, 9, 10}},
+		{name: "capacity below one", capacity: 0, add: []int{1, 2}, trim: -1, wantCap: 1, want: []int{2}},
+		{name: "trimmed after wrapping", capacity: 4, add: []int{1, 2, 3, 4, 5, 6}, trim: 2, wantCap: 4, want: []int{5, 6}},
+		{name: "trimmed to nothing", capacity: 3, add: []int{1, 2}, trim: 0, wantCap: 3, want: []int{}},
+		{name: "trim above length", capacity: 3, add: []int{1, 2}, trim: 5, wantCap: 3, want: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New[int](tt.capacity)
+			for _, item := range tt.add {
+				b.Add(item)
+			}
+			if tt.trim >= 0 {
+				b.Trim(tt.trim)
+			}
+
+			if got := b.Cap(); got != tt.wantCap {
+				t.Errorf("Cap() = %d, want %d", got, tt.wantCap)
+			}
+			if got := b.Len(); got != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.want))
+			}
+			if got := b.Slice(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Slice() = %v, want %v", got, tt.want)
+			}
+			done := []int{}
+			b.Do(func(item int) { done = append(done, item) })
+			if !reflect.DeepEqual(done, tt.want) {
+				t.Errorf("Do() visited %v, want %v", done, tt.want)
+			}
+			last, ok := b.Last()
+			if ok != (len(tt.want) > 0) {
+				t.Fatalf("Last() ok = %v, want %v", ok, len(tt.want) > 0)
+			}
+			if ok && last != tt.want[len(tt.want)-1] {
+				t.Errorf("Last() = %d, want %d", last, tt.want[len(tt.want)-1])
+			}
+		})
+	}
+}
+
+// TestBufferMatchesSlice checks the buffer against the sliding window slice
+// it replaced
+func TestBufferMatchesSlice(t *testing.T) {
+	for _, window := range []int{1, 2, 5, 10} {
+		b := New[int](window)
+		var history []int
+		for i := 0; i < 50; i++ {
+			b.Add(i)
+			history = append(history, i)
+			if len(history) > window {
+				history = history[1:]
+			}
+			if got := b.Slice(); !reflect.DeepEqual(got, history) {
+				t.Fatalf("window %d after %d items: Slice() = %v, want %v", window, i+1, got, history)
+			}
+		}
+	}
+}
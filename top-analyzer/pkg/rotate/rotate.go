@@ -0,0 +1,97 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Writer is a log file that is rotated once it would grow beyond a maximum
+// size. Rotated files are renamed to filename.1, filename.2, ... with .1
+// being the most recent, and only the configured number of backups is kept.
+type Writer struct {
+	mu         sync.Mutex
+	filename   string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// Open opens filename for appending. A maxSize of zero disables rotation.
+func Open(filename string, maxSize int64, maxBackups int) (*Writer, error) {
+	w := &Writer{
+		filename:   filename,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p to the file, rotating it first when p would push the file
+// beyond the maximum size
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups, moves the current file to filename.1 and
+// reopens an empty file
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	if w.maxBackups > 0 {
+		os.Remove(backupName(w.filename, w.maxBackups))
+		for i := w.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupName(w.filename, i), backupName(w.filename, i+1))
+		}
+		if err := os.Rename(w.filename, backupName(w.filename, 1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(w.filename); err != nil {
+		return fmt.Errorf("failed to truncate log file: %w", err)
+	}
+
+	return w.open()
+}
+
+// Close closes the current file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func backupName(filename string, index int) string {
+	return fmt.Sprintf("%s.%d", filename, index)
+}
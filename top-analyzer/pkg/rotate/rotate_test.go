@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterRotates(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int64
+		maxBackups  int
+		writes      int            // 10 byte lines written
+		wantFiles   map[string]int // file -> lines it holds
+		wantMissing []string       // files that must not exist
+	}{
+		{
+			name:        "below the limit",
+			maxSize:     100,
+			maxBackups:  2,
+			writes:      5,
+			wantFiles:   map[string]int{"analyzer.log": 5},
+			wantMissing: []string{"analyzer.log.1"},
+		},
+		{
+			name:        "past the limit",
+			maxSize:     100,
+			maxBackups:  2,
+			writes:      15,
+			wantFiles:   map[string]int{"analyzer.log": 5, "analyzer.log.1": 10},
+			wantMissing: []string{"analyzer.log.2"},
+		},
+		{
+			name:        "oldest backup dropped",
+			maxSize:     100,
+			maxBackups:  2,
+			writes:      45,
+			wantFiles:   map[string]int{"analyzer.log": 5, "analyzer.log.1": 10, "analyzer.log.2": 10},
+			wantMissing: []string{"analyzer.log.3"},
+		},
+		{
+			name:        "no backups kept",
+			maxSize:     100,
+			writes:      15,
+			wantFiles:   map[string]int{"analyzer.log": 5},
+			wantMissing: []string{"analyzer.log.1"},
+		},
+		{
+			name:        "rotation disabled",
+			maxBackups:  2,
+			writes:      50,
+			wantFiles:   map[string]int{"analyzer.log": 50},
+			wantMissing: []string{"analyzer.log.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Open(filepath.Join(dir, "analyzer.log"), tt.maxSize, tt.maxBackups)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			for i := 0; i < tt.writes; i++ {
+				if _, err := w.Write([]byte("log entry\n")); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			for name, want := range tt.wantFiles {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("%s not written: %v", name, err)
+				}
+				if got := strings.Count(string(data), "log entry"); got != want {
+					t.Errorf("%s holds %d lines, want %d", name, got, want)
+				}
+				if tt.maxSize > 0 && int64(len(data)) > tt.maxSize {
+					t.Errorf("%s is %d bytes, want at most %d", name, len(data), tt.maxSize)
+				}
+			}
+			for _, name := range tt.wantMissing {
+				if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+					t.Errorf("%s exists, want it absent", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package round
+
+import "math"
+
+// To rounds value to the given number of decimals. A negative number of
+// decimals leaves the value untouched.
+func To(value float64, decimals int) float64 {
+	if decimals < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
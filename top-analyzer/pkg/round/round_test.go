@@ -0,0 +1,36 @@
+package round
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		decimals int
+		want     float64
+	}{
+		{name: "two decimals", value: 45.6666, decimals: 2, want: 45.67},
+		{name: "one decimal", value: 45.6666, decimals: 1, want: 45.7},
+		{name: "no decimals", value: 45.6666, decimals: 0, want: 46},
+		{name: "half away from zero", value: 0.125, decimals: 2, want: 0.13},
+		{name: "negative value", value: -12.345, decimals: 1, want: -12.3},
+		{name: "already rounded", value: 12.5, decimals: 3, want: 12.5},
+		{name: "negative decimals keep full precision", value: 45.6666, decimals: -1, want: 45.6666},
+		{name: "infinity", value: math.Inf(1), decimals: 2, want: math.Inf(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := To(tt.value, tt.decimals); got != tt.want {
+				t.Errorf("To(%v, %d) = %v, want %v", tt.value, tt.decimals, got, tt.want)
+			}
+		})
+	}
+
+	if got := To(math.NaN(), 2); !math.IsNaN(got) {
+		t.Errorf("To(NaN, 2) = %v, want NaN", got)
+	}
+}
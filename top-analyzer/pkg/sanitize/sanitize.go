@@ -0,0 +1,71 @@
+package sanitize
+
+import (
+	"math"
+	"reflect"
+)
+
+// Floats replaces every NaN and Inf float reachable from v with 0, so that
+// v can be marshaled by encoding/json. v must be a pointer; exported struct
+// fields, slices, arrays, maps and pointers are walked recursively.
+func Floats(v any) {
+	sanitizeValue(reflect.ValueOf(v))
+}
+
+// sanitizeValue replaces non-finite floats in v and reports whether
+// anything was replaced
+func sanitizeValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if (math.IsNaN(f) || math.IsInf(f, 0)) && v.CanSet() {
+			v.SetFloat(0)
+			return true
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return sanitizeValue(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		// Values held by an interface are not addressable, sanitize a copy
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if sanitizeValue(elem) && v.CanSet() {
+			v.Set(elem)
+			return true
+		}
+	case reflect.Struct:
+		changed := false
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && sanitizeValue(v.Field(i)) {
+				changed = true
+			}
+		}
+		return changed
+	case reflect.Slice, reflect.Array:
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			if sanitizeValue(v.Index(i)) {
+				changed = true
+			}
+		}
+		return changed
+	case reflect.Map:
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, sanitize a copy and store it back
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if sanitizeValue(elem) {
+				v.SetMapIndex(iter.Key(), elem)
+				changed = true
+			}
+		}
+		return changed
+	}
+	return false
+}
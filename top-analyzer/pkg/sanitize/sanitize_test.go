@@ -0,0 +1,58 @@
+package sanitize
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type inner struct {
+	Value float64
+}
+
+type sample struct {
+	Value    float64
+	Values   []float64
+	Array    [2]float64
+	Byname   map[string]float64
+	Nested   inner
+	Pointer  *inner
+	Any      any
+	unexport float64
+}
+
+func TestFloats(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+
+	tests := []struct {
+		name string
+		in   sample
+		want sample
+	}{
+		{name: "finite values kept", in: sample{Value: 1.5, Values: []float64{2}}, want: sample{Value: 1.5, Values: []float64{2}}},
+		{name: "NaN field", in: sample{Value: nan}, want: sample{}},
+		{name: "negative Inf field", in: sample{Value: math.Inf(-1)}, want: sample{}},
+		{name: "slice element", in: sample{Values: []float64{1, inf, 3}}, want: sample{Values: []float64{1, 0, 3}}},
+		{name: "array element", in: sample{Array: [2]float64{nan, 2}}, want: sample{Array: [2]float64{0, 2}}},
+		{name: "map value", in: sample{Byname: map[string]float64{"cpu": nan, "gpu": 40}}, want: sample{Byname: map[string]float64{"cpu": 0, "gpu": 40}}},
+		{name: "nested struct", in: sample{Nested: inner{Value: inf}}, want: sample{}},
+		{name: "pointer", in: sample{Pointer: &inner{Value: nan}}, want: sample{Pointer: &inner{}}},
+		{name: "interface", in: sample{Any: inner{Value: nan}}, want: sample{Any: inner{}}},
+		{name: "unexported field left alone", in: sample{Value: nan, unexport: 7}, want: sample{unexport: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in
+			Floats(&got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Floats() = %+v, want %+v", got, tt.want)
+			}
+			if _, err := json.Marshal(got); err != nil {
+				t.Errorf("json.Marshal() after Floats() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build sqlite
+
+package sqlite
+
+// The pure Go driver needs no cgo, so cross-compiling for ARM keeps working
+import _ "modernc.org/sqlite"
+
+// driverName is the database/sql driver registered by modernc.org/sqlite
+const driverName = "sqlite"
@@ -0,0 +1,6 @@
+//go:build !sqlite
+
+package sqlite
+
+// driverName is empty when built without the sqlite build tag
+const driverName = ""
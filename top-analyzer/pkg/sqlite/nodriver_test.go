@@ -0,0 +1,31 @@
+//go:build !sqlite
+
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "new database", path: filepath.Join(t.TempDir(), "samples.db")},
+		{name: "in memory", path: ":memory:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := Open(tt.path)
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("Open() error = %v, want %v", err, ErrUnsupported)
+			}
+			if sink != nil {
+				t.Errorf("Open() = %v, want nil without the sqlite build tag", sink)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// schema creates the samples table, one row per sample with the scalar
+// metrics as columns and the sensors and partitions as JSON objects
+const schema = `
+CREATE TABLE IF NOT EXISTS samples (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp   INTEGER NOT NULL, -- Unix seconds
+	hostname    TEXT    NOT NULL,
+	instance_id TEXT    NOT NULL,
+	cpu_user    REAL    NOT NULL,
+	cpu_sys     REAL    NOT NULL,
+	cpu_idle    REAL    NOT NULL,
+	cpu_iowait  REAL    NOT NULL,
+	mem_total   INTEGER NOT NULL,
+	mem_used    INTEGER NOT NULL,
+	swap_total  INTEGER NOT NULL,
+	swap_used   INTEGER NOT NULL,
+	load1       REAL    NOT NULL,
+	load5       REAL    NOT NULL,
+	load15      REAL    NOT NULL,
+	processes   INTEGER NOT NULL,
+	sensors     TEXT    NOT NULL, -- JSON object of sensor name to °C
+	partitions  TEXT    NOT NULL  -- JSON object of mount point to filesystem stats
+);
+CREATE INDEX IF NOT EXISTS samples_timestamp ON samples (timestamp);
+`
+
+const insertSample = `
+INSERT INTO samples (
+	timestamp, hostname, instance_id,
+	cpu_user, cpu_sys, cpu_idle, cpu_iowait,
+	mem_total, mem_used, swap_total, swap_used,
+	load1, load5, load15, processes,
+	sensors, partitions
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// ErrUnsupported is returned by Open when the binary was built without the
+// sqlite build tag
+var ErrUnsupported = errors.New("SQLite support is not compiled in, rebuild with -tags sqlite")
+
+// Sink stores samples in a local SQLite database for on-device historical
+// querying without a remote time series database
+type Sink struct {
+	db     *sql.DB
+	insert *sql.Stmt
+}
+
+// Open opens or creates the SQLite database at path and its samples table
+func Open(path string) (*Sink, error) {
+	if driverName == "" {
+		return nil, ErrUnsupported
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create samples table: %w", err)
+	}
+	insert, err := db.Prepare(insertSample)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	return &Sink{db: db, insert: insert}, nil
+}
+
+// Insert stores a sample as one row of the samples table
+func (s *Sink) Insert(ctx context.Context, stats *parser.SystemStats) error {
+	sensors, err := json.Marshal(stats.Temperature.Sensors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sensors: %w", err)
+	}
+	partitions, err := json.Marshal(stats.Filesystem)
+	if err != nil {
+		return fmt.Errorf("failed to marshal partitions: %w", err)
+	}
+
+	meta := instance.Current()
+	_, err = s.insert.ExecContext(ctx,
+		stats.Timestamp.Unix(), meta.Hostname, meta.InstanceID,
+		stats.CPU.User, stats.CPU.Sys, stats.CPU.Idle, stats.CPU.IO,
+		stats.Memory.Total, stats.Memory.Used, stats.Swap.Total, stats.Swap.Used,
+		stats.LoadAverage.One, stats.LoadAverage.Five, stats.LoadAverage.Fifteen, stats.ProcessCount(),
+		string(sensors), string(partitions))
+	if err != nil {
+		return fmt.Errorf("failed to insert sample: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *Sink) Close() error {
+	s.insert.Close()
+	return s.db.Close()
+}
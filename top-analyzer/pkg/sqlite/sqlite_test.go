@@ -0,0 +1,122 @@
+//go:build sqlite
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+// row is the part of a samples row checked by the tests
+type row struct {
+	Timestamp  int64
+	InstanceID string
+	CPUUser    float64
+	MemUsed    int64
+	Load1      float64
+	Processes  int
+	Sensors    map[string]float64
+	Partitions map[string]parser.FilesystemStats
+}
+
+func TestSinkInsert(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sample := func(offset time.Duration, cpu float64, sensors map[string]float64, partitions map[string]parser.FilesystemStats) *parser.SystemStats {
+		return &parser.SystemStats{
+			Timestamp:   start.Add(offset),
+			CPU:         parser.CPU{User: cpu},
+			Memory:      parser.Memory{Total: 1 << 30, Used: int64(cpu) << 20},
+			LoadAverage: parser.LoadAverage{One: cpu / 10},
+			Processes:   []parser.Process{{PID: 1, Command: "init"}, {PID: 2, Command: "sshd"}},
+			Temperature: temperature.TemperatureStats{Sensors: sensors},
+			Filesystem:  partitions,
+		}
+	}
+	root := map[string]parser.FilesystemStats{"/": {Device: "/dev/sda1", Size: 10 << 30, UsedPct: 50, MountPoint: "/"}}
+	id := instance.Current().InstanceID
+
+	tests := []struct {
+		name    string
+		samples []*parser.SystemStats
+		want    []row
+	}{
+		{
+			name: "two samples",
+			samples: []*parser.SystemStats{
+				sample(0, 10, map[string]float64{"cpu": 45}, root),
+				sample(10*time.Second, 20, map[string]float64{"cpu": 47.5}, root),
+			},
+			want: []row{
+				{Timestamp: start.Unix(), InstanceID: id, CPUUser: 10, MemUsed: 10 << 20, Load1: 1, Processes: 2, Sensors: map[string]float64{"cpu": 45}, Partitions: root},
+				{Timestamp: start.Unix() + 10, InstanceID: id, CPUUser: 20, MemUsed: 20 << 20, Load1: 2, Processes: 2, Sensors: map[string]float64{"cpu": 47.5}, Partitions: root},
+			},
+		},
+		{
+			name:    "no sensors or partitions",
+			samples: []*parser.SystemStats{sample(0, 5, map[string]float64{}, map[string]parser.FilesystemStats{})},
+			want: []row{
+				{Timestamp: start.Unix(), InstanceID: id, CPUUser: 5, MemUsed: 5 << 20, Load1: 0.5, Processes: 2, Sensors: map[string]float64{}, Partitions: map[string]parser.FilesystemStats{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "samples.db")
+			sink, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			for _, stats := range tt.samples {
+				if err := sink.Insert(context.Background(), stats); err != nil {
+					t.Fatalf("Insert() error = %v", err)
+				}
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			db, err := sql.Open(driverName, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			rows, err := db.Query("SELECT timestamp, instance_id, cpu_user, mem_used, load1, processes, sensors, partitions FROM samples ORDER BY id")
+			if err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+			defer rows.Close()
+
+			var got []row
+			for rows.Next() {
+				var r row
+				var sensors, partitions string
+				if err := rows.Scan(&r.Timestamp, &r.InstanceID, &r.CPUUser, &r.MemUsed, &r.Load1, &r.Processes, &sensors, &partitions); err != nil {
+					t.Fatalf("scan error = %v", err)
+				}
+				if err := json.Unmarshal([]byte(sensors), &r.Sensors); err != nil {
+					t.Fatalf("sensors %q: %v", sensors, err)
+				}
+				if err := json.Unmarshal([]byte(partitions), &r.Partitions); err != nil {
+					t.Fatalf("partitions %q: %v", partitions, err)
+				}
+				got = append(got, r)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
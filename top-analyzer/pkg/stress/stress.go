@@ -0,0 +1,55 @@
+package stress
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Max is the system stress the sum of the factors is capped at
+const Max = 100.0
+
+// Factor is a single contribution to the system stress
+type Factor struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Breakdown itemizes the factors a system stress score is made of, in the
+// order they were evaluated
+type Breakdown []Factor
+
+// Add records a contribution of value to the stress
+func (b *Breakdown) Add(name string, value float64) {
+	*b = append(*b, Factor{Name: name, Value: value})
+}
+
+// Sum returns the sum of the factors, before the cap
+func (b Breakdown) Sum() float64 {
+	sum := 0.0
+	for _, factor := range b {
+		sum += factor.Value
+	}
+	return sum
+}
+
+// Total returns the system stress: the sum of the factors capped at Max
+func (b Breakdown) Total() float64 {
+	return min(b.Sum(), Max)
+}
+
+// String formats the breakdown as e.g. "cpu +20, memory +30, partition /
+// critical +40 = 90", noting when the sum was capped
+func (b Breakdown) String() string {
+	if len(b) == 0 {
+		return "no stress factors = 0"
+	}
+	terms := make([]string, 0, len(b))
+	for _, factor := range b {
+		terms = append(terms, fmt.Sprintf("%s +%g", factor.Name, factor.Value))
+	}
+	result := fmt.Sprintf("%s = %g", strings.Join(terms, ", "), b.Sum())
+	if b.Sum() > Max {
+		result += fmt.Sprintf(", capped at %g", Max)
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package stress
+
+import "testing"
+
+func TestBreakdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		factors    Breakdown
+		wantSum    float64
+		wantTotal  float64
+		wantString string
+	}{
+		{
+			name:       "under the cap",
+			factors:    Breakdown{{Name: "cpu", Value: 20}, {Name: "memory", Value: 30}, {Name: "partition / critical", Value: 40}},
+			wantSum:    90,
+			wantTotal:  90,
+			wantString: "cpu +20, memory +30, partition / critical +40 = 90",
+		},
+		{
+			name:       "capped",
+			factors:    Breakdown{{Name: "cpu", Value: 30}, {Name: "memory", Value: 30}, {Name: "load", Value: 30}, {Name: "partition / critical", Value: 40}},
+			wantSum:    130,
+			wantTotal:  Max,
+			wantString: "cpu +30, memory +30, load +30, partition / critical +40 = 130, capped at 100",
+		},
+		{name: "no factors", wantString: "no stress factors = 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.factors.Sum(); got != tt.wantSum {
+				t.Errorf("Sum() = %v, want %v", got, tt.wantSum)
+			}
+			if got := tt.factors.Total(); got != tt.wantTotal {
+				t.Errorf("Total() = %v, want %v", got, tt.wantTotal)
+			}
+			if got := tt.factors.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
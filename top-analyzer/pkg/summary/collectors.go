@@ -0,0 +1,13 @@
+package summary
+
+// CollectorStatus is the state of a collection subsystem, such as the
+// temperature sensors, in the latest collection attempt
+type CollectorStatus struct {
+	Up    bool   `json:"up"`
+	Error string `json:"error,omitempty"` // Why the subsystem failed, when it is down
+}
+
+// SetCollectors records the state of every collection subsystem
+func (s *SystemSummary) SetCollectors(collectors map[string]CollectorStatus) {
+	s.Collectors = collectors
+}
@@ -0,0 +1,42 @@
+package summary
+
+// Health bands of the health score
+const (
+	HealthHealthy  = "Healthy"
+	HealthDegraded = "Degraded"
+	HealthCritical = "Critical"
+)
+
+// anomalyPenalty is the number of health points lost per active anomaly
+const anomalyPenalty = 10
+
+// SetAnomalies records the number of anomalies active in the latest trend
+func (s *SystemSummary) SetAnomalies(count int) {
+	s.Anomalies = count
+}
+
+// HealthScore condenses system stress and active anomalies into a single
+// gauge from 0 to 100, where 100 is perfectly healthy
+func (s *SystemSummary) HealthScore() int {
+	score := 100 - s.SystemStress - float64(s.Anomalies*anomalyPenalty)
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return int(score)
+}
+
+// HealthBand returns the textual band of the health score
+func (s *SystemSummary) HealthBand() string {
+	score := s.HealthScore()
+	switch {
+	case score >= 80:
+		return HealthHealthy
+	case score >= 50:
+		return HealthDegraded
+	default:
+		return HealthCritical
+	}
+}
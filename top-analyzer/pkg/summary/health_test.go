@@ -0,0 +1,57 @@
+package summary
+
+import "testing"
+
+func TestHealthScoreMonotonic(t *testing.T) {
+	stresses := []float64{0, 10, 49.5, 50, 85, 100, 120}
+	anomalies := []int{0, 1, 2, 5, 11}
+
+	score := func(stress float64, anomalyCount int) int {
+		s := &SystemSummary{SystemStress: stress, Anomalies: anomalyCount}
+		return s.HealthScore()
+	}
+	for i, stress := range stresses {
+		for j, count := range anomalies {
+			got := score(stress, count)
+			if got < 0 || got > 100 {
+				t.Errorf("HealthScore(stress %v, %d anomalies) = %d, want within 0-100", stress, count, got)
+			}
+			if i > 0 && got > score(stresses[i-1], count) {
+				t.Errorf("HealthScore rose from stress %v to %v with %d anomalies", stresses[i-1], stress, count)
+			}
+			if j > 0 && got > score(stress, anomalies[j-1]) {
+				t.Errorf("HealthScore rose from %d to %d anomalies at stress %v", anomalies[j-1], count, stress)
+			}
+		}
+	}
+}
+
+func TestHealthBand(t *testing.T) {
+	tests := []struct {
+		name      string
+		stress    float64
+		anomalies int
+		wantScore int
+		wantBand  string
+	}{
+		{name: "idle", stress: 0, wantScore: 100, wantBand: HealthHealthy},
+		{name: "lower edge of healthy", stress: 20, wantScore: 80, wantBand: HealthHealthy},
+		{name: "stressed", stress: 30, wantScore: 70, wantBand: HealthDegraded},
+		{name: "anomalies", stress: 10, anomalies: 2, wantScore: 70, wantBand: HealthDegraded},
+		{name: "lower edge of degraded", stress: 40, anomalies: 1, wantScore: 50, wantBand: HealthDegraded},
+		{name: "critical", stress: 85, wantScore: 15, wantBand: HealthCritical},
+		{name: "clamped at zero", stress: 100, anomalies: 3, wantScore: 0, wantBand: HealthCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SystemSummary{SystemStress: tt.stress, Anomalies: tt.anomalies}
+			if got := s.HealthScore(); got != tt.wantScore {
+				t.Errorf("HealthScore() = %d, want %d", got, tt.wantScore)
+			}
+			if got := s.HealthBand(); got != tt.wantBand {
+				t.Errorf("HealthBand() = %q, want %q", got, tt.wantBand)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package summary
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sanitize"
+)
+
+// OutputFormat is the file format a summary output is written in
+type OutputFormat string
+
+const (
+	// OutputJSON rewrites the file with the indented summary on each save
+	OutputJSON OutputFormat = "json"
+	// OutputCSV appends a row of the headline metrics on each save, after a
+	// header row when the file is new
+	OutputCSV OutputFormat = "csv"
+	// OutputJSONL appends the summary as a single JSON line on each save
+	OutputJSONL OutputFormat = "jsonl"
+)
+
+// Output is a file the summary is written to on each save cycle
+type Output struct {
+	Path   string
+	Format OutputFormat
+}
+
+// ParseOutputs parses comma separated summary outputs, each a path with an
+// optional format, e.g. "latest.json,share/summary.csv,ship/summary.log=jsonl".
+// A path without a format gets the one of its extension. Relative paths are
+// resolved against dir.
+func ParseOutputs(s, dir string) ([]Output, error) {
+	var outputs []Output
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		path, format, hasFormat := strings.Cut(entry, "=")
+		path = strings.TrimSpace(path)
+		if !hasFormat {
+			format = strings.TrimPrefix(filepath.Ext(path), ".")
+		}
+		output := Output{Path: path, Format: OutputFormat(strings.ToLower(strings.TrimSpace(format)))}
+		switch output.Format {
+		case OutputJSON, OutputCSV, OutputJSONL:
+		default:
+			return nil, fmt.Errorf("invalid summary output %q: format must be json, csv or jsonl", entry)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid summary output %q: missing path", entry)
+		}
+		if !filepath.IsAbs(output.Path) {
+			output.Path = filepath.Join(dir, output.Path)
+		}
+		outputs = append(outputs, output)
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no summary output configured")
+	}
+	return outputs, nil
+}
+
+// Write writes the summary to the output in its format
+func (s *SystemSummary) Write(output Output) error {
+	switch output.Format {
+	case OutputCSV:
+		return s.appendCSV(output.Path)
+	case OutputJSONL:
+		return s.appendJSONL(output.Path)
+	default:
+		return s.Save(output.Path)
+	}
+}
+
+// csvHeader names the columns of csvRecord
+var csvHeader = []string{
+	"timestamp", "cpu_user", "cpu_system", "cpu_idle", "load1", "load5", "load15",
+	"memory_total", "memory_used", "memory_used_percent", "temperature_max", "temperature_overall",
+	"processes_total", "processes_running", "processes_uninterruptible", "processes_zombie",
+	"system_stress", "anomalies", "health_score", "health_band",
+}
+
+// csvRecord returns the headline metrics of the summary as a CSV row
+func (s *SystemSummary) csvRecord() []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		s.Timestamp.Format(time.RFC3339),
+		f(s.CPU.User), f(s.CPU.System), f(s.CPU.Idle), f(s.CPU.Load1), f(s.CPU.Load5), f(s.CPU.Load15),
+		strconv.FormatUint(s.Memory.Total, 10), strconv.FormatUint(s.Memory.Used, 10), f(s.Memory.UsedPc),
+		f(s.Temperature.MaxTemp), f(s.Temperature.Overall),
+		strconv.Itoa(s.Processes.Total), strconv.Itoa(s.Processes.Running),
+		strconv.Itoa(s.Processes.Uninterr), strconv.Itoa(s.Processes.Zombie),
+		f(s.SystemStress), strconv.Itoa(s.Anomalies), strconv.Itoa(s.HealthScore()), s.HealthBand(),
+	}
+}
+
+// appendCSV appends the headline metrics to filename as a CSV row, writing
+// the header first when the file is new or empty
+func (s *SystemSummary) appendCSV(filename string) error {
+	sanitize.Floats(s)
+
+	changed, sum, err := s.changedSince(filename)
+	if err != nil || !changed {
+		return err
+	}
+
+	file, err := openAppend(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		w.Write(csvHeader)
+	}
+	w.Write(s.csvRecord())
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	s.saved(filename, sum)
+
+	return nil
+}
+
+// appendJSONL appends the summary to filename as a single line of JSON
+func (s *SystemSummary) appendJSONL(filename string) error {
+	sanitize.Floats(s)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+
+	changed, sum, err := s.changedSince(filename)
+	if err != nil || !changed {
+		return err
+	}
+
+	file, err := openAppend(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	s.saved(filename, sum)
+
+	return nil
+}
+
+// openAppend opens filename for appending, creating it and its directory
+func openAppend(filename string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open summary: %w", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,145 @@
+package summary
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Output
+		wantErr bool
+	}{
+		{name: "default", value: "latest.json", want: []Output{{Path: "/out/latest.json", Format: OutputJSON}}},
+		{
+			name:  "format from extension and explicit",
+			value: "latest.json, share/summary.csv,ship/summary.log=jsonl,/var/log/top.jsonl",
+			want: []Output{
+				{Path: "/out/latest.json", Format: OutputJSON},
+				{Path: "/out/share/summary.csv", Format: OutputCSV},
+				{Path: "/out/ship/summary.log", Format: OutputJSONL},
+				{Path: "/var/log/top.jsonl", Format: OutputJSONL},
+			},
+		},
+		{name: "unknown extension", value: "summary.txt", wantErr: true},
+		{name: "unknown format", value: "summary.log=xml", wantErr: true},
+		{name: "missing path", value: "=json", wantErr: true},
+		{name: "empty", value: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputs(tt.value, "/out")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOutputs(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOutputs(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	// check asserts the content of an output after saves cycles
+	type check func(t *testing.T, path string, saves int)
+
+	isJSON := func(t *testing.T, path string, saves int) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s SystemSummary
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatalf("%s isn't a JSON summary: %v", path, err)
+		}
+	}
+	isCSV := func(t *testing.T, path string, saves int) {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatalf("%s isn't CSV: %v", path, err)
+		}
+		if len(records) != saves+1 {
+			t.Fatalf("%s has %d rows, want a header and %d", path, len(records), saves)
+		}
+		if !reflect.DeepEqual(records[0], csvHeader) {
+			t.Errorf("%s header = %v, want %v", path, records[0], csvHeader)
+		}
+	}
+	isJSONL := func(t *testing.T, path string, saves int) {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		lines := 0
+		for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+			var s SystemSummary
+			if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+				t.Fatalf("%s line %d isn't a JSON summary: %v", path, lines+1, err)
+			}
+		}
+		if lines != saves {
+			t.Errorf("%s has %d lines, want %d", path, lines, saves)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		outputs string
+		saves   int
+		want    map[string]check // by output path relative to the directory
+	}{
+		{
+			name:    "json and csv",
+			outputs: "latest.json,share/summary.csv",
+			saves:   2,
+			want:    map[string]check{"latest.json": isJSON, "share/summary.csv": isCSV},
+		},
+		{
+			name:    "json, csv and jsonl",
+			outputs: "latest.json,summary.csv,ship/summary.log=jsonl",
+			saves:   3,
+			want:    map[string]check{"latest.json": isJSON, "summary.csv": isCSV, "ship/summary.log": isJSONL},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputs, err := ParseOutputs(tt.outputs, dir)
+			if err != nil {
+				t.Fatalf("ParseOutputs() error = %v", err)
+			}
+
+			s := New()
+			for i := 0; i < tt.saves; i++ {
+				stats := &parser.SystemStats{CPU: parser.CPU{User: float64(10 * (i + 1))}}
+				s.Update(stats, nil, &stats.Temperature, "")
+				for _, output := range outputs {
+					if err := s.Write(output); err != nil {
+						t.Fatalf("Write(%v) error = %v", output, err)
+					}
+				}
+			}
+
+			for path, check := range tt.want {
+				check(t, filepath.Join(dir, path), tt.saves)
+			}
+		})
+	}
+}
@@ -1,21 +1,39 @@
 package summary
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/parth2601/monchecker/top-analyzer/pkg/analyzer"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/power"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/round"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sanitize"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/stress"
 	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
 )
 
+// historyLength is the number of values kept in the summary histories
+const historyLength = 10
+
+// HistoryPoint is a single history value with the time it was sampled
+type HistoryPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
 type SystemSummary struct {
-	Timestamp     time.Time `json:"timestamp"`
-	LastCrashFile string    `json:"last_crash_file,omitempty"`
-	LastCrashTime time.Time `json:"last_crash_time,omitempty"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Instance      instance.Metadata `json:"instance"`
+	LastCrashFile string            `json:"last_crash_file,omitempty"`
+	LastCrashTime time.Time         `json:"last_crash_time,omitempty"`
 	CPU           struct {
 		User   float64 `json:"user"`
 		System float64 `json:"system"`
@@ -32,14 +50,17 @@ type SystemSummary struct {
 	} `json:"memory"`
 	Temperature struct {
 		Sensors map[string]struct {
-			Value    float64 `json:"value"`
+			Value    float64 `json:"value"` // Instantaneous reading
 			Location string  `json:"location"`
-			MaxTemp  float64 `json:"max_temp"`
-			AvgTemp  float64 `json:"avg_temp"`
+			MaxTemp  float64 `json:"max_temp"` // Maximum over the retained history
+			AvgTemp  float64 `json:"avg_temp"` // Running average since the monitor started
+			Smoothed float64 `json:"smoothed"` // Moving average over the retained history
 		} `json:"sensors"`
-		MaxTemp float64              `json:"max_temp"`
-		AvgTemp float64              `json:"avg_temp"`
-		History map[string][]float64 `json:"history"`
+		MaxTemp      float64                   `json:"max_temp"`
+		AvgTemp      float64                   `json:"avg_temp"`
+		Overall      float64                   `json:"overall"` // Sensors combined as configured by SetOverallTemperature, the basis of the stress score
+		History      map[string][]float64      `json:"history"`
+		TimedHistory map[string][]HistoryPoint `json:"timed_history"` // History with sample timestamps
 	} `json:"temperature"`
 	Filesystem struct {
 		Partitions map[string]struct {
@@ -52,44 +73,90 @@ type SystemSummary struct {
 			MountPoint string  `json:"mount_point"`
 			Critical   bool    `json:"critical"`
 		} `json:"partitions"`
-		History map[string][]float64 `json:"history"` // History of free space percentage
+		History      map[string][]float64      `json:"history"`       // History of free space percentage
+		TimedHistory map[string][]HistoryPoint `json:"timed_history"` // History with sample timestamps
 	} `json:"filesystem"`
 	Processes struct {
-		Total        int `json:"total"`
-		Running      int `json:"running"`
-		Sleeping     int `json:"sleeping"`
-		Uninterr     int `json:"uninterruptible"`
-		Zombie       int `json:"zombie"`
-		HighCPU      int `json:"high_cpu"`
-		HighMem      int `json:"high_memory"`
+		Total        int      `json:"total"`
+		Running      int      `json:"running"`
+		Sleeping     int      `json:"sleeping"`
+		Uninterr     int      `json:"uninterruptible"`
+		Zombie       int      `json:"zombie"`
+		HighCPU      int      `json:"high_cpu"`
+		HighMem      int      `json:"high_memory"`
+		UninterrCmds []string `json:"uninterruptible_commands"` // Commands of the D state processes
 		HighCPUProcs []struct {
 			Name       string  `json:"name"`
 			CPUPercent float64 `json:"cpu_percent"`
 		} `json:"high_cpu_processes"`
 	} `json:"processes"`
-	SystemStress float64 `json:"system_stress"`
+	Power struct {
+		OnAC      bool                    `json:"on_ac"`              // Running on mains or USB power, or without battery
+		DrawWatts float64                 `json:"draw_watts"`         // Power drawn from the batteries
+		Supplies  map[string]power.Supply `json:"supplies,omitempty"` // State of each power supply
+	} `json:"power"`
+	SystemStress    float64                    `json:"system_stress"`
+	StressBreakdown stress.Breakdown           `json:"stress_breakdown"`     // Factors SystemStress is made of, before the cap
+	Insights        []analyzer.Insight         `json:"insights"`             // Findings of the insight analyzer for the latest sample
+	Anomalies       int                        `json:"anomalies"`            // Anomalies active in the latest trend
+	Watched         []parser.WatchStatus       `json:"watched,omitempty"`    // State of the -watch processes
+	Collectors      map[string]CollectorStatus `json:"collectors,omitempty"` // State of each collection subsystem in the latest attempt
+	Absent          []string                   `json:"absent,omitempty"`     // Sections missing from the latest top output, whose metrics are stale
+	TopFormat       string                     `json:"top_format,omitempty"` // Flavor of top the latest output was parsed as, busybox or gnu
+
+	// Running temperature totals per sensor backing AvgTemp
+	tempSum   map[string]float64
+	tempCount map[string]int
+
+	// Number of decimals stored percentages are rounded to, -1 for none
+	precision int
+
+	// Thresholds for counting high CPU and high memory processes
+	thresholds parser.ProcessThresholds
+
+	// Thresholds and weights of the partitions whose low space adds stress
+	fsThresholds filesystem.Thresholds
+
+	// Command substrings of the watched processes
+	watchlist []string
+
+	// How the sensors are combined into the overall temperature
+	overallTemp temperature.Overall
+
+	// Whether Save and Write skip writing a summary unchanged since the last
+	// write, and the fingerprint of the last write to each file
+	skipUnchanged bool
+	savedSums     map[string][sha256.Size]byte
 }
 
 func New() *SystemSummary {
 	return &SystemSummary{
+		Instance:     instance.Current(),
+		overallTemp:  temperature.OverallMax,
+		fsThresholds: filesystem.DefaultThresholds,
 		Temperature: struct {
 			Sensors map[string]struct {
 				Value    float64 `json:"value"`
 				Location string  `json:"location"`
 				MaxTemp  float64 `json:"max_temp"`
 				AvgTemp  float64 `json:"avg_temp"`
+				Smoothed float64 `json:"smoothed"`
 			} `json:"sensors"`
-			MaxTemp float64              `json:"max_temp"`
-			AvgTemp float64              `json:"avg_temp"`
-			History map[string][]float64 `json:"history"`
+			MaxTemp      float64                   `json:"max_temp"`
+			AvgTemp      float64                   `json:"avg_temp"`
+			Overall      float64                   `json:"overall"`
+			History      map[string][]float64      `json:"history"`
+			TimedHistory map[string][]HistoryPoint `json:"timed_history"`
 		}{
 			Sensors: make(map[string]struct {
 				Value    float64 `json:"value"`
 				Location string  `json:"location"`
 				MaxTemp  float64 `json:"max_temp"`
 				AvgTemp  float64 `json:"avg_temp"`
+				Smoothed float64 `json:"smoothed"`
 			}),
-			History: make(map[string][]float64),
+			History:      make(map[string][]float64),
+			TimedHistory: make(map[string][]HistoryPoint),
 		},
 		Filesystem: struct {
 			Partitions map[string]struct {
@@ -102,7 +169,8 @@ func New() *SystemSummary {
 				MountPoint string  `json:"mount_point"`
 				Critical   bool    `json:"critical"`
 			} `json:"partitions"`
-			History map[string][]float64 `json:"history"`
+			History      map[string][]float64      `json:"history"`
+			TimedHistory map[string][]HistoryPoint `json:"timed_history"`
 		}{
 			Partitions: make(map[string]struct {
 				Device     string  `json:"device"`
@@ -114,11 +182,55 @@ func New() *SystemSummary {
 				MountPoint string  `json:"mount_point"`
 				Critical   bool    `json:"critical"`
 			}),
-			History: make(map[string][]float64),
+			History:      make(map[string][]float64),
+			TimedHistory: make(map[string][]HistoryPoint),
 		},
+		tempSum:    make(map[string]float64),
+		tempCount:  make(map[string]int),
+		precision:  -1,
+		thresholds: parser.DefaultProcessThresholds,
 	}
 }
 
+// SetSkipUnchanged sets whether Save skips the write when the summary didn't
+// change since the last one written to the same file, sparing flash storage
+// with limited write cycles. Timestamps are ignored in the comparison.
+func (s *SystemSummary) SetSkipUnchanged(skip bool) {
+	s.skipUnchanged = skip
+}
+
+// SetOverallTemperature sets how the sensors are combined into the overall
+// temperature the stress score is based on
+func (s *SystemSummary) SetOverallTemperature(overall temperature.Overall) {
+	s.overallTemp = overall
+}
+
+// SetWatchlist sets the command substrings of the processes whose state is
+// reported in Watched
+func (s *SystemSummary) SetWatchlist(patterns []string) {
+	s.watchlist = patterns
+}
+
+// SetProcessThresholds sets the thresholds for counting high CPU and high
+// memory processes
+func (s *SystemSummary) SetProcessThresholds(thresholds parser.ProcessThresholds) {
+	s.thresholds = thresholds
+}
+
+// SetPrecision sets the number of decimals stored percentages are rounded
+// to, so saved summaries don't change on insignificant digits. A negative
+// value keeps full precision.
+func (s *SystemSummary) SetPrecision(decimals int) {
+	s.precision = decimals
+}
+
+// SetFilesystemThresholds sets the thresholds from which a partition adds
+// system stress as critical or low on space, and the weights of the
+// partitions whose low space adds more
+func (s *SystemSummary) SetFilesystemThresholds(thresholds filesystem.Thresholds) {
+	s.fsThresholds = thresholds
+}
+
 func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.PowerStats, tempStats *temperature.TemperatureStats, crashFile string) {
 	s.Timestamp = time.Now()
 	if crashFile != "" {
@@ -126,20 +238,40 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 		s.LastCrashTime = time.Now()
 	}
 
+	// Sections missing from a cut short top output keep their last values
+	s.Absent = nil
+	for _, section := range stats.Missing() {
+		s.Absent = append(s.Absent, string(section))
+	}
+
+	if stats.Format != "" {
+		s.TopFormat = string(stats.Format)
+	}
+
 	// Update CPU stats
-	s.CPU.User = stats.CPU.User
-	s.CPU.System = stats.CPU.Sys
-	s.CPU.Idle = stats.CPU.Idle
-	s.CPU.Load1 = stats.LoadAverage.One
-	s.CPU.Load5 = stats.LoadAverage.Five
-	s.CPU.Load15 = stats.LoadAverage.Fifteen
-
-	// Update memory stats
-	total := stats.Memory.Total
-	s.Memory.Total = uint64(total)
-	s.Memory.Used = uint64(stats.Memory.Used)
-	s.Memory.Free = uint64(stats.Memory.Free)
-	s.Memory.UsedPc = float64(s.Memory.Used) / float64(total) * 100
+	if stats.Has(parser.SectionCPU) {
+		s.CPU.User = stats.CPU.User
+		s.CPU.System = stats.CPU.Sys
+		s.CPU.Idle = stats.CPU.Idle
+	}
+	if stats.Has(parser.SectionLoad) {
+		s.CPU.Load1 = stats.LoadAverage.One
+		s.CPU.Load5 = stats.LoadAverage.Five
+		s.CPU.Load15 = stats.LoadAverage.Fifteen
+	}
+
+	// Update memory stats. A zero total means top's memory line couldn't be
+	// read; usage is then recorded as 0% instead of dividing by zero.
+	if stats.Has(parser.SectionMemory) {
+		if total := stats.Memory.Total; total > 0 {
+			s.Memory.Total = uint64(total)
+			s.Memory.Used = uint64(stats.Memory.Used)
+			s.Memory.Free = uint64(stats.Memory.Free)
+			s.Memory.UsedPc = float64(s.Memory.Used) / float64(total) * 100
+		} else {
+			s.Memory.Total, s.Memory.Used, s.Memory.Free, s.Memory.UsedPc = 0, 0, 0, 0
+		}
+	}
 
 	// Update temperature stats
 	s.Temperature.Sensors = make(map[string]struct {
@@ -147,6 +279,7 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 		Location string  `json:"location"`
 		MaxTemp  float64 `json:"max_temp"`
 		AvgTemp  float64 `json:"avg_temp"`
+		Smoothed float64 `json:"smoothed"`
 	})
 
 	// Add all detected sensors with their locations and update history
@@ -163,17 +296,23 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 			Location string  `json:"location"`
 			MaxTemp  float64 `json:"max_temp"`
 			AvgTemp  float64 `json:"avg_temp"`
+			Smoothed float64 `json:"smoothed"`
 		}{
 			Value:    temp,
 			Location: location,
 			MaxTemp:  temp, // Initial value, will be updated
 			AvgTemp:  temp, // Initial value, will be updated
+			Smoothed: temp, // Initial value, will be updated
 		}
 
+		s.tempSum[sensorName] += temp
+		s.tempCount[sensorName]++
+
 		s.Temperature.History[sensorName] = append(s.Temperature.History[sensorName], temp)
-		if len(s.Temperature.History[sensorName]) > 10 {
+		if len(s.Temperature.History[sensorName]) > historyLength {
 			s.Temperature.History[sensorName] = s.Temperature.History[sensorName][1:]
 		}
+		s.Temperature.TimedHistory[sensorName] = appendHistoryPoint(s.Temperature.TimedHistory[sensorName], s.Timestamp, temp)
 	}
 
 	// Calculate max and avg for each sensor and overall
@@ -200,19 +339,24 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 			overallCount++
 		}
 
-		// Update sensor max and avg
+		// Update sensor max, running average and smoothed value
 		sensor := s.Temperature.Sensors[sensorName]
 		sensor.MaxTemp = sensorMax
-		sensor.AvgTemp = sensorSum / float64(len(temps))
+		sensor.Smoothed = sensorSum / float64(len(temps))
+		sensor.AvgTemp = sensor.Smoothed
+		if s.tempCount[sensorName] > 0 {
+			sensor.AvgTemp = s.tempSum[sensorName] / float64(s.tempCount[sensorName])
+		}
 		s.Temperature.Sensors[sensorName] = sensor
 	}
 
 	if overallCount > 0 {
 		s.Temperature.AvgTemp = overallSum / float64(overallCount)
 	}
+	s.Temperature.Overall = s.overallTemp.Of(s.Temperature.History)
 
 	// Update process stats
-	s.Processes.Total = len(stats.Processes)
+	s.Processes.Total = stats.ProcessCount()
 	stateCount := make(map[string]int)
 	highCPU := 0
 	highMem := 0
@@ -223,7 +367,7 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 
 	for _, proc := range stats.Processes {
 		stateCount[proc.State]++
-		if proc.CPUPercent > 10 {
+		if s.thresholds.HighCPU(proc) {
 			highCPU++
 			s.Processes.HighCPUProcs = append(s.Processes.HighCPUProcs, struct {
 				Name       string  `json:"name"`
@@ -233,7 +377,7 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 				CPUPercent: proc.CPUPercent,
 			})
 		}
-		if proc.VSZPercent > 5 {
+		if s.thresholds.HighMemory(proc) {
 			highMem++
 		}
 	}
@@ -242,11 +386,25 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 	s.Processes.Sleeping = stateCount["S"]
 	s.Processes.Uninterr = stateCount["D"]
 	s.Processes.Zombie = stateCount["Z"]
+	if stats.Tasks.Total > 0 {
+		// The summary line counts every task, the table may be truncated
+		s.Processes.Running = stats.Tasks.Running
+		s.Processes.Sleeping = stats.Tasks.Sleeping
+		s.Processes.Zombie = stats.Tasks.Zombie
+	}
+	s.Processes.UninterrCmds = parser.UninterruptibleCommands(stats.Processes)
+	if len(s.watchlist) > 0 {
+		s.Watched = parser.Watch(stats.Processes, s.watchlist)
+	}
 	s.Processes.HighCPU = highCPU
 	s.Processes.HighMem = highMem
 
-	// Calculate system stress
-	s.SystemStress = calculateSystemStress(s)
+	// Update power stats
+	if powerStats != nil {
+		s.Power.OnAC = powerStats.OnAC()
+		s.Power.DrawWatts = powerStats.Draw()
+		s.Power.Supplies = powerStats.Supplies
+	}
 
 	// Update filesystem stats
 	if stats.Filesystem != nil {
@@ -266,6 +424,9 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 		if s.Filesystem.History == nil {
 			s.Filesystem.History = make(map[string][]float64)
 		}
+		if s.Filesystem.TimedHistory == nil {
+			s.Filesystem.TimedHistory = make(map[string][]HistoryPoint)
+		}
 
 		// Update partitions info
 		for mount, fs := range stats.Filesystem {
@@ -292,101 +453,164 @@ func (s *SystemSummary) Update(stats *parser.SystemStats, powerStats *power.Powe
 
 			// Update history
 			s.Filesystem.History[mount] = append(s.Filesystem.History[mount], freeSpace)
-			if len(s.Filesystem.History[mount]) > 10 {
+			if len(s.Filesystem.History[mount]) > historyLength {
 				s.Filesystem.History[mount] = s.Filesystem.History[mount][1:]
 			}
+			s.Filesystem.TimedHistory[mount] = appendHistoryPoint(s.Filesystem.TimedHistory[mount], s.Timestamp, freeSpace)
 		}
 	}
+
+	// Calculate system stress, once the partitions are up to date
+	s.StressBreakdown = calculateSystemStress(s)
+	s.SystemStress = s.StressBreakdown.Total()
+
+	s.roundPercentages()
+}
+
+// SetInsights records the insights derived from the latest sample
+func (s *SystemSummary) SetInsights(insights []analyzer.Insight) {
+	s.Insights = insights
+}
+
+// roundPercentages rounds the stored percentages to the configured precision
+func (s *SystemSummary) roundPercentages() {
+	s.CPU.User = round.To(s.CPU.User, s.precision)
+	s.CPU.System = round.To(s.CPU.System, s.precision)
+	s.CPU.Idle = round.To(s.CPU.Idle, s.precision)
+	s.Memory.UsedPc = round.To(s.Memory.UsedPc, s.precision)
+	s.SystemStress = round.To(s.SystemStress, s.precision)
+	for i := range s.Processes.HighCPUProcs {
+		s.Processes.HighCPUProcs[i].CPUPercent = round.To(s.Processes.HighCPUProcs[i].CPUPercent, s.precision)
+	}
+	for mount, partition := range s.Filesystem.Partitions {
+		partition.UsedPct = round.To(partition.UsedPct, s.precision)
+		partition.FreeSpace = round.To(partition.FreeSpace, s.precision)
+		s.Filesystem.Partitions[mount] = partition
+	}
 }
 
-func calculateSystemStress(s *SystemSummary) float64 {
-	stress := 0.0
+// Stress a critical or low space partition adds, unless weighted by
+// -critical-partitions
+var (
+	partitionStress       = filesystem.PartitionStress{Critical: 20, Low: 10}
+	systemPartitionStress = map[string]filesystem.PartitionStress{
+		"/":     {Critical: 40, Low: 20},
+		"/boot": {Critical: 30, Low: 15},
+	}
+)
+
+// calculateSystemStress itemizes the factors of the system stress
+func calculateSystemStress(s *SystemSummary) stress.Breakdown {
+	var factors stress.Breakdown
 
 	// CPU stress factors (total CPU usage)
 	totalCPU := s.CPU.User + s.CPU.System
 	if totalCPU > 90 {
-		stress += 30
+		factors.Add("cpu", 30)
 	} else if totalCPU > 70 {
-		stress += 20
+		factors.Add("cpu", 20)
 	} else if totalCPU > 50 {
-		stress += 10
+		factors.Add("cpu", 10)
 	}
 
 	// Memory stress factors
 	if s.Memory.UsedPc > 90 {
-		stress += 30
+		factors.Add("memory", 30)
 	} else if s.Memory.UsedPc > 70 {
-		stress += 20
+		factors.Add("memory", 20)
 	} else if s.Memory.UsedPc > 50 {
-		stress += 10
+		factors.Add("memory", 10)
 	}
 
 	// Load average stress
 	load := s.CPU.Load1
 	if load > 10 {
-		stress += 30
+		factors.Add("load", 30)
 	} else if load > 5 {
-		stress += 20
+		factors.Add("load", 20)
 	} else if load > 2 {
-		stress += 10
+		factors.Add("load", 10)
 	}
 
 	// Temperature stress - Operating range: -25°C to 75°C
-	if s.Temperature.MaxTemp > 70 {
+	if s.Temperature.Overall > 70 {
 		// Approaching the upper limit of operating range
-		stress += 30
-	} else if s.Temperature.MaxTemp > 60 {
-		stress += 20
-	} else if s.Temperature.MaxTemp > 50 {
-		stress += 10
-	} else if s.Temperature.MaxTemp < -20 {
+		factors.Add("temperature high", 30)
+	} else if s.Temperature.Overall > 60 {
+		factors.Add("temperature high", 20)
+	} else if s.Temperature.Overall > 50 {
+		factors.Add("temperature high", 10)
+	} else if s.Temperature.Overall < -20 {
 		// Approaching the lower limit of operating range
-		stress += 20
-	} else if s.Temperature.MaxTemp < -10 {
-		stress += 10
+		factors.Add("temperature low", 20)
+	} else if s.Temperature.Overall < -10 {
+		factors.Add("temperature low", 10)
 	}
 
 	// Process stress factors
 	if s.Processes.Uninterr > 5 {
-		stress += 20
+		factors.Add("uninterruptible processes", 20)
 	}
 	if s.Processes.HighCPU > 10 {
-		stress += 20
+		factors.Add("high cpu processes", 20)
 	}
 
-	// Filesystem stress factors
-	for mount, partition := range s.Filesystem.Partitions {
-		// Critical low space on any partition
-		if partition.FreeSpace < 10 {
-			// Higher stress for critical system partitions
-			if mount == "/" {
-				stress += 40 // Root partition critical
-			} else if mount == "/boot" {
-				stress += 30 // Boot partition critical
-			} else {
-				stress += 20 // Other partition critical
+	// Battery stress factors, only while running on battery
+	if !s.Power.OnAC {
+		for _, name := range sortedKeys(s.Power.Supplies) {
+			supply := s.Power.Supplies[name]
+			if !supply.Discharging() || supply.Capacity < 0 {
+				continue
 			}
-		} else if partition.FreeSpace < 20 {
-			// Warning level (less than 20% free)
-			if mount == "/" {
-				stress += 20 // Root partition low
-			} else if mount == "/boot" {
-				stress += 15 // Boot partition low
-			} else {
-				stress += 10 // Other partition low
+			if supply.Capacity < 10 {
+				factors.Add("battery "+name+" critical", 30)
+			} else if supply.Capacity < 20 {
+				factors.Add("battery "+name+" low", 15)
 			}
 		}
 	}
 
-	return min(stress, 100.0)
+	// Filesystem stress factors, weighted so that e.g. root and boot count
+	// more
+	for _, mount := range sortedKeys(s.Filesystem.Partitions) {
+		partition := s.Filesystem.Partitions[mount]
+		weighted := s.fsThresholds.Weights.Stress(mount, partitionStress, systemPartitionStress)
+		if s.fsThresholds.IsCritical(partition.FreeSpace, partition.Size) {
+			factors.Add("partition "+mount+" critical", weighted.Critical) // Critical low space
+		} else if partition.FreeSpace < 20 && s.fsThresholds.Evaluated(partition.Size) {
+			// Warning level (less than 20% free)
+			factors.Add("partition "+mount+" low", weighted.Low) // Low space
+		}
+	}
+
+	return factors
+}
+
+// sortedKeys returns the keys of m in order, so the stress factors are
+// listed in the same order every time
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (s *SystemSummary) Save(filename string) error {
+	// A NaN or Inf anywhere would make the marshal fail and lose the summary
+	sanitize.Floats(s)
+
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal summary: %w", err)
 	}
 
+	changed, sum, err := s.changedSince(filename)
+	if err != nil || !changed {
+		return err
+	}
+
 	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
@@ -394,14 +618,62 @@ func (s *SystemSummary) Save(filename string) error {
 	if err := os.WriteFile(filename, data, 0644); err != nil {
 		return fmt.Errorf("failed to write summary: %w", err)
 	}
+	s.saved(filename, sum)
 
 	return nil
 }
 
-// helper function
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+// changedSince reports whether the summary needs writing to filename: always
+// unless skipping unchanged writes, otherwise when it changed since the last
+// write to that file. sum is the fingerprint to record once written.
+func (s *SystemSummary) changedSince(filename string) (changed bool, sum [sha256.Size]byte, err error) {
+	if !s.skipUnchanged {
+		return true, sum, nil
+	}
+	if sum, err = s.fingerprint(); err != nil {
+		return false, sum, err
+	}
+	last, written := s.savedSums[filename]
+	return !written || sum != last, sum, nil
+}
+
+// saved records the fingerprint of a write to filename
+func (s *SystemSummary) saved(filename string, sum [sha256.Size]byte) {
+	if !s.skipUnchanged {
+		return
+	}
+	if s.savedSums == nil {
+		s.savedSums = make(map[string][sha256.Size]byte)
+	}
+	s.savedSums[filename] = sum
+}
+
+// fingerprint hashes the summary without its timestamps, which change on
+// every update even when nothing else does
+func (s *SystemSummary) fingerprint() ([sha256.Size]byte, error) {
+	c := *s
+	c.Timestamp = time.Time{}
+	c.Temperature.TimedHistory = nil
+	c.Filesystem.TimedHistory = nil
+	// Insights are stamped with the time they were derived at
+	c.Insights = make([]analyzer.Insight, len(s.Insights))
+	for i, insight := range s.Insights {
+		insight.Timestamp = time.Time{}
+		c.Insights[i] = insight
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	return sha256.Sum256(data), nil
+}
+
+// appendHistoryPoint appends a timestamped value, keeping the same number of
+// points as the plain history arrays
+func appendHistoryPoint(points []HistoryPoint, t time.Time, value float64) []HistoryPoint {
+	points = append(points, HistoryPoint{Time: t, Value: value})
+	if len(points) > historyLength {
+		points = points[1:]
 	}
-	return b
+	return points
 }
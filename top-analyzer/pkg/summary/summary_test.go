@@ -0,0 +1,652 @@
+package summary
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/analyzer"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/stress"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	insight := func(description string, at time.Time) analyzer.Insight {
+		return analyzer.Insight{Type: "High CPU Usage", Description: description, Severity: "Warning", Timestamp: at}
+	}
+
+	tests := []struct {
+		name       string
+		a, b       func(s *SystemSummary)
+		wantEquals bool
+	}{
+		{
+			name:       "only the timestamp differs",
+			a:          func(s *SystemSummary) { s.Timestamp = base },
+			b:          func(s *SystemSummary) { s.Timestamp = base.Add(time.Minute) },
+			wantEquals: true,
+		},
+		{
+			name: "identical insights derived at different times",
+			a:    func(s *SystemSummary) { s.SetInsights([]analyzer.Insight{insight("CPU usage is high", base)}) },
+			b: func(s *SystemSummary) {
+				s.SetInsights([]analyzer.Insight{insight("CPU usage is high", base.Add(time.Minute))})
+			},
+			wantEquals: true,
+		},
+		{
+			name:       "different insights",
+			a:          func(s *SystemSummary) { s.SetInsights([]analyzer.Insight{insight("CPU usage is high", base)}) },
+			b:          func(s *SystemSummary) { s.SetInsights([]analyzer.Insight{insight("CPU usage is very high", base)}) },
+			wantEquals: false,
+		},
+		{
+			name:       "an insight appears",
+			a:          func(s *SystemSummary) {},
+			b:          func(s *SystemSummary) { s.SetInsights([]analyzer.Insight{insight("CPU usage is high", base)}) },
+			wantEquals: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := New(), New()
+			tt.a(a)
+			tt.b(b)
+			sumA, err := a.fingerprint()
+			if err != nil {
+				t.Fatalf("fingerprint() error = %v", err)
+			}
+			sumB, err := b.fingerprint()
+			if err != nil {
+				t.Fatalf("fingerprint() error = %v", err)
+			}
+			if got := sumA == sumB; got != tt.wantEquals {
+				t.Errorf("fingerprints equal = %v, want %v", got, tt.wantEquals)
+			}
+		})
+	}
+}
+
+func TestTimedHistoryAlignsWithHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+	}{
+		{name: "single sample", samples: 1},
+		{name: "history not full", samples: 4},
+		{name: "history overflowing", samples: historyLength + 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			var times []time.Time
+			for i := 0; i < tt.samples; i++ {
+				stats := &parser.SystemStats{
+					Filesystem: map[string]parser.FilesystemStats{
+						"/": {Device: "/dev/sda1", Size: 1 << 30, UsedPct: float64(i), MountPoint: "/"},
+					},
+				}
+				temps := &temperature.TemperatureStats{Sensors: map[string]float64{"cpu": float64(40 + i)}}
+				s.Update(stats, nil, temps, "")
+				times = append(times, s.Timestamp)
+			}
+			times = times[max(len(times)-historyLength, 0):]
+
+			check := func(series string, values []float64, points []HistoryPoint) {
+				if len(points) != len(values) {
+					t.Fatalf("%s: %d timed points for %d values", series, len(points), len(values))
+				}
+				for i, point := range points {
+					if point.Value != values[i] {
+						t.Errorf("%s[%d] value = %v, want %v", series, i, point.Value, values[i])
+					}
+					if !point.Time.Equal(times[i]) {
+						t.Errorf("%s[%d] time = %v, want %v", series, i, point.Time, times[i])
+					}
+				}
+			}
+			check("temperature", s.Temperature.History["cpu"], s.Temperature.TimedHistory["cpu"])
+			check("filesystem", s.Filesystem.History["/"], s.Filesystem.TimedHistory["/"])
+		})
+	}
+}
+
+func TestSmoothedTemperature(t *testing.T) {
+	tests := []struct {
+		name         string
+		temps        []float64
+		wantValue    float64
+		wantSmoothed float64
+		wantAvg      float64
+	}{
+		{name: "single reading", temps: []float64{50}, wantValue: 50, wantSmoothed: 50, wantAvg: 50},
+		{name: "steady", temps: []float64{50, 50, 50}, wantValue: 50, wantSmoothed: 50, wantAvg: 50},
+		{name: "rising", temps: []float64{40, 42, 44, 46, 48}, wantValue: 48, wantSmoothed: 44, wantAvg: 44},
+		{
+			// The smoothed value only covers the retained history, the
+			// average every reading since the start
+			name:         "rising beyond the history",
+			temps:        []float64{30, 32, 34, 36, 38, 40, 42, 44, 46, 48, 50, 52, 54, 56, 58},
+			wantValue:    58,
+			wantSmoothed: 49,
+			wantAvg:      44,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			for _, temp := range tt.temps {
+				s.Update(&parser.SystemStats{}, nil, &temperature.TemperatureStats{Sensors: map[string]float64{"cpu": temp}}, "")
+			}
+
+			sensor := s.Temperature.Sensors["cpu"]
+			if sensor.Value != tt.wantValue {
+				t.Errorf("Value = %v, want %v", sensor.Value, tt.wantValue)
+			}
+			if sensor.Smoothed != tt.wantSmoothed {
+				t.Errorf("Smoothed = %v, want %v", sensor.Smoothed, tt.wantSmoothed)
+			}
+			if sensor.AvgTemp != tt.wantAvg {
+				t.Errorf("AvgTemp = %v, want %v", sensor.AvgTemp, tt.wantAvg)
+			}
+		})
+	}
+}
+
+func TestSummaryPrecision(t *testing.T) {
+	tests := []struct {
+		name          string
+		precision     int
+		wantMemory    float64
+		wantCPUUser   float64
+		wantPartition float64
+	}{
+		{name: "two decimals", precision: 2, wantMemory: 45.67, wantCPUUser: 12.35, wantPartition: 33.33},
+		{name: "one decimal", precision: 1, wantMemory: 45.7, wantCPUUser: 12.3, wantPartition: 33.3},
+		{name: "full precision", precision: -1, wantMemory: 45.6666, wantCPUUser: 12.3456, wantPartition: 33.3333},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.SetPrecision(tt.precision)
+			s.Update(&parser.SystemStats{
+				CPU:    parser.CPU{User: 12.3456},
+				Memory: parser.Memory{Total: 1000000, Used: 456666, Free: 543334},
+				Filesystem: map[string]parser.FilesystemStats{
+					"/": {Size: 1 << 30, UsedPct: 33.3333, MountPoint: "/"},
+				},
+			}, nil, &temperature.TemperatureStats{}, "")
+
+			if s.Memory.UsedPc != tt.wantMemory {
+				t.Errorf("memory used percent = %v, want %v", s.Memory.UsedPc, tt.wantMemory)
+			}
+			if s.CPU.User != tt.wantCPUUser {
+				t.Errorf("CPU user = %v, want %v", s.CPU.User, tt.wantCPUUser)
+			}
+			if got := s.Filesystem.Partitions["/"].UsedPct; got != tt.wantPartition {
+				t.Errorf("partition used percent = %v, want %v", got, tt.wantPartition)
+			}
+		})
+	}
+}
+
+func TestInsightsMarshaled(t *testing.T) {
+	tests := []struct {
+		name      string
+		cpu       parser.CPU
+		wantTypes []string
+	}{
+		{name: "high CPU", cpu: parser.CPU{User: 85, Sys: 10}, wantTypes: []string{"High CPU Usage"}},
+		{name: "idle", cpu: parser.CPU{User: 5, Sys: 2, Idle: 93}, wantTypes: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &parser.SystemStats{
+				CPU:    tt.cpu,
+				Memory: parser.Memory{Total: 1000, Used: 100, Free: 900},
+			}
+			insights := analyzer.New(10)
+			insights.AddStats(stats)
+			s := New()
+			s.Update(stats, nil, &temperature.TemperatureStats{}, "")
+			s.SetInsights(insights.GetInsights())
+
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var decoded struct {
+				Insights []struct {
+					Type        string `json:"type"`
+					Description string `json:"description"`
+				} `json:"insights"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			types := []string{}
+			for _, insight := range decoded.Insights {
+				types = append(types, insight.Type)
+				if insight.Description == "" {
+					t.Errorf("insight %s has no description", insight.Type)
+				}
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("marshaled insight types = %q, want %q", types, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestProcessThresholds(t *testing.T) {
+	procs := []parser.Process{
+		{PID: 1, Command: "init", CPUPercent: 0.1, VSZPercent: 0.2},
+		{PID: 10, Command: "borderline", CPUPercent: 12, VSZPercent: 6},
+		{PID: 20, Command: "hog", CPUPercent: 60, VSZPercent: 30},
+	}
+
+	tests := []struct {
+		name        string
+		thresholds  parser.ProcessThresholds
+		wantHighCPU int
+		wantHighMem int
+	}{
+		{name: "defaults", thresholds: parser.DefaultProcessThresholds, wantHighCPU: 2, wantHighMem: 2},
+		{name: "raised CPU threshold", thresholds: parser.ProcessThresholds{CPUPercent: 15, MemoryPercent: 5}, wantHighCPU: 1, wantHighMem: 2},
+		{name: "raised memory threshold", thresholds: parser.ProcessThresholds{CPUPercent: 10, MemoryPercent: 10}, wantHighCPU: 2, wantHighMem: 1},
+		{name: "hog excluded", thresholds: parser.ProcessThresholds{CPUPercent: 10, MemoryPercent: 5, ExcludePID: 20}, wantHighCPU: 1, wantHighMem: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.SetProcessThresholds(tt.thresholds)
+			s.Update(&parser.SystemStats{Processes: procs}, nil, &temperature.TemperatureStats{}, "")
+
+			if s.Processes.HighCPU != tt.wantHighCPU {
+				t.Errorf("high CPU processes = %d, want %d", s.Processes.HighCPU, tt.wantHighCPU)
+			}
+			if len(s.Processes.HighCPUProcs) != tt.wantHighCPU {
+				t.Errorf("listed high CPU processes = %d, want %d", len(s.Processes.HighCPUProcs), tt.wantHighCPU)
+			}
+			if s.Processes.HighMem != tt.wantHighMem {
+				t.Errorf("high memory processes = %d, want %d", s.Processes.HighMem, tt.wantHighMem)
+			}
+		})
+	}
+}
+
+func TestSaveNonFinite(t *testing.T) {
+	tests := []struct {
+		name string
+		cpu  parser.CPU
+		temp float64
+	}{
+		{name: "NaN CPU usage", cpu: parser.CPU{User: math.NaN()}, temp: 40},
+		{name: "Inf temperature", cpu: parser.CPU{User: 10}, temp: math.Inf(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.Update(&parser.SystemStats{CPU: tt.cpu}, nil, &temperature.TemperatureStats{
+				Sensors: map[string]float64{"cpu": tt.temp},
+			}, "")
+
+			filename := filepath.Join(t.TempDir(), "summary.json")
+			if err := s.Save(filename); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("summary not written: %v", err)
+			}
+			var saved struct {
+				CPU struct {
+					User float64 `json:"user"`
+				} `json:"cpu"`
+				Temperature struct {
+					Sensors map[string]struct {
+						Value float64 `json:"value"`
+					} `json:"sensors"`
+				} `json:"temperature"`
+			}
+			if err := json.Unmarshal(data, &saved); err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range map[string]float64{"cpu user": saved.CPU.User, "cpu sensor": saved.Temperature.Sensors["cpu"].Value} {
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					t.Errorf("saved %s = %v, want a finite value", name, value)
+				}
+			}
+		})
+	}
+}
+
+func TestPartialTopOutput(t *testing.T) {
+	const (
+		load   = "top - 07:32:26 up 4 days, 23:37,  1 user,  load average: 0.10, 0.24, 0.20\n"
+		cpu    = "%Cpu(s): 12.0 us,  3.0 sy,  0.0 ni, 84.5 id,  0.5 wa,  0.0 hi,  0.0 si,  0.0 st\n"
+		memory = "MiB Mem :   2048.0 total,   1024.0 free,    512.0 used,    512.0 buff/cache\n"
+		swap   = "MiB Swap:   1024.0 total,   1024.0 free,      0.0 used.   1400.0 avail Mem\n"
+		table  = "\n  PID USER      PR  NI    VIRT    RES    SHR S  %CPU  %MEM     TIME+ COMMAND\n" +
+			"    1 root      20   0  167744  11832   8456 S   0.0   0.6   0:04.12 systemd\n"
+	)
+	// The summary starts from a full sample with 50% memory used and 40% CPU
+	full := &parser.SystemStats{
+		CPU:    parser.CPU{User: 30, Sys: 10, Idle: 60},
+		Memory: parser.Memory{Total: 1000, Used: 500, Free: 500},
+	}
+
+	tests := []struct {
+		name           string
+		output         string
+		wantAbsent     []string
+		wantCPUUser    float64
+		wantMemoryUsed float64
+	}{
+		{name: "full output", output: load + cpu + memory + swap + table, wantCPUUser: 12, wantMemoryUsed: 25},
+		{name: "CPU only", output: cpu, wantAbsent: []string{"memory", "swap", "load", "processes"}, wantCPUUser: 12, wantMemoryUsed: 50},
+		{name: "cut off after memory", output: load + cpu + memory, wantAbsent: []string{"swap", "processes"}, wantCPUUser: 12, wantMemoryUsed: 25},
+		{name: "memory only", output: memory, wantAbsent: []string{"cpu", "swap", "load", "processes"}, wantCPUUser: 30, wantMemoryUsed: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := parser.ParseTopOutput([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("ParseTopOutput() error = %v", err)
+			}
+
+			s := New()
+			s.Update(full, nil, &full.Temperature, "")
+			s.Update(stats, nil, &stats.Temperature, "")
+
+			if !reflect.DeepEqual(s.Absent, tt.wantAbsent) {
+				t.Errorf("Absent = %q, want %q", s.Absent, tt.wantAbsent)
+			}
+			if s.CPU.User != tt.wantCPUUser {
+				t.Errorf("CPU user = %v, want %v", s.CPU.User, tt.wantCPUUser)
+			}
+			if s.Memory.UsedPc != tt.wantMemoryUsed {
+				t.Errorf("memory used = %v%%, want %v%%", s.Memory.UsedPc, tt.wantMemoryUsed)
+			}
+		})
+	}
+}
+
+func TestTopFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []parser.Format // Of the samples in turn
+		want    string
+	}{
+		{name: "busybox", formats: []parser.Format{parser.FormatBusyBox}, want: "busybox"},
+		{name: "gnu", formats: []parser.Format{parser.FormatGNU}, want: "gnu"},
+		{name: "stats not from top keep the format", formats: []parser.Format{parser.FormatGNU, ""}, want: "gnu"},
+		{name: "never from top", formats: []parser.Format{""}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			for _, format := range tt.formats {
+				stats := &parser.SystemStats{Format: format}
+				s.Update(stats, nil, &stats.Temperature, "")
+			}
+
+			if s.TopFormat != tt.want {
+				t.Errorf("TopFormat = %q, want %q", s.TopFormat, tt.want)
+			}
+			data, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := fields["top_format"].(string); got != tt.want {
+				t.Errorf("top_format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCriticalFreePercent(t *testing.T) {
+	tests := []struct {
+		name         string
+		criticalFree float64
+		wantStress   float64 // Stress added by the partition at 12% free
+	}{
+		{name: "default 10% critical free", criticalFree: filesystem.DefaultThresholds.CriticalFreePercent, wantStress: 10},
+		{name: "15% critical free", criticalFree: 15, wantStress: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stress := func(fs map[string]parser.FilesystemStats) float64 {
+				s := New()
+				thresholds := filesystem.DefaultThresholds
+				thresholds.CriticalFreePercent = tt.criticalFree
+				s.SetFilesystemThresholds(thresholds)
+				s.Update(&parser.SystemStats{Filesystem: fs}, nil, &temperature.TemperatureStats{}, "")
+				return s.SystemStress
+			}
+
+			base := stress(nil)
+			got := stress(map[string]parser.FilesystemStats{
+				"/data": {Size: 10 << 30, UsedPct: 88, MountPoint: "/data"},
+			})
+			if got-base != tt.wantStress {
+				t.Errorf("partition stress = %v, want %v", got-base, tt.wantStress)
+			}
+		})
+	}
+}
+
+func TestPartitionWeights(t *testing.T) {
+	// Both partitions are 95% full
+	stats := &parser.SystemStats{
+		Filesystem: map[string]parser.FilesystemStats{
+			"/":     {Device: "/dev/sda1", Size: 10737418240, Used: 10200547328, Available: 536870912, UsedPct: 95, MountPoint: "/"},
+			"/data": {Device: "/dev/sdb1", Size: 10737418240, Used: 10200547328, Available: 536870912, UsedPct: 95, MountPoint: "/data"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		weights filesystem.PartitionWeights
+		want    map[string]float64 // Stress factor by name
+	}{
+		{
+			name: "default",
+			want: map[string]float64{"partition / critical": 40, "partition /data critical": 20},
+		},
+		{
+			name:    "custom critical partition",
+			weights: filesystem.PartitionWeights{"/data": 2},
+			want:    map[string]float64{"partition / critical": 20, "partition /data critical": 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := filesystem.DefaultThresholds
+			thresholds.Weights = tt.weights
+
+			s := New()
+			s.SetFilesystemThresholds(thresholds)
+			s.Update(stats, nil, &stats.Temperature, "")
+
+			got := make(map[string]float64)
+			for _, factor := range s.StressBreakdown {
+				got[factor.Name] = factor.Value
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("stress factor %q = %v, want %v (breakdown %v)", name, got[name], want, s.StressBreakdown)
+				}
+			}
+		})
+	}
+}
+
+// TestDefaultPartitionStress pins the stress of critical and low space
+// partitions without -critical-partitions
+func TestDefaultPartitionStress(t *testing.T) {
+	const size = 10737418240
+
+	tests := []struct {
+		name    string
+		usedPct float64
+		want    map[string]float64 // Stress factor by name
+	}{
+		{
+			name:    "critical",
+			usedPct: 95,
+			want:    map[string]float64{"partition / critical": 40, "partition /boot critical": 30, "partition /data critical": 20},
+		},
+		{
+			name:    "low",
+			usedPct: 85,
+			want:    map[string]float64{"partition / low": 20, "partition /boot low": 15, "partition /data low": 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &parser.SystemStats{Filesystem: make(map[string]parser.FilesystemStats)}
+			for _, mount := range []string{"/", "/boot", "/data"} {
+				stats.Filesystem[mount] = parser.FilesystemStats{Size: size, UsedPct: tt.usedPct, MountPoint: mount}
+			}
+
+			s := New()
+			s.SetFilesystemThresholds(filesystem.DefaultThresholds)
+			s.Update(stats, nil, &stats.Temperature, "")
+
+			got := make(map[string]float64)
+			for _, factor := range s.StressBreakdown {
+				got[factor.Name] = factor.Value
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("stress factor %q = %v, want %v (breakdown %v)", name, got[name], want, s.StressBreakdown)
+				}
+			}
+		})
+	}
+}
+
+func TestStressBreakdown(t *testing.T) {
+	// Root is 95% full
+	root := map[string]parser.FilesystemStats{
+		"/": {Device: "/dev/sda1", Size: 10737418240, Used: 10200547328, Available: 536870912, UsedPct: 95, MountPoint: "/"},
+	}
+
+	tests := []struct {
+		name      string
+		stats     *parser.SystemStats
+		want      stress.Breakdown
+		wantTotal float64
+	}{
+		{
+			name: "cpu, memory and root critical",
+			stats: &parser.SystemStats{
+				CPU:        parser.CPU{User: 60, Sys: 15, Idle: 25},
+				Memory:     parser.Memory{Total: 1000, Used: 950, Free: 50},
+				Filesystem: root,
+			},
+			want:      stress.Breakdown{{Name: "cpu", Value: 20}, {Name: "memory", Value: 30}, {Name: "partition / critical", Value: 40}},
+			wantTotal: 90,
+		},
+		{
+			name: "capped",
+			stats: &parser.SystemStats{
+				CPU:        parser.CPU{User: 80, Sys: 15, Idle: 5},
+				Memory:     parser.Memory{Total: 1000, Used: 950, Free: 50},
+				Filesystem: root,
+			},
+			want:      stress.Breakdown{{Name: "cpu", Value: 30}, {Name: "memory", Value: 30}, {Name: "partition / critical", Value: 40}},
+			wantTotal: 100,
+		},
+		{
+			name:      "idle",
+			stats:     &parser.SystemStats{CPU: parser.CPU{User: 5, Sys: 5, Idle: 90}, Memory: parser.Memory{Total: 1000, Used: 100, Free: 900}},
+			wantTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.Update(tt.stats, nil, &tt.stats.Temperature, "")
+
+			if !reflect.DeepEqual(s.StressBreakdown, tt.want) {
+				t.Errorf("StressBreakdown = %v, want %v", s.StressBreakdown, tt.want)
+			}
+			if got := s.StressBreakdown.Sum(); got != tt.want.Sum() {
+				t.Errorf("StressBreakdown.Sum() = %v, want %v", got, tt.want.Sum())
+			}
+			if s.SystemStress != tt.wantTotal {
+				t.Errorf("SystemStress = %v, want %v", s.SystemStress, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestZeroMemoryTotal(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []parser.Memory
+		wantUsedPc float64
+		wantTotal  uint64
+	}{
+		{name: "zero total", samples: []parser.Memory{{}}, wantUsedPc: 0},
+		{name: "used without a total", samples: []parser.Memory{{Used: 512}}, wantUsedPc: 0},
+		{name: "zero total after a valid sample", samples: []parser.Memory{{Total: 100, Used: 50, Free: 50}, {}}, wantUsedPc: 0},
+		{name: "valid sample", samples: []parser.Memory{{Total: 100, Used: 50, Free: 50}}, wantUsedPc: 50, wantTotal: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			for _, memory := range tt.samples {
+				stats := &parser.SystemStats{Memory: memory}
+				s.Update(stats, nil, &stats.Temperature, "")
+			}
+
+			if s.Memory.UsedPc != tt.wantUsedPc || s.Memory.Total != tt.wantTotal {
+				t.Errorf("memory = %d total, %v%% used, want %d total, %v%% used", s.Memory.Total, s.Memory.UsedPc, tt.wantTotal, tt.wantUsedPc)
+			}
+
+			filename := filepath.Join(t.TempDir(), "latest.json")
+			if err := s.Save(filename); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved SystemSummary
+			if err := json.Unmarshal(data, &saved); err != nil {
+				t.Fatalf("saved summary isn't valid JSON: %v", err)
+			}
+			if saved.Memory.UsedPc != tt.wantUsedPc {
+				t.Errorf("saved memory used = %v%%, want %v%%", saved.Memory.UsedPc, tt.wantUsedPc)
+			}
+		})
+	}
+}
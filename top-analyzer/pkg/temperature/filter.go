@@ -0,0 +1,80 @@
+package temperature
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects the sensors that are tracked by name, using path.Match glob
+// patterns such as "pmic*" or "coretemp-*/Core *". A pattern matches a sensor
+// by its full name or, for lm-sensors "<chip>/<label>" names, by its chip. A
+// sensor is tracked when it matches an include pattern, or there are none, and
+// no exclude pattern.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseFilter builds a filter from comma separated include and exclude
+// patterns, rejecting malformed ones
+func ParseFilter(include, exclude string) (Filter, error) {
+	var f Filter
+	var err error
+	if f.Include, err = parsePatterns(include); err != nil {
+		return Filter{}, err
+	}
+	if f.Exclude, err = parsePatterns(exclude); err != nil {
+		return Filter{}, err
+	}
+	return f, nil
+}
+
+func parsePatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid sensor pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Tracks reports whether the sensor passes the filter
+func (f Filter) Tracks(name string) bool {
+	if matchAny(f.Exclude, name) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, name)
+}
+
+// matchAny reports whether a pattern matches the sensor name or its chip
+func matchAny(patterns []string, name string) bool {
+	chip, _, _ := strings.Cut(name, "/")
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, chip); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// apply drops the sensors the filter doesn't track
+func (t *TemperatureStats) apply(f Filter) {
+	for name := range t.Sensors {
+		if !f.Tracks(name) {
+			delete(t.Sensors, name)
+			delete(t.Sources, name)
+			delete(t.Thresholds, name)
+			delete(t.Rescaled, name)
+		}
+	}
+}
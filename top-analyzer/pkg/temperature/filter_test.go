@@ -0,0 +1,82 @@
+package temperature
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFilterApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		include     string
+		exclude     string
+		wantSensors []string
+		wantErr     bool
+	}{
+		{
+			name:        "no filter",
+			wantSensors: []string{"acpitz-acpi-0/temp1", "coretemp-isa-0000/Core 0", "coretemp-isa-0000/Package id 0", "nvme-pci-0100/Composite"},
+		},
+		{
+			name:        "chips excluded",
+			exclude:     "acpitz*, nvme*",
+			wantSensors: []string{"coretemp-isa-0000/Core 0", "coretemp-isa-0000/Package id 0"},
+		},
+		{
+			name:        "full names included",
+			include:     "coretemp-*/Package*,nvme-pci-0100/Composite",
+			wantSensors: []string{"coretemp-isa-0000/Package id 0", "nvme-pci-0100/Composite"},
+		},
+		{
+			name:        "exclude wins over include",
+			include:     "coretemp*",
+			exclude:     "coretemp-isa-0000/Core *",
+			wantSensors: []string{"coretemp-isa-0000/Package id 0"},
+		},
+		{name: "everything excluded", exclude: "*", wantSensors: []string{}},
+		{name: "malformed pattern", exclude: "coretemp[", wantErr: true},
+	}
+
+	data, err := os.ReadFile("testdata/sensors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter(tt.include, tt.exclude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			stats := &TemperatureStats{
+				Sensors:    make(map[string]float64),
+				Sources:    make(map[string]string),
+				Thresholds: make(map[string]float64),
+			}
+			if err := parseSensorsJSON(data, stats); err != nil {
+				t.Fatal(err)
+			}
+			stats.apply(filter)
+
+			names := make([]string, 0, len(stats.Sensors))
+			for name := range stats.Sensors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantSensors) {
+				t.Errorf("sensors = %q, want %q", names, tt.wantSensors)
+			}
+			for name := range stats.Thresholds {
+				if _, tracked := stats.Sensors[name]; !tracked {
+					t.Errorf("threshold of excluded sensor %s kept", name)
+				}
+			}
+		})
+	}
+}
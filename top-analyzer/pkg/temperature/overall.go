@@ -0,0 +1,57 @@
+package temperature
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Overall selects how the readings of several sensors are combined into the
+// overall temperature the stress scores are based on
+type Overall string
+
+const (
+	OverallMax     Overall = "max"     // The hottest reading of any sensor
+	OverallAverage Overall = "average" // The mean of each sensor's average, every sensor weighing the same
+)
+
+// ParseOverall validates an overall temperature name
+func ParseOverall(s string) (Overall, error) {
+	switch overall := Overall(s); overall {
+	case OverallMax, OverallAverage:
+		return overall, nil
+	}
+	return "", fmt.Errorf("unknown overall temperature %q (expected max or average)", s)
+}
+
+// Of combines the readings of each sensor over a window into the overall
+// temperature, 0 when there are none. Anything but OverallAverage is treated
+// as OverallMax.
+func (o Overall) Of(readings map[string][]float64) float64 {
+	values := make([]float64, 0, len(readings))
+	for _, temps := range readings {
+		if len(temps) == 0 {
+			continue
+		}
+		if o == OverallAverage {
+			values = append(values, mean(temps))
+		} else {
+			values = append(values, slices.Max(temps))
+		}
+	}
+
+	if len(values) == 0 {
+		return 0
+	}
+	if o == OverallAverage {
+		return mean(values)
+	}
+	return slices.Max(values)
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
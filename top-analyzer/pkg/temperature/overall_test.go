@@ -0,0 +1,54 @@
+package temperature
+
+import "testing"
+
+func TestOverallOf(t *testing.T) {
+	twoSensors := map[string][]float64{"cpu": {50, 70}, "board": {30, 30, 30, 30}}
+
+	tests := []struct {
+		name     string
+		overall  Overall
+		readings map[string][]float64
+		want     float64
+	}{
+		{name: "max of two sensors", overall: OverallMax, readings: twoSensors, want: 70},
+		// Each sensor weighs the same whatever its number of readings
+		{name: "average of two sensors", overall: OverallAverage, readings: twoSensors, want: 45},
+		{name: "unknown treated as max", overall: "median", readings: twoSensors, want: 70},
+		{name: "sensor without readings", overall: OverallAverage, readings: map[string][]float64{"cpu": {40, 60}, "gpu": {}}, want: 50},
+		{name: "no sensors", overall: OverallMax, readings: map[string][]float64{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.overall.Of(tt.readings); got != tt.want {
+				t.Errorf("%s.Of(%v) = %v, want %v", tt.overall, tt.readings, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOverall(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Overall
+		wantErr bool
+	}{
+		{input: "max", want: OverallMax},
+		{input: "average", want: OverallAverage},
+		{input: "mean", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOverall(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOverall(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOverall(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
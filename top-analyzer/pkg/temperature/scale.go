@@ -0,0 +1,45 @@
+package temperature
+
+import "math"
+
+// Readings are in degrees or millidegrees Celsius depending on the source:
+// sysfs reports millidegrees, /proc/acpi and lm-sensors degrees. Some drivers
+// don't follow the convention of their source, so the scale is checked
+// against the magnitude of the reading.
+const (
+	// millidegreesAbove is the magnitude above which a reading can only be
+	// in millidegrees, whatever its source
+	millidegreesAbove = 1000
+
+	// Range a millidegree source reading is taken as degrees in, since as
+	// millidegrees it would be within a fraction of a degree of 0°C
+	degreesMin = -50
+	degreesMax = 150
+)
+
+// toCelsius converts a raw reading to degrees Celsius, given whether its
+// source normally reports millidegrees. rescaled is true when the magnitude
+// of the reading contradicted its source and the other scale was used.
+func toCelsius(raw float64, millidegrees bool) (celsius float64, rescaled bool) {
+	switch {
+	case math.Abs(raw) > millidegreesAbove:
+		return raw / 1000, !millidegrees
+	case millidegrees && raw != 0 && raw >= degreesMin && raw <= degreesMax:
+		return raw, true
+	case millidegrees:
+		return raw / 1000, false
+	}
+	return raw, false
+}
+
+// addRaw records a raw sensor reading, converted to degrees Celsius
+func (t *TemperatureStats) addRaw(name string, raw float64, millidegrees bool, source string) {
+	temp, rescaled := toCelsius(raw, millidegrees)
+	t.add(name, temp, source)
+	if rescaled {
+		if t.Rescaled == nil {
+			t.Rescaled = make(map[string]bool)
+		}
+		t.Rescaled[name] = true
+	}
+}
@@ -0,0 +1,46 @@
+package temperature
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAddRawScale(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          float64
+		millidegrees bool
+		source       string
+		want         float64
+		wantRescaled bool
+	}{
+		{name: "procfs degrees", raw: 45, source: "/proc/acpi/thermal_zone/THM0/temperature", want: 45},
+		{name: "sysfs millidegrees", raw: 45000, millidegrees: true, source: "/sys/class/hwmon/hwmon0/temp1_input", want: 45},
+		{name: "procfs reporting millidegrees", raw: 45000, source: "/proc/acpi/thermal_zone/THM0/temperature", want: 45, wantRescaled: true},
+		{name: "sysfs reporting degrees", raw: 45, millidegrees: true, source: "/sys/class/hwmon/hwmon0/temp1_input", want: 45, wantRescaled: true},
+		{name: "sysfs below freezing", raw: -5000, millidegrees: true, source: "/sys/class/thermal/thermal_zone0/temp", want: -5},
+		{name: "sysfs zero", raw: 0, millidegrees: true, source: "/sys/class/thermal/thermal_zone0/temp", want: 0},
+		{name: "lm-sensors degrees", raw: 38.85, source: "sensors -j", want: 38.85},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &TemperatureStats{
+				Sensors:    make(map[string]float64),
+				Sources:    make(map[string]string),
+				Thresholds: make(map[string]float64),
+			}
+			stats.addRaw("cpu", tt.raw, tt.millidegrees, tt.source)
+
+			if got := stats.Sensors["cpu"]; math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("sensor = %v°C, want %v°C", got, tt.want)
+			}
+			if got := stats.Rescaled["cpu"]; got != tt.wantRescaled {
+				t.Errorf("rescaled = %v, want %v", got, tt.wantRescaled)
+			}
+			if got := stats.Sources["cpu"]; got != tt.source {
+				t.Errorf("source = %q, want %q", got, tt.source)
+			}
+		})
+	}
+}
@@ -1,42 +1,93 @@
 package temperature
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// sensorsTimeout bounds how long the sensors command may run
+const sensorsTimeout = 5 * time.Second
+
+// sysfsRoot is where the kernel exposes the hwmon and thermal classes
+const sysfsRoot = "/sys/class"
+
 type TemperatureStats struct {
-	Sensors map[string]float64 // sensor name -> temperature in Celsius
+	Sensors    map[string]float64 // sensor name -> temperature in Celsius
+	Sources    map[string]string  // sensor name -> path the reading came from
+	Thresholds map[string]float64 // sensor name -> critical trip point in Celsius, when exposed
+	Rescaled   map[string]bool    // sensor name -> reading wasn't in the scale its source normally reports, see toCelsius
+}
+
+// add records a sensor reading and the path it was read from
+func (t *TemperatureStats) add(name string, temp float64, source string) {
+	t.Sensors[name] = temp
+	t.Sources[name] = source
 }
 
+// Above returns the names of the sensors reading limit or more, in order
+func (t *TemperatureStats) Above(limit float64) []string {
+	var names []string
+	for name, temp := range t.Sensors {
+		if temp >= limit {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadTemperatureStats reads every temperature sensor found
 func ReadTemperatureStats() (*TemperatureStats, error) {
+	return ReadTemperatureStatsWith(Filter{})
+}
+
+// ReadTemperatureStatsWith reads the temperature sensors tracked by the
+// filter. A source whose sensors are all filtered out counts as empty, so the
+// next one is tried.
+func ReadTemperatureStatsWith(filter Filter) (*TemperatureStats, error) {
 	stats := &TemperatureStats{
-		Sensors: make(map[string]float64),
+		Sensors:    make(map[string]float64),
+		Sources:    make(map[string]string),
+		Thresholds: make(map[string]float64),
+	}
+	found := func(err error) bool {
+		stats.apply(filter)
+		return err == nil && len(stats.Sensors) > 0
 	}
 
 	// Try multiple temperature source paths
-	// First try standard hwmon
-	if err := readFromHwmon(stats); err == nil && len(stats.Sensors) > 0 {
+	// First try lm-sensors, which knows about chips the raw readers miss
+	if found(readFromLmSensors(stats)) {
+		return stats, nil
+	}
+
+	// Then try standard hwmon
+	if found(readFromHwmon(sysfsRoot, stats)) {
 		return stats, nil
 	}
 
 	// Then try thermal_zone (common on ARM devices)
-	if err := readFromThermalZone(stats); err == nil && len(stats.Sensors) > 0 {
+	if found(readFromThermalZone(sysfsRoot, stats)) {
 		return stats, nil
 	}
 
 	// Fallback to procfs if available
-	if err := readFromProcTemperature(stats); err == nil && len(stats.Sensors) > 0 {
+	if found(readFromProcTemperature(stats)) {
 		return stats, nil
 	}
 
 	// Last resort: manually check known device-specific files
 	// This is very device specific but can help on certain ARM boards
-	if err := readFromDeviceSpecific(stats); err == nil && len(stats.Sensors) > 0 {
+	if found(readFromDeviceSpecific(stats)) {
 		return stats, nil
 	}
 
@@ -47,17 +98,70 @@ func ReadTemperatureStats() (*TemperatureStats, error) {
 
 	// No sensors found, create mock values for testing
 	if os.Getenv("MOCK_TEMP") == "1" {
-		stats.Sensors["cpu"] = 45.5
-		stats.Sensors["board"] = 38.2
+		stats.add("cpu", 45.5, "MOCK_TEMP")
+		stats.add("board", 38.2, "MOCK_TEMP")
 		return stats, nil
 	}
 
 	return stats, fmt.Errorf("no temperature sensors found")
 }
 
-func readFromHwmon(stats *TemperatureStats) error {
+// readFromLmSensors reads the temperatures reported by the sensors command of
+// lm-sensors, when it is installed
+func readFromLmSensors(stats *TemperatureStats) error {
+	sensorsPath, err := exec.LookPath("sensors")
+	if err != nil {
+		return fmt.Errorf("sensors command not available: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sensorsTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, sensorsPath, "-j").Output()
+	if err != nil {
+		return fmt.Errorf("failed to execute sensors command: %w", err)
+	}
+
+	return parseSensorsJSON(output, stats)
+}
+
+// parseSensorsJSON parses the output of sensors -j. Every temperature input is
+// recorded as "<chip>/<label>", along with its critical limit when reported.
+func parseSensorsJSON(data []byte, stats *TemperatureStats) error {
+	var chips map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &chips); err != nil {
+		return fmt.Errorf("failed to parse sensors output: %w", err)
+	}
+
+	for chip, features := range chips {
+		for label, raw := range features {
+			// Skip the "Adapter" string and anything else not a feature
+			var subfeatures map[string]float64
+			if err := json.Unmarshal(raw, &subfeatures); err != nil {
+				continue
+			}
+
+			for key, value := range subfeatures {
+				if !strings.HasPrefix(key, "temp") || !strings.HasSuffix(key, "_input") {
+					continue
+				}
+				name := chip + "/" + label
+				stats.addRaw(name, value, false, "sensors -j")
+
+				crit := strings.TrimSuffix(key, "_input") + "_crit"
+				if trip, ok := subfeatures[crit]; ok && trip > 0 {
+					stats.Thresholds[name], _ = toCelsius(trip, false)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFromHwmon reads the hwmon devices under root, laid out like /sys/class
+func readFromHwmon(root string, stats *TemperatureStats) error {
 	// Read all hwmon devices
-	hwmonDirs, err := filepath.Glob("/sys/class/hwmon/hwmon*")
+	hwmonDirs, err := filepath.Glob(filepath.Join(root, "hwmon", "hwmon*"))
 	if err != nil {
 		return fmt.Errorf("failed to find hwmon devices: %w", err)
 	}
@@ -87,17 +191,19 @@ func readFromHwmon(stats *TemperatureStats) error {
 				continue
 			}
 
-			// Convert millidegree Celsius to Celsius
-			stats.Sensors[name] = temp / 1000.0
+			// Normally in millidegree Celsius
+			stats.addRaw(name, temp, true, tempFile)
 		}
 	}
 
 	return nil
 }
 
-func readFromThermalZone(stats *TemperatureStats) error {
+// readFromThermalZone reads the thermal zones under root, laid out like
+// /sys/class
+func readFromThermalZone(root string, stats *TemperatureStats) error {
 	// Try thermal_zone directories
-	thermalDirs, err := filepath.Glob("/sys/class/thermal/thermal_zone*")
+	thermalDirs, err := filepath.Glob(filepath.Join(root, "thermal", "thermal_zone*"))
 	if err != nil {
 		return fmt.Errorf("failed to find thermal zones: %w", err)
 	}
@@ -121,13 +227,50 @@ func readFromThermalZone(stats *TemperatureStats) error {
 			continue
 		}
 
-		// Convert millidegree Celsius to Celsius
-		stats.Sensors[zoneType] = temp / 1000.0
+		// Normally in millidegree Celsius
+		stats.addRaw(zoneType, temp, true, filepath.Join(dir, "temp"))
+
+		if trip, ok := readCriticalTripPoint(dir); ok {
+			stats.Thresholds[zoneType] = trip
+		}
 	}
 
 	return nil
 }
 
+// readCriticalTripPoint returns the critical trip point of a thermal zone in
+// Celsius, if the zone exposes one
+func readCriticalTripPoint(zoneDir string) (float64, bool) {
+	typeFiles, err := filepath.Glob(filepath.Join(zoneDir, "trip_point_*_type"))
+	if err != nil {
+		return 0, false
+	}
+
+	for _, typeFile := range typeFiles {
+		typeBytes, err := ioutil.ReadFile(typeFile)
+		if err != nil || strings.TrimSpace(string(typeBytes)) != "critical" {
+			continue
+		}
+
+		tempFile := strings.TrimSuffix(typeFile, "_type") + "_temp"
+		tempBytes, err := ioutil.ReadFile(tempFile)
+		if err != nil {
+			continue
+		}
+
+		temp, err := strconv.ParseFloat(strings.TrimSpace(string(tempBytes)), 64)
+		if err != nil || temp <= 0 {
+			continue
+		}
+
+		// Normally in millidegree Celsius
+		celsius, _ := toCelsius(temp, true)
+		return celsius, true
+	}
+
+	return 0, false
+}
+
 func readFromProcTemperature(stats *TemperatureStats) error {
 	// Try to read from /proc/acpi/thermal_zone if it exists
 	files, err := filepath.Glob("/proc/acpi/thermal_zone/*/temperature")
@@ -155,7 +298,8 @@ func readFromProcTemperature(stats *TemperatureStats) error {
 			continue
 		}
 
-		stats.Sensors[zoneName] = temp
+		// Normally in degrees Celsius
+		stats.addRaw(zoneName, temp, false, file)
 	}
 
 	return nil
@@ -169,7 +313,7 @@ func readFromDeviceSpecific(stats *TemperatureStats) error {
 		if err == nil {
 			temp, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
 			if err == nil {
-				stats.Sensors["rpi_cpu"] = temp / 1000.0
+				stats.addRaw("rpi_cpu", temp, true, piTempFile)
 			}
 		}
 	}
@@ -186,7 +330,7 @@ func readFromDeviceSpecific(stats *TemperatureStats) error {
 			if err == nil {
 				temp, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
 				if err == nil {
-					stats.Sensors["beaglebone"] = temp / 1000.0
+					stats.addRaw("beaglebone", temp, true, file)
 				}
 			}
 		}
@@ -0,0 +1,225 @@
+package temperature
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeTree writes files, keyed by path relative to root, under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSysfsSensors(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		read        func(root string, stats *TemperatureStats) error
+		wantSensors map[string]float64
+		wantSources map[string]string // relative to the fixture root
+	}{
+		{
+			name: "hwmon devices",
+			files: map[string]string{
+				"hwmon/hwmon0/name":        "cpu_thermal\n",
+				"hwmon/hwmon0/temp1_input": "45000\n",
+				"hwmon/hwmon1/name":        "nvme\n",
+				"hwmon/hwmon1/temp1_input": "38500\n",
+			},
+			read:        readFromHwmon,
+			wantSensors: map[string]float64{"cpu_thermal": 45, "nvme": 38.5},
+			wantSources: map[string]string{"cpu_thermal": "hwmon/hwmon0/temp1_input", "nvme": "hwmon/hwmon1/temp1_input"},
+		},
+		{
+			name: "hwmon device without a name",
+			files: map[string]string{
+				"hwmon/hwmon0/temp1_input": "45000\n",
+				"hwmon/hwmon1/name":        "nvme\n",
+				"hwmon/hwmon1/temp1_input": "38500\n",
+			},
+			read:        readFromHwmon,
+			wantSensors: map[string]float64{"nvme": 38.5},
+			wantSources: map[string]string{"nvme": "hwmon/hwmon1/temp1_input"},
+		},
+		{
+			name: "thermal zones",
+			files: map[string]string{
+				"thermal/thermal_zone0/type": "x86_pkg_temp\n",
+				"thermal/thermal_zone0/temp": "52000\n",
+				"thermal/thermal_zone1/type": "acpitz\n",
+				"thermal/thermal_zone1/temp": "27800\n",
+			},
+			read:        readFromThermalZone,
+			wantSensors: map[string]float64{"x86_pkg_temp": 52, "acpitz": 27.8},
+			wantSources: map[string]string{"x86_pkg_temp": "thermal/thermal_zone0/temp", "acpitz": "thermal/thermal_zone1/temp"},
+		},
+		{
+			name:        "empty tree",
+			read:        readFromThermalZone,
+			wantSensors: map[string]float64{},
+			wantSources: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+
+			stats := &TemperatureStats{
+				Sensors:    make(map[string]float64),
+				Sources:    make(map[string]string),
+				Thresholds: make(map[string]float64),
+			}
+			if err := tt.read(root, stats); err != nil {
+				t.Fatalf("read error = %v", err)
+			}
+
+			names := make([]string, 0, len(stats.Sensors))
+			for name := range stats.Sensors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			wantNames := make([]string, 0, len(tt.wantSensors))
+			for name := range tt.wantSensors {
+				wantNames = append(wantNames, name)
+			}
+			sort.Strings(wantNames)
+			if !reflect.DeepEqual(names, wantNames) {
+				t.Fatalf("sensor names = %v, want %v", names, wantNames)
+			}
+
+			for name, want := range tt.wantSensors {
+				if got := stats.Sensors[name]; got != want {
+					t.Errorf("sensor %s = %v, want %v", name, got, want)
+				}
+				if got, want := stats.Sources[name], filepath.Join(root, tt.wantSources[name]); got != want {
+					t.Errorf("sensor %s source = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestThermalZoneTripPoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		trips         map[string]string // trip point file -> content
+		wantThreshold float64
+		wantOK        bool
+	}{
+		{
+			name: "critical trip point",
+			trips: map[string]string{
+				"trip_point_0_type": "passive\n", "trip_point_0_temp": "80000\n",
+				"trip_point_1_type": "critical\n", "trip_point_1_temp": "95000\n",
+			},
+			wantThreshold: 95,
+			wantOK:        true,
+		},
+		{
+			name:  "no critical trip point",
+			trips: map[string]string{"trip_point_0_type": "passive\n", "trip_point_0_temp": "80000\n"},
+		},
+		{
+			name:  "critical trip point disabled",
+			trips: map[string]string{"trip_point_0_type": "critical\n", "trip_point_0_temp": "0\n"},
+		},
+		{name: "no trip points"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			files := map[string]string{
+				"thermal/thermal_zone0/type": "cpu-thermal\n",
+				"thermal/thermal_zone0/temp": "52000\n",
+			}
+			for name, content := range tt.trips {
+				files[filepath.Join("thermal/thermal_zone0", name)] = content
+			}
+			writeTree(t, root, files)
+
+			stats := &TemperatureStats{
+				Sensors:    make(map[string]float64),
+				Sources:    make(map[string]string),
+				Thresholds: make(map[string]float64),
+			}
+			if err := readFromThermalZone(root, stats); err != nil {
+				t.Fatalf("readFromThermalZone() error = %v", err)
+			}
+
+			threshold, ok := stats.Thresholds["cpu-thermal"]
+			if ok != tt.wantOK || threshold != tt.wantThreshold {
+				t.Errorf("threshold = (%v, %v), want (%v, %v)", threshold, ok, tt.wantThreshold, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseSensorsJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		fixture        string
+		wantSensors    map[string]float64
+		wantThresholds map[string]float64
+		wantErr        bool
+	}{
+		{
+			name:    "desktop chips",
+			fixture: "testdata/sensors.json",
+			wantSensors: map[string]float64{
+				"coretemp-isa-0000/Package id 0": 52,
+				"coretemp-isa-0000/Core 0":       49,
+				"nvme-pci-0100/Composite":        38.85,
+				"acpitz-acpi-0/temp1":            27.8,
+			},
+			wantThresholds: map[string]float64{
+				"coretemp-isa-0000/Package id 0": 100,
+				"coretemp-isa-0000/Core 0":       100,
+				"nvme-pci-0100/Composite":        84.85,
+			},
+		},
+		{name: "truncated output", fixture: "testdata/sensors_truncated.json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stats := &TemperatureStats{
+				Sensors:    make(map[string]float64),
+				Sources:    make(map[string]string),
+				Thresholds: make(map[string]float64),
+			}
+			err = parseSensorsJSON(data, stats)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSensorsJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(stats.Sensors, tt.wantSensors) {
+				t.Errorf("Sensors = %v, want %v", stats.Sensors, tt.wantSensors)
+			}
+			if !reflect.DeepEqual(stats.Thresholds, tt.wantThresholds) {
+				t.Errorf("Thresholds = %v, want %v", stats.Thresholds, tt.wantThresholds)
+			}
+		})
+	}
+}
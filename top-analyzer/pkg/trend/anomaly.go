@@ -0,0 +1,64 @@
+package trend
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AnomalyMethod selects how the latest value of a metric is judged anomalous
+type AnomalyMethod string
+
+const (
+	AnomalyZScore     AnomalyMethod = "zscore"     // Too many standard deviations away from the mean
+	AnomalyPercentile AnomalyMethod = "percentile" // Above a percentile of the preceding values, robust to skew
+)
+
+// DefaultAnomalyPercentile is the percentile used by AnomalyPercentile unless
+// configured otherwise
+const DefaultAnomalyPercentile = 95.0
+
+// minPercentileSamples is the number of preceding values needed before a
+// percentile is meaningful
+const minPercentileSamples = 5
+
+// ParseAnomalyMethod validates an anomaly method name
+func ParseAnomalyMethod(s string) (AnomalyMethod, error) {
+	switch method := AnomalyMethod(s); method {
+	case AnomalyZScore, AnomalyPercentile:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown anomaly method %q (expected zscore or percentile)", s)
+}
+
+// detectPercentileAnomaly checks if the latest value is above the p-th
+// percentile of the values before it, returning that percentile
+func detectPercentileAnomaly(values []float64, p float64) (float64, bool) {
+	if len(values) <= minPercentileSamples {
+		return 0, false
+	}
+
+	limit := percentile(values[:len(values)-1], p)
+	return limit, values[len(values)-1] > limit
+}
+
+// percentile returns the p-th percentile of values, interpolating linearly
+// between the closest ranks
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	if rank <= 0 {
+		return sorted[0]
+	}
+	if rank >= float64(len(sorted)-1) {
+		return sorted[len(sorted)-1]
+	}
+	lower := int(rank)
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,86 @@
+package trend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestAnomalyMethod(t *testing.T) {
+	// A single spike inflates the standard deviation of a skewed series,
+	// masking a later outlier from the z-score
+	skewed := make([]float64, 0, 20)
+	for i := 0; i < 18; i++ {
+		skewed = append(skewed, 1)
+	}
+	skewed = append(skewed, 50, 10)
+	steady := []float64{10, 11, 9, 10, 12, 10, 11, 9, 10, 10}
+
+	tests := []struct {
+		name        string
+		method      AnomalyMethod
+		series      []float64
+		wantReasons []string
+	}{
+		{name: "skewed series under zscore", method: AnomalyZScore, series: skewed, wantReasons: nil},
+		{name: "skewed series under percentile", method: AnomalyPercentile, series: skewed, wantReasons: []string{"value 10.0 exceeded p95 5.9"}},
+		{name: "steady series under zscore", method: AnomalyZScore, series: steady, wantReasons: nil},
+		{name: "steady series under percentile", method: AnomalyPercentile, series: steady, wantReasons: nil},
+		{name: "too few values for a percentile", method: AnomalyPercentile, series: []float64{1, 1, 1, 1, 50}, wantReasons: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A trend threshold out of reach leaves the method as the only cause
+			analyzer := NewWithFullOptions(len(tt.series), 2, 1000, 70, len(tt.series))
+			analyzer.SetAnomalyMethod(tt.method, DefaultAnomalyPercentile)
+			for _, value := range tt.series {
+				// The same series as CPU usage, a sensor and used space
+				stats := cpuSample(value)
+				stats.Temperature.Sensors = map[string]float64{"cpu": value}
+				stats.Filesystem = map[string]parser.FilesystemStats{
+					"/data": {Size: 10 << 30, UsedPct: value, MountPoint: "/data"},
+				}
+				analyzer.AddStats(stats)
+			}
+
+			trend := analyzer.Analyze()
+			if got := trend.CPUUsage.Reasons; !reflect.DeepEqual(got, tt.wantReasons) {
+				t.Errorf("CPU reasons = %q, want %q", got, tt.wantReasons)
+			}
+			wantAnomaly := len(tt.wantReasons) > 0
+			if got := trend.Temperature.Sensors["cpu"].Anomaly; got != wantAnomaly {
+				t.Errorf("sensor anomaly = %v, want %v", got, wantAnomaly)
+			}
+			if got := trend.Filesystem.Partitions["/data"].Anomaly; got != wantAnomaly {
+				t.Errorf("partition anomaly = %v, want %v", got, wantAnomaly)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{name: "median of an odd count", values: []float64{3, 1, 2}, p: 50, want: 2},
+		{name: "interpolated between ranks", values: []float64{1, 2, 3, 4}, p: 50, want: 2.5},
+		{name: "95th percentile", values: []float64{10, 20, 30, 40, 50}, p: 95, want: 48},
+		{name: "zeroth percentile", values: []float64{5, 3, 9}, p: 0, want: 3},
+		{name: "hundredth percentile", values: []float64{5, 3, 9}, p: 100, want: 9},
+		{name: "single value", values: []float64{7}, p: 95, want: 7},
+		{name: "no values", p: 95, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %g) = %v, want %v", tt.values, tt.p, got, tt.want)
+			}
+		})
+	}
+}
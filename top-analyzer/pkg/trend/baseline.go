@@ -0,0 +1,170 @@
+package trend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// Metric names used as keys for the long-term baseline
+const (
+	metricCPU         = "cpu"
+	metricMemory      = "memory"
+	metricProcesses   = "processes"
+	metricLoad        = "load"
+	metricTemperature = "temperature"
+)
+
+// baselineAlpha is the EWMA smoothing factor of the long-term baseline.
+// A small value makes the baseline follow the "new normal" slowly.
+const baselineAlpha = 0.05
+
+// analyzerState is the part of the analyzer persisted across restarts
+type analyzerState struct {
+	BaselineMean    map[string]float64
+	BaselineVar     map[string]float64
+	BaselineSamples int
+}
+
+// SetPersistentBaseline makes anomaly detection score the latest value against
+// the long-term EWMA baseline instead of the short history window, once the
+// baseline has seen at least a full window of samples.
+func (t *TrendAnalyzer) SetPersistentBaseline(enabled bool) {
+	t.persistentBaseline = enabled
+}
+
+// GetBaseline returns the long-term baseline mean and variance of a metric
+func (t *TrendAnalyzer) GetBaseline(metric string) (mean, variance float64) {
+	return t.baselineMean[metric], t.baselineVar[metric]
+}
+
+// flushBaseline adds the pending sample to the baseline, once it was scored
+func (t *TrendAnalyzer) flushBaseline() {
+	if t.baselinePending == nil {
+		return
+	}
+	t.updateBaseline(t.baselinePending)
+	t.baselinePending = nil
+}
+
+func (t *TrendAnalyzer) updateBaseline(stats *parser.SystemStats) {
+	values := make(map[string]float64)
+	if stats.Has(parser.SectionCPU) {
+		values[metricCPU] = stats.CPU.User + stats.CPU.Sys
+	}
+	if stats.Has(parser.SectionProcesses) {
+		values[metricProcesses] = float64(stats.ProcessCount())
+	}
+	if stats.Has(parser.SectionLoad) {
+		values[metricLoad] = stats.LoadAverage.One
+	}
+	if stats.Has(parser.SectionMemory) && stats.Memory.Total > 0 {
+		values[metricMemory] = float64(stats.Memory.Used) / float64(stats.Memory.Total) * 100
+	}
+	if len(stats.Temperature.Sensors) > 0 {
+		sum := 0.0
+		for _, temp := range stats.Temperature.Sensors {
+			sum += temp
+		}
+		values[metricTemperature] = sum / float64(len(stats.Temperature.Sensors))
+	}
+
+	for metric, value := range values {
+		mean, exists := t.baselineMean[metric]
+		if !exists {
+			t.baselineMean[metric] = value
+			t.baselineVar[metric] = 0
+			continue
+		}
+
+		// Exponentially weighted mean and variance
+		diff := value - mean
+		incr := baselineAlpha * diff
+		t.baselineMean[metric] = mean + incr
+		t.baselineVar[metric] = (1 - baselineAlpha) * (t.baselineVar[metric] + diff*incr)
+	}
+	t.baselineSamples++
+}
+
+// anomalyBaseline returns the mean and standard deviation the latest value of
+// a metric is scored against. This is the window statistics unless the
+// persistent baseline is enabled and established.
+func (t *TrendAnalyzer) anomalyBaseline(metric string, mean, stdDev float64) (float64, float64) {
+	if !t.persistentBaseline || t.baselineSamples < t.window {
+		return mean, stdDev
+	}
+	baselineMean, exists := t.baselineMean[metric]
+	if !exists {
+		return mean, stdDev
+	}
+	return baselineMean, sqrt(t.baselineVar[metric])
+}
+
+// SaveState atomically replaces filename with the long-term baseline so it
+// survives restarts, and a crash while writing never leaves a truncated state
+func (t *TrendAnalyzer) SaveState(filename string) error {
+	t.flushBaseline()
+	state := analyzerState{
+		BaselineMean:    t.baselineMean,
+		BaselineVar:     t.baselineVar,
+		BaselineSamples: t.baselineSamples,
+	}
+
+	jsonData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(jsonData); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// LoadState restores the long-term baseline previously written by SaveState
+func (t *TrendAnalyzer) LoadState(filename string) error {
+	jsonData, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state analyzerState
+	if err := json.Unmarshal(jsonData, &state); err != nil {
+		return fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	if state.BaselineMean != nil {
+		t.baselineMean = state.BaselineMean
+	}
+	if state.BaselineVar != nil {
+		t.baselineVar = state.BaselineVar
+	}
+	t.baselineSamples = state.BaselineSamples
+
+	return nil
+}
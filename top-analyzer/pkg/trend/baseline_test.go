@@ -0,0 +1,129 @@
+package trend
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// cpuSample returns stats whose only section is a CPU usage of user percent
+func cpuSample(user float64) *parser.SystemStats {
+	return &parser.SystemStats{
+		CPU:            parser.CPU{User: user},
+		ParsedSections: map[parser.Section]bool{parser.SectionCPU: true},
+	}
+}
+
+func TestBaselineConverges(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  float64
+		steady   float64
+		samples  int
+		wantMean float64
+		maxVar   float64
+	}{
+		{name: "steady from the start", initial: 20, steady: 20, samples: 10, wantMean: 20, maxVar: 0},
+		{name: "new normal above", initial: 10, steady: 60, samples: 300, wantMean: 60, maxVar: 0.5},
+		{name: "new normal below", initial: 90, steady: 30, samples: 300, wantMean: 30, maxVar: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.AddStats(cpuSample(tt.initial))
+			analyzer.Analyze()
+			for i := 0; i < tt.samples; i++ {
+				analyzer.AddStats(cpuSample(tt.steady))
+				analyzer.Analyze()
+			}
+
+			mean, variance := analyzer.GetBaseline(metricCPU)
+			if math.Abs(mean-tt.wantMean) > 0.01 {
+				t.Errorf("baseline mean = %v, want %v", mean, tt.wantMean)
+			}
+			if variance > tt.maxVar {
+				t.Errorf("baseline variance = %v, want at most %v", variance, tt.maxVar)
+			}
+		})
+	}
+}
+
+func TestBaselineRestoredFromState(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     []float64
+		analyzeLast bool
+	}{
+		{name: "single sample", samples: []float64{40}, analyzeLast: true},
+		{name: "varying samples", samples: []float64{10, 30, 20, 50, 40}, analyzeLast: true},
+		{name: "last sample not analyzed yet", samples: []float64{10, 30, 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := New(10)
+			for i, value := range tt.samples {
+				saved.AddStats(cpuSample(value))
+				if tt.analyzeLast || i < len(tt.samples)-1 {
+					saved.Analyze()
+				}
+			}
+			filename := filepath.Join(t.TempDir(), "state.json")
+			if err := saved.SaveState(filename); err != nil {
+				t.Fatalf("SaveState() error = %v", err)
+			}
+
+			restored := New(10)
+			if err := restored.LoadState(filename); err != nil {
+				t.Fatalf("LoadState() error = %v", err)
+			}
+			wantMean, wantVar := saved.GetBaseline(metricCPU)
+			gotMean, gotVar := restored.GetBaseline(metricCPU)
+			if gotMean != wantMean || gotVar != wantVar {
+				t.Errorf("restored baseline = (%v, %v), want (%v, %v)", gotMean, gotVar, wantMean, wantVar)
+			}
+			if restored.baselineSamples != len(tt.samples) {
+				t.Errorf("restored baseline samples = %d, want %d", restored.baselineSamples, len(tt.samples))
+			}
+		})
+	}
+}
+
+func TestSnapshotKeepsBaseline(t *testing.T) {
+	tests := []struct {
+		name      string
+		snapshots int
+	}{
+		{name: "one snapshot", snapshots: 1},
+		{name: "repeated snapshots", snapshots: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			for _, value := range []float64{10, 30, 50} {
+				analyzer.AddStats(cpuSample(value))
+			}
+			wantMean, wantVar := analyzer.GetBaseline(metricCPU)
+			wantSamples := analyzer.baselineSamples
+
+			for i := 0; i < tt.snapshots; i++ {
+				filename := filepath.Join(t.TempDir(), "crash.json")
+				if err := analyzer.SaveSnapshot(filename); err != nil {
+					t.Fatalf("SaveSnapshot() error = %v", err)
+				}
+			}
+
+			gotMean, gotVar := analyzer.GetBaseline(metricCPU)
+			if gotMean != wantMean || gotVar != wantVar {
+				t.Errorf("baseline after snapshot = (%v, %v), want (%v, %v)", gotMean, gotVar, wantMean, wantVar)
+			}
+			if analyzer.baselineSamples != wantSamples {
+				t.Errorf("baseline samples after snapshot = %d, want %d", analyzer.baselineSamples, wantSamples)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package trend
+
+// Flatten returns the trend as flat key/value pairs such as cpu.mean,
+// temp.<sensor>.max and fs.<mount>.free. Flags are reported as 0 or 1.
+func (t *Trend) Flatten() map[string]float64 {
+	flat := map[string]float64{
+		"cpu.mean":          t.CPUUsage.Mean,
+		"cpu.stddev":        t.CPUUsage.StdDev,
+		"cpu.trend":         t.CPUUsage.Trend,
+		"cpu.anomaly":       boolValue(t.CPUUsage.Anomaly),
+		"memory.mean":       t.MemoryUsage.Mean,
+		"memory.stddev":     t.MemoryUsage.StdDev,
+		"memory.trend":      t.MemoryUsage.Trend,
+		"memory.anomaly":    boolValue(t.MemoryUsage.Anomaly),
+		"memory.low_free":   boolValue(t.MemoryUsage.LowFree),
+		"swap.mean":         t.SwapUsage.Mean,
+		"swap.trend":        t.SwapUsage.Trend,
+		"swap.thrashing":    boolValue(t.SwapUsage.Thrashing),
+		"processes.mean":    t.ProcessCount.Mean,
+		"processes.stddev":  t.ProcessCount.StdDev,
+		"processes.trend":   t.ProcessCount.Trend,
+		"processes.anomaly": boolValue(t.ProcessCount.Anomaly),
+		"load.mean":         t.LoadAverage.Mean,
+		"load.stddev":       t.LoadAverage.StdDev,
+		"load.trend":        t.LoadAverage.Trend,
+		"load.anomaly":      boolValue(t.LoadAverage.Anomaly),
+		"temp.mean":         t.Temperature.Mean,
+		"temp.max":          t.Temperature.Max,
+		"temp.overall":      t.Temperature.Overall,
+		"temp.min":          t.Temperature.Min,
+		"temp.trend":        t.Temperature.Trend,
+		"temp.anomaly":      boolValue(t.Temperature.Anomaly),
+		"stress":            t.SystemStress,
+	}
+
+	for name, sensor := range t.Temperature.Sensors {
+		prefix := "temp." + name + "."
+		flat[prefix+"mean"] = sensor.Mean
+		flat[prefix+"max"] = sensor.Max
+		flat[prefix+"min"] = sensor.Min
+		flat[prefix+"trend"] = sensor.Trend
+		flat[prefix+"threshold"] = sensor.AbsoluteThreshold
+		flat[prefix+"anomaly"] = boolValue(sensor.Anomaly)
+	}
+
+	for mount, fs := range t.Filesystem.Partitions {
+		prefix := "fs." + mount + "."
+		flat[prefix+"free"] = fs.Current
+		flat[prefix+"mean"] = fs.Mean
+		flat[prefix+"trend"] = fs.Trend
+		flat[prefix+"anomaly"] = boolValue(fs.Anomaly)
+		flat[prefix+"critical"] = boolValue(fs.Critical)
+		flat[prefix+"hours_to_full"] = fs.TimeToFull.Hours()
+	}
+
+	return flat
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package trend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestFlatten(t *testing.T) {
+	analyzer := New(10)
+	for i, cpu := range []float64{20, 30, 40} {
+		stats := loadSample(cpu, 1)
+		stats.Temperature.Sensors = map[string]float64{"cpu": 50 + 5*float64(i)}
+		stats.Filesystem = map[string]parser.FilesystemStats{
+			"/": {Device: "/dev/sda1", Size: 50 << 30, UsedPct: 60, MountPoint: "/"},
+		}
+		analyzer.AddStats(stats)
+	}
+	flat := analyzer.Analyze().Flatten()
+
+	tests := []struct {
+		key  string
+		want float64
+	}{
+		{key: "cpu.mean", want: 30},
+		{key: "cpu.anomaly", want: 1}, // Rising 2%/s
+		{key: "load.mean", want: 1},
+		{key: "load.trend", want: 0},
+		{key: "temp.cpu.max", want: 60},
+		{key: "temp.cpu.min", want: 50},
+		{key: "temp.cpu.mean", want: 55},
+		{key: "fs./.free", want: 40},
+		{key: "fs./.critical", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := flat[tt.key]
+			if !ok {
+				t.Fatalf("Flatten() has no %s", tt.key)
+			}
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("Flatten()[%s] = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if got := flat["cpu.trend"]; got <= 0 {
+		t.Errorf("Flatten()[cpu.trend] = %v, want a rising trend", got)
+	}
+	if _, ok := flat["temp.gpu.max"]; ok {
+		t.Errorf("Flatten() has temp.gpu.max, want only the sensors seen")
+	}
+}
@@ -0,0 +1,149 @@
+package trend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/bytesize"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+)
+
+// Problem is a condition of a trend that requires a crash dump and a
+// notification
+type Problem struct {
+	Critical bool // Warning otherwise
+	Source   string
+	Message  string
+}
+
+// IsHealthy reports whether the trend has none of the conditions listed by
+// Problems
+func (t *Trend) IsHealthy() bool {
+	return len(t.ProblemDetails()) == 0
+}
+
+// Problems lists the messages of the conditions of the trend that require a
+// crash dump and a notification
+func (t *Trend) Problems() []string {
+	var messages []string
+	for _, problem := range t.ProblemDetails() {
+		messages = append(messages, problem.Message)
+	}
+	return messages
+}
+
+// ProblemDetails lists the conditions of the trend that require a crash dump
+// and a notification, with their severity and source
+func (t *Trend) ProblemDetails() []Problem {
+	var problems []Problem
+	add := func(critical bool, source, format string, args ...any) {
+		problems = append(problems, Problem{Critical: critical, Source: source, Message: fmt.Sprintf(format, args...)})
+	}
+
+	stressThreshold := t.stressThreshold
+	if stressThreshold == 0 {
+		stressThreshold = DefaultStressThreshold
+	}
+	if t.SystemStress >= stressThreshold {
+		add(true, "stress", "High system stress: %.1f%%", t.SystemStress)
+	}
+	if t.CPUUsage.Anomaly {
+		add(false, "cpu", "CPU anomaly detected: %s", strings.Join(t.CPUUsage.Reasons, "; "))
+	}
+	if t.MemoryUsage.Anomaly {
+		add(false, "memory", "Memory anomaly detected: %s", strings.Join(t.MemoryUsage.Reasons, "; "))
+	}
+	if t.MemoryUsage.LowFree {
+		add(true, "memory", "Low free memory: %s (%.1f%%)", bytesize.Format(t.MemoryUsage.Free, bytesize.Mega), t.MemoryUsage.FreePercent)
+	}
+	if t.MemoryUsage.CacheCollapse {
+		add(false, "memory", "Memory pressure: buff/cache collapsed while used memory climbed")
+	}
+	if t.SwapUsage.Thrashing {
+		add(true, "swap", "Swap thrashing detected: %s", strings.Join(t.SwapUsage.Reasons, "; "))
+	}
+	if t.Temperature.Anomaly {
+		add(false, "temperature", "Temperature anomaly detected: %s", strings.Join(t.Temperature.Reasons, "; "))
+	}
+	sensors := make([]string, 0, len(t.Temperature.Sensors))
+	for name := range t.Temperature.Sensors {
+		sensors = append(sensors, name)
+	}
+	sort.Strings(sensors)
+	for _, name := range sensors {
+		if sensor := t.Temperature.Sensors[name]; sensor.ThresholdExceeded {
+			add(true, "temperature", "Temperature threshold exceeded: %s %.1f°C (threshold: %.1f°C)", name, sensor.Max, sensor.AbsoluteThreshold)
+		}
+	}
+	if t.ProcessCount.Anomaly {
+		add(false, "processes", "Process count anomaly detected: %s", strings.Join(t.ProcessCount.Reasons, "; "))
+	}
+	if t.LoadAverage.Anomaly {
+		add(false, "load", "Load average anomaly detected: %s", strings.Join(t.LoadAverage.Reasons, "; "))
+	}
+
+	for _, proc := range t.StuckProcesses {
+		add(false, "processes", "Process %s (PID: %d) stuck in uninterruptible sleep for %d samples", proc.Command, proc.PID, proc.Samples)
+	}
+	problems = append(problems, t.ExitedProblems()...)
+	for _, pattern := range t.MissingWatched {
+		add(true, "watch", "Watched process %q is not running", pattern)
+	}
+	for _, proc := range t.Unexpected {
+		add(false, "processes", "Unexpected process %s (PID: %d) using %.1f%% CPU", proc.Command, proc.PID, proc.CPUPercent)
+	}
+	for _, sensor := range t.LostSensors {
+		add(false, "temperature", "Sensor %s lost: missing for %d samples", sensor.Name, sensor.Samples)
+	}
+
+	fsThresholds := t.fsThresholds
+	if fsThresholds.FullWarning == 0 && fsThresholds.FullCritical == 0 {
+		fsThresholds = filesystem.DefaultThresholds
+	}
+	mounts := make([]string, 0, len(t.Filesystem.Partitions))
+	for mount := range t.Filesystem.Partitions {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+	partitionCritical := false
+	for _, mount := range mounts {
+		fs := t.Filesystem.Partitions[mount]
+		if fs.Critical {
+			partitionCritical = true
+			add(true, "filesystem", "Low disk space on %s: only %.1f%% free space remaining", mount, fs.Current)
+		}
+		if fs.TimeToFull > 0 {
+			switch {
+			case fs.TimeToFull <= fsThresholds.FullCritical:
+				add(true, "filesystem", "%s projected to fill up in %s", mount, fs.TimeToFull.Round(time.Minute))
+			case fs.TimeToFull <= fsThresholds.FullWarning:
+				add(false, "filesystem", "%s projected to fill up in %s", mount, fs.TimeToFull.Round(time.Minute))
+			}
+		}
+		if fs.Anomaly {
+			if fs.Trend < 0 {
+				add(false, "filesystem", "Abnormal decrease in free space on %s (trend: %.3f%%/s)", mount, fs.Trend)
+			} else {
+				add(false, "filesystem", "Abnormal change in free space on %s (current: %.1f%%, mean: %.1f%%)", mount, fs.Current, fs.Mean)
+			}
+		}
+	}
+	if t.Filesystem.Critical && !partitionCritical {
+		add(true, "filesystem", "Low disk space")
+	}
+
+	return problems
+}
+
+// ExitedProblems lists the critical processes that exited since the previous
+// sample. Unlike the other problems they are reported even while the
+// baseline is still calibrating.
+func (t *Trend) ExitedProblems() []Problem {
+	var problems []Problem
+	for _, pattern := range t.ExitedCritical {
+		problems = append(problems, Problem{Critical: true, Source: "watch", Message: fmt.Sprintf("Critical process %q exited", pattern)})
+	}
+	return problems
+}
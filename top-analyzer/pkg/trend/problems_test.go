@@ -0,0 +1,264 @@
+package trend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestProblemDetailsStressThreshold(t *testing.T) {
+	tests := []struct {
+		name        string
+		threshold   float64
+		stress      float64
+		cpuAnomaly  bool
+		wantSources []string
+	}{
+		{name: "just under a custom threshold", threshold: 60, stress: 59.9},
+		{name: "at a custom threshold", threshold: 60, stress: 60, wantSources: []string{"stress"}},
+		{name: "just over a custom threshold", threshold: 60, stress: 60.1, wantSources: []string{"stress"}},
+		{name: "default threshold", stress: 84.9},
+		{name: "over the default threshold", stress: DefaultStressThreshold, wantSources: []string{"stress"}},
+		{name: "anomaly under the threshold", threshold: 60, stress: 59.9, cpuAnomaly: true, wantSources: []string{"cpu"}},
+		{name: "anomaly over the threshold", threshold: 60, stress: 60.1, cpuAnomaly: true, wantSources: []string{"stress", "cpu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := &Trend{SystemStress: tt.stress, stressThreshold: tt.threshold}
+			if tt.cpuAnomaly {
+				trend.CPUUsage.Anomaly = true
+				trend.CPUUsage.Reasons = []string{"z-score 3.0 exceeded 2.0"}
+			}
+
+			var sources []string
+			for _, problem := range trend.ProblemDetails() {
+				sources = append(sources, problem.Source)
+			}
+			if !reflect.DeepEqual(sources, tt.wantSources) {
+				t.Errorf("problem sources = %q, want %q", sources, tt.wantSources)
+			}
+			if got := trend.IsHealthy(); got != (len(tt.wantSources) == 0) {
+				t.Errorf("IsHealthy() = %v, want %v", got, len(tt.wantSources) == 0)
+			}
+		})
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	tests := []struct {
+		name         string
+		set          func(trend *Trend)
+		wantProblems []string
+	}{
+		{name: "all clear", set: func(trend *Trend) {}},
+		{
+			name:         "filesystem critical only",
+			set:          func(trend *Trend) { trend.Filesystem.Critical = true },
+			wantProblems: []string{"Low disk space"},
+		},
+		{
+			name:         "filesystem anomaly only",
+			set:          func(trend *Trend) { trend.Filesystem.Anomaly = true },
+			wantProblems: nil, // Reported per partition only
+		},
+		{
+			name: "swap thrashing",
+			set: func(trend *Trend) {
+				trend.SwapUsage.Thrashing = true
+				trend.SwapUsage.Reasons = []string{"swap grew 50%"}
+			},
+			wantProblems: []string{"Swap thrashing detected: swap grew 50%"},
+		},
+		{
+			name:         "critical process exited",
+			set:          func(trend *Trend) { trend.ExitedCritical = []string{"watchdog"} },
+			wantProblems: []string{`Critical process "watchdog" exited`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := &Trend{}
+			tt.set(trend)
+
+			if got := trend.Problems(); !reflect.DeepEqual(got, tt.wantProblems) {
+				t.Errorf("Problems() = %q, want %q", got, tt.wantProblems)
+			}
+			if got := trend.IsHealthy(); got != (len(tt.wantProblems) == 0) {
+				t.Errorf("IsHealthy() = %v, want %v", got, len(tt.wantProblems) == 0)
+			}
+		})
+	}
+}
+
+func TestMissingWatched(t *testing.T) {
+	running := []parser.Process{
+		{PID: 1, Command: "init"},
+		{PID: 300, Command: "postgres: checkpointer", CPUPercent: 2},
+		{PID: 400, Command: "/usr/bin/my-app --serve", CPUPercent: 15},
+	}
+
+	tests := []struct {
+		name         string
+		watchlist    []string
+		processes    []parser.Process
+		wantMissing  []string
+		wantProblems []Problem
+	}{
+		{name: "every watched process running", watchlist: []string{"postgres", "my-app"}, processes: running},
+		{
+			name:         "one watched process missing",
+			watchlist:    []string{"postgres", "my-app"},
+			processes:    running[:2],
+			wantMissing:  []string{"my-app"},
+			wantProblems: []Problem{{Critical: true, Source: "watch", Message: `Watched process "my-app" is not running`}},
+		},
+		{name: "no watchlist", processes: running[:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetWatchlist(tt.watchlist)
+			analyzer.AddStats(&parser.SystemStats{Processes: running})
+			analyzer.AddStats(&parser.SystemStats{Processes: tt.processes})
+			trend := analyzer.Analyze()
+
+			if !reflect.DeepEqual(trend.MissingWatched, tt.wantMissing) {
+				t.Errorf("MissingWatched = %q, want %q", trend.MissingWatched, tt.wantMissing)
+			}
+			var problems []Problem
+			for _, problem := range trend.ProblemDetails() {
+				if problem.Source == "watch" {
+					problems = append(problems, problem)
+				}
+			}
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Errorf("watch problems = %+v, want %+v", problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestTimeToFullTiers(t *testing.T) {
+	horizons := func(warning, critical time.Duration) filesystem.Thresholds {
+		thresholds := filesystem.DefaultThresholds
+		thresholds.FullWarning = warning
+		thresholds.FullCritical = critical
+		return thresholds
+	}
+
+	tests := []struct {
+		name         string
+		thresholds   filesystem.Thresholds
+		interval     time.Duration // Free space falls by 1% every interval
+		free         float64       // Free space percentage of the last sample
+		wantProblems []Problem
+	}{
+		{
+			name:         "full in 30 minutes",
+			thresholds:   filesystem.DefaultThresholds,
+			interval:     time.Minute,
+			free:         30,
+			wantProblems: []Problem{{Critical: true, Source: "filesystem", Message: "/data projected to fill up in 30m0s"}},
+		},
+		{
+			name:         "full in 12 hours",
+			thresholds:   filesystem.DefaultThresholds,
+			interval:     24 * time.Minute,
+			free:         30,
+			wantProblems: []Problem{{Critical: false, Source: "filesystem", Message: "/data projected to fill up in 12h0m0s"}},
+		},
+		{name: "full in 48 hours", thresholds: filesystem.DefaultThresholds, interval: 96 * time.Minute, free: 30},
+		{
+			name:         "custom horizons",
+			thresholds:   horizons(time.Hour, 15*time.Minute),
+			interval:     time.Minute,
+			free:         30,
+			wantProblems: []Problem{{Critical: false, Source: "filesystem", Message: "/data projected to fill up in 30m0s"}},
+		},
+		{
+			name:         "critical tier disabled",
+			thresholds:   horizons(24*time.Hour, 0),
+			interval:     time.Minute,
+			free:         30,
+			wantProblems: []Problem{{Critical: false, Source: "filesystem", Message: "/data projected to fill up in 30m0s"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetInterval(tt.interval)
+			analyzer.SetFilesystemThresholds(tt.thresholds)
+			for free := tt.free + 4; free >= tt.free; free-- {
+				analyzer.AddStats(&parser.SystemStats{Filesystem: map[string]parser.FilesystemStats{
+					"/data": {Device: "/dev/sdb1", Size: 100 << 30, UsedPct: 100 - free, MountPoint: "/data"},
+				}})
+			}
+
+			var problems []Problem
+			for _, problem := range analyzer.Analyze().ProblemDetails() {
+				if strings.Contains(problem.Message, "projected to fill up") {
+					problems = append(problems, problem)
+				}
+			}
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Errorf("time to full problems = %+v, want %+v", problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestUnexpectedProcesses(t *testing.T) {
+	procs := []parser.Process{
+		{PID: 100, Command: "nginx: worker process", CPUPercent: 40},
+		{PID: 200, Command: "sshd: admin", CPUPercent: 0.5},
+		{PID: 300, Command: "bash", CPUPercent: 0.2},
+		{PID: 400, Command: "xmrig", CPUPercent: 80},
+	}
+
+	tests := []struct {
+		name         string
+		expected     []string
+		processes    []parser.Process
+		wantProblems []Problem
+	}{
+		{
+			name:         "busy unexpected process",
+			expected:     []string{"nginx", "sshd"},
+			processes:    procs,
+			wantProblems: []Problem{{Source: "processes", Message: "Unexpected process xmrig (PID: 400) using 80.0% CPU"}},
+		},
+		{name: "only expected processes busy", expected: []string{"nginx", "sshd"}, processes: procs[:3]},
+		{name: "no expected processes", processes: procs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetExpectedProcesses(tt.expected, parser.DefaultProcessThresholds)
+			analyzer.AddStats(&parser.SystemStats{Processes: tt.processes})
+			analyzer.AddStats(&parser.SystemStats{Processes: tt.processes})
+			trend := analyzer.Analyze()
+
+			var problems []Problem
+			for _, problem := range trend.ProblemDetails() {
+				if problem.Source == "processes" {
+					problems = append(problems, problem)
+				}
+			}
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Errorf("process problems = %+v, want %+v", problems, tt.wantProblems)
+			}
+			if got, want := len(trend.Unexpected), len(tt.wantProblems); got != want {
+				t.Errorf("Unexpected = %d processes, want %d", got, want)
+			}
+		})
+	}
+}
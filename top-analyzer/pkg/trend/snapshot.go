@@ -0,0 +1,127 @@
+package trend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// SnapshotVersion is the schema version of the snapshots written by
+// SaveSnapshot. Bump it whenever the layout changes incompatibly.
+const SnapshotVersion = 1
+
+// Snapshot is the content of a snapshot or crash dump file
+type Snapshot struct {
+	SchemaVersion int
+	Timestamp     time.Time
+	Instance      instance.Metadata
+	Note          string            `json:",omitempty"`
+	Config        map[string]string `json:",omitempty"` // Effective configuration of the run, see instance.SetConfig
+	Stats         []*parser.SystemStats
+	Trend         *Trend
+	Summary       struct {
+		TotalStorage       int64
+		UsedStorage        int64
+		FreeStorage        int64
+		StorageUsagePct    float64
+		CriticalPartitions []string
+		LowSpacePartitions []string
+	}
+	Culprits struct {
+		TopCPU    []parser.Process // Highest CPU users at the time of the snapshot
+		TopMemory []parser.Process // Highest memory users at the time of the snapshot
+	}
+}
+
+// LoadSnapshot reads a snapshot file written by SaveSnapshot
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Validate checks the schema version and structural integrity of the
+// snapshot and returns the problems found, none when it is valid
+func (s *Snapshot) Validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch s.SchemaVersion {
+	case SnapshotVersion:
+	case 0:
+		add("missing schema version")
+	default:
+		add("unsupported schema version %d (expected %d)", s.SchemaVersion, SnapshotVersion)
+	}
+	if s.Timestamp.IsZero() {
+		add("missing timestamp")
+	}
+
+	if len(s.Stats) == 0 {
+		add("no samples")
+	}
+	for i, stats := range s.Stats {
+		if stats == nil {
+			add("sample %d is null", i)
+			continue
+		}
+		if stats.Filesystem == nil {
+			add("sample %d has no filesystem map", i)
+		}
+		if stats.Temperature.Sensors == nil {
+			add("sample %d has no temperature sensor map", i)
+		}
+	}
+
+	if s.Trend == nil {
+		if len(s.Stats) >= 2 {
+			add("missing trend for %d samples", len(s.Stats))
+		}
+	} else {
+		if s.Trend.Temperature.Sensors == nil {
+			add("trend has no temperature sensor map")
+		}
+		if s.Trend.Filesystem.Partitions == nil {
+			add("trend has no partition map")
+		}
+	}
+
+	if len(s.Culprits.TopCPU) > culpritCount || len(s.Culprits.TopMemory) > culpritCount {
+		add("more than %d culprits", culpritCount)
+	}
+
+	// The storage summary is computed from the latest sample
+	if len(s.Stats) > 0 && s.Stats[len(s.Stats)-1] != nil {
+		latest := s.Stats[len(s.Stats)-1]
+		var total int64
+		for _, fs := range latest.Filesystem {
+			total += fs.Size
+		}
+		if total != s.Summary.TotalStorage {
+			add("summary total storage %d does not match the %d bytes of the latest sample", s.Summary.TotalStorage, total)
+		}
+		for _, mountPoint := range append(s.Summary.CriticalPartitions, s.Summary.LowSpacePartitions...) {
+			if _, exists := latest.Filesystem[mountPoint]; !exists {
+				add("summary partition %s is missing from the latest sample", mountPoint)
+			}
+		}
+		if processes := len(latest.Processes); len(s.Culprits.TopCPU) > processes || len(s.Culprits.TopMemory) > processes {
+			add("more culprits than the %d processes of the latest sample", processes)
+		}
+	}
+
+	return problems
+}
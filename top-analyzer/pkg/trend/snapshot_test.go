@@ -0,0 +1,222 @@
+package trend
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+func TestSnapshotStats(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		stats *parser.SystemStats
+	}{
+		{
+			name: "per-core",
+			stats: &parser.SystemStats{
+				Timestamp: timestamp,
+				CPU:       parser.CPU{User: 12, Idle: 88},
+				PerCore:   []parser.CPU{{User: 95, Idle: 5}, {User: 2, Idle: 98}},
+			},
+		},
+		{
+			name:  "cpu scaled",
+			stats: &parser.SystemStats{Timestamp: timestamp, CPU: parser.CPU{User: 50, Idle: 50}, CPUScale: 4},
+		},
+		{
+			name:  "cpu clamped",
+			stats: &parser.SystemStats{Timestamp: timestamp, CPU: parser.CPU{User: 100}, CPUScale: 4, CPUClamped: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.AddStats(cpuSample(10))
+			analyzer.AddStats(tt.stats)
+
+			filename := filepath.Join(t.TempDir(), "crash.json")
+			if err := analyzer.SaveSnapshot(filename); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			snapshot, err := LoadSnapshot(filename)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+
+			latest := snapshot.Stats[len(snapshot.Stats)-1]
+			if !latest.Timestamp.Equal(tt.stats.Timestamp) {
+				t.Errorf("saved timestamp = %v, want %v", latest.Timestamp, tt.stats.Timestamp)
+			}
+			if !reflect.DeepEqual(latest.PerCore, tt.stats.PerCore) {
+				t.Errorf("saved per-core = %+v, want %+v", latest.PerCore, tt.stats.PerCore)
+			}
+			if latest.CPUScale != tt.stats.CPUScale || latest.CPUClamped != tt.stats.CPUClamped {
+				t.Errorf("saved cpu scale %d, clamped %v, want %d, %v", latest.CPUScale, latest.CPUClamped, tt.stats.CPUScale, tt.stats.CPUClamped)
+			}
+		})
+	}
+}
+
+func TestSnapshotCulprits(t *testing.T) {
+	idle := []parser.Process{
+		{PID: 1, Command: "init", CPUPercent: 0.1, VSZPercent: 0.5},
+		{PID: 2, Command: "sshd", CPUPercent: 0.2, VSZPercent: 1},
+	}
+	cpuHog := parser.Process{PID: 100, Command: "ffmpeg -i input.mkv", CPUPercent: 95, VSZPercent: 3}
+	memHog := parser.Process{PID: 200, Command: "java -jar app.jar", CPUPercent: 2, VSZPercent: 60}
+
+	tests := []struct {
+		name          string
+		processes     []parser.Process
+		excludedPID   int
+		wantTopCPU    int // PID of the first CPU culprit
+		wantTopMemory int // PID of the first memory culprit
+	}{
+		{name: "high CPU process", processes: append([]parser.Process{cpuHog}, idle...), wantTopCPU: 100, wantTopMemory: 100},
+		{name: "high CPU and high memory processes", processes: append([]parser.Process{memHog, cpuHog}, idle...), wantTopCPU: 100, wantTopMemory: 200},
+		{name: "excluded high CPU process", processes: append([]parser.Process{cpuHog}, idle...), excludedPID: 100, wantTopCPU: 2, wantTopMemory: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetExcludedPID(tt.excludedPID)
+			// Only the most recent sample counts
+			analyzer.AddStats(&parser.SystemStats{Processes: idle})
+			analyzer.AddStats(&parser.SystemStats{CPU: parser.CPU{User: 95}, Processes: tt.processes})
+
+			filename := filepath.Join(t.TempDir(), "crash.json")
+			if err := analyzer.SaveSnapshot(filename); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			snapshot, err := LoadSnapshot(filename)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+
+			culprits := snapshot.Culprits
+			if len(culprits.TopCPU) == 0 || culprits.TopCPU[0].PID != tt.wantTopCPU {
+				t.Errorf("TopCPU = %+v, want PID %d first", culprits.TopCPU, tt.wantTopCPU)
+			}
+			if len(culprits.TopMemory) == 0 || culprits.TopMemory[0].PID != tt.wantTopMemory {
+				t.Errorf("TopMemory = %+v, want PID %d first", culprits.TopMemory, tt.wantTopMemory)
+			}
+			for _, proc := range append(culprits.TopCPU, culprits.TopMemory...) {
+				if tt.excludedPID != 0 && proc.PID == tt.excludedPID {
+					t.Errorf("culprits include the excluded PID %d", tt.excludedPID)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveSnapshotNonFinite(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *parser.SystemStats
+	}{
+		{name: "NaN CPU usage", stats: &parser.SystemStats{CPU: parser.CPU{User: math.NaN()}}},
+		{name: "Inf load average", stats: &parser.SystemStats{LoadAverage: parser.LoadAverage{One: math.Inf(1)}}},
+		{name: "NaN process CPU", stats: &parser.SystemStats{Processes: []parser.Process{{PID: 1, Command: "init", CPUPercent: math.NaN()}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.AddStats(&parser.SystemStats{CPU: parser.CPU{User: 10}})
+			analyzer.AddStats(tt.stats)
+
+			filename := filepath.Join(t.TempDir(), "crash.json")
+			if err := analyzer.SaveSnapshot(filename); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			snapshot, err := LoadSnapshot(filename)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+			latest := snapshot.Stats[len(snapshot.Stats)-1]
+			if user := latest.CPU.User; math.IsNaN(user) || math.IsInf(user, 0) {
+				t.Errorf("saved CPU user = %v, want a finite value", user)
+			}
+		})
+	}
+}
+
+func TestSnapshotInstance(t *testing.T) {
+	analyzer := New(10)
+	analyzer.AddStats(cpuSample(10))
+	analyzer.AddStats(cpuSample(20))
+
+	var ids []string
+	for _, name := range []string{"crash_1.json", "crash_2.json"} {
+		filename := filepath.Join(t.TempDir(), name)
+		if err := analyzer.SaveSnapshot(filename); err != nil {
+			t.Fatalf("SaveSnapshot() error = %v", err)
+		}
+		snapshot, err := LoadSnapshot(filename)
+		if err != nil {
+			t.Fatalf("LoadSnapshot() error = %v", err)
+		}
+		if snapshot.Instance.Hostname == "" || snapshot.Instance.InstanceID == "" || snapshot.Instance.StartTime.IsZero() {
+			t.Errorf("snapshot instance = %+v, want hostname, instance ID and start time", snapshot.Instance)
+		}
+		ids = append(ids, snapshot.Instance.InstanceID)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("instance IDs = %q, want the same within a run", ids)
+	}
+}
+
+func TestSnapshotConfig(t *testing.T) {
+	old := instance.Config()
+	t.Cleanup(func() { instance.SetConfig(old) })
+
+	analyzer := New(10)
+	analyzer.AddStats(cpuSample(10))
+	analyzer.AddStats(cpuSample(20))
+
+	tests := []struct {
+		name     string
+		config   map[string]string
+		wantSame bool // Hash the same as the previous snapshot's
+	}{
+		{name: "initial", config: map[string]string{"interval": "5s", "stress-crash-threshold": "85"}},
+		{name: "unchanged", config: map[string]string{"interval": "5s", "stress-crash-threshold": "85"}, wantSame: true},
+		{name: "threshold changed", config: map[string]string{"interval": "5s", "stress-crash-threshold": "90"}},
+	}
+
+	previous := ""
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance.SetConfig(tt.config)
+			filename := filepath.Join(t.TempDir(), "crash.json")
+			if err := analyzer.SaveSnapshot(filename); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			snapshot, err := LoadSnapshot(filename)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+
+			hash := snapshot.Instance.ConfigHash
+			if hash == "" {
+				t.Fatal("snapshot has no config hash")
+			}
+			if !reflect.DeepEqual(snapshot.Config, tt.config) {
+				t.Errorf("snapshot config = %v, want %v", snapshot.Config, tt.config)
+			}
+			if previous != "" && (hash == previous) != tt.wantSame {
+				t.Errorf("config hash %s after %s, same = %v, want %v", hash, previous, hash == previous, tt.wantSame)
+			}
+			previous = hash
+		})
+	}
+}
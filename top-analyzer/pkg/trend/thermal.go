@@ -0,0 +1,69 @@
+package trend
+
+import (
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// Thresholds of the correlation between the temperature and the CPU usage
+const (
+	ThermalSamples    = 5   // Samples with CPU usage and temperature needed before correlating
+	cpuRiseRate       = 0.2 // CPU percent per second considered a rising load
+	warmupCorrelation = 0.7 // Correlation from which a temperature rise follows the CPU usage
+)
+
+// Thermal is how the average temperature of the sensors and the CPU usage
+// of a history move together
+type Thermal struct {
+	TempSlope   float64 // °C per second
+	CPUSlope    float64 // CPU percent per second
+	Correlation float64 // Pearson correlation of the two, 0 when either is constant
+}
+
+// CorrelateThermal correlates the average temperature and the CPU usage of
+// the samples with both, taken every interval. ok is false until
+// ThermalSamples of them are available.
+func CorrelateThermal(history []*parser.SystemStats, interval time.Duration) (thermal Thermal, ok bool) {
+	var temps, cpus []float64
+	for _, stats := range history {
+		if !stats.Has(parser.SectionCPU) || len(stats.Temperature.Sensors) == 0 {
+			continue
+		}
+		sum := 0.0
+		for _, temp := range stats.Temperature.Sensors {
+			sum += temp
+		}
+		temps = append(temps, sum/float64(len(stats.Temperature.Sensors)))
+		cpus = append(cpus, stats.CPU.User+stats.CPU.Sys)
+	}
+	if len(temps) < ThermalSamples {
+		return Thermal{}, false
+	}
+	return Thermal{
+		TempSlope:   Slope(temps, interval),
+		CPUSlope:    Slope(cpus, interval),
+		Correlation: correlation(temps, cpus),
+	}, true
+}
+
+// FollowsCPU reports whether the temperature rises along with a rising CPU
+// usage, closely correlated, as it does right after a CPU-heavy task starts
+func (th Thermal) FollowsCPU() bool {
+	return th.TempSlope > 0 && th.CPUSlope > cpuRiseRate && th.Correlation >= warmupCorrelation
+}
+
+// correlation returns the Pearson correlation coefficient of two series of
+// the same length, 0 when either is constant
+func correlation(x, y []float64) float64 {
+	meanX, stdDevX := calculateStats(x)
+	meanY, stdDevY := calculateStats(y)
+	if stdDevX == 0 || stdDevY == 0 {
+		return 0
+	}
+	covariance := 0.0
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+	}
+	return covariance / float64(len(x)) / (stdDevX * stdDevY)
+}
@@ -0,0 +1,58 @@
+package trend
+
+import (
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+func TestCorrelateThermal(t *testing.T) {
+	sample := func(cpu, temp float64) *parser.SystemStats {
+		return &parser.SystemStats{
+			CPU:            parser.CPU{User: cpu},
+			ParsedSections: map[parser.Section]bool{parser.SectionCPU: true},
+			Temperature:    temperature.TemperatureStats{Sensors: map[string]float64{"cpu_thermal": temp}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		history        []*parser.SystemStats
+		wantOK         bool
+		wantFollowsCPU bool
+	}{
+		{
+			name:           "correlated rise",
+			history:        []*parser.SystemStats{sample(10, 40), sample(20, 43), sample(30, 46), sample(40, 49), sample(50, 52)},
+			wantOK:         true,
+			wantFollowsCPU: true,
+		},
+		{
+			name:    "temperature rise with a constant cpu",
+			history: []*parser.SystemStats{sample(50, 40), sample(50, 43), sample(50, 46), sample(50, 49), sample(50, 52)},
+			wantOK:  true,
+		},
+		{
+			name:    "cooling while the cpu rises",
+			history: []*parser.SystemStats{sample(10, 52), sample(20, 49), sample(30, 46), sample(40, 43), sample(50, 40)},
+			wantOK:  true,
+		},
+		{
+			name:    "too few samples",
+			history: []*parser.SystemStats{sample(10, 40), sample(20, 43), sample(30, 46), sample(40, 49)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thermal, ok := CorrelateThermal(tt.history, DefaultInterval)
+			if ok != tt.wantOK {
+				t.Fatalf("CorrelateThermal() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := thermal.FollowsCPU(); got != tt.wantFollowsCPU {
+				t.Errorf("FollowsCPU() = %v, want %v (%+v)", got, tt.wantFollowsCPU, thermal)
+			}
+		})
+	}
+}
@@ -6,9 +6,18 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/parth2601/monchecker/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/instance"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/ring"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/round"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/sanitize"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/stress"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
 )
 
 type Trend struct {
@@ -17,18 +26,39 @@ type Trend struct {
 		StdDev  float64
 		Trend   float64
 		Anomaly bool
+		Reasons []string // Why Anomaly is set
 	}
 	MemoryUsage struct {
+		Mean          float64
+		StdDev        float64
+		Trend         float64
+		Anomaly       bool
+		Reasons       []string // Why Anomaly is set
+		CacheCollapse bool     // buff/cache dropped sharply while used memory climbed
+		LowFree       bool     // Free memory of the latest sample below the percentage or byte threshold
+		Free          int64    // Free memory of the latest sample in bytes
+		FreePercent   float64  // Free memory of the latest sample as a percentage of the total
+	}
+	SwapUsage struct { // Percentage of swap space used
+		Mean      float64
+		StdDev    float64
+		Trend     float64
+		Thrashing bool     // swap used oscillating or growing while iowait is high
+		Reasons   []string // Why Thrashing is set
+	}
+	ProcessCount struct {
 		Mean    float64
 		StdDev  float64
 		Trend   float64
 		Anomaly bool
+		Reasons []string // Why Anomaly is set
 	}
-	ProcessCount struct {
+	LoadAverage struct { // 1 minute load average
 		Mean    float64
 		StdDev  float64
 		Trend   float64
 		Anomaly bool
+		Reasons []string // Why Anomaly is set
 	}
 	Temperature struct {
 		Mean              float64
@@ -37,8 +67,11 @@ type Trend struct {
 		Anomaly           bool
 		Max               float64
 		Min               float64
+		Overall           float64 // Sensors combined as configured by SetOverallTemperature, the basis of the stress score
 		AbsoluteThreshold float64
 		ThresholdExceeded bool
+		Reasons           []string // Why Anomaly is set
+		Warmup            bool     // Rising along with the CPU usage, trend anomalies suppressed, see SetWarmupSuppression
 		Sensors           map[string]struct {
 			Mean              float64
 			StdDev            float64
@@ -54,40 +87,154 @@ type Trend struct {
 		Partitions map[string]struct {
 			Mean       float64 // Mean free space percentage
 			StdDev     float64
-			Trend      float64 // Trend of free space (negative means decreasing)
+			Trend      float64 // Trend of free space in percent per second (negative means decreasing)
 			Anomaly    bool
 			Min        float64 // Minimum free space percentage observed
 			Max        float64 // Maximum free space percentage observed
 			Current    float64 // Current free space percentage
-			Critical   bool    // Less than 10% free space
+			Critical   bool    // Less than the critical free percent of free space
 			Device     string
 			MountPoint string
+			Size       int64         // Size of the partition in bytes
+			TimeToFull time.Duration // Projected time until full at the current trend, 0 when not filling
 		}
 		Anomaly  bool // Any partition has anomaly
 		Critical bool // Any partition is critical
 	}
-	SystemStress float64
+	StuckProcesses  []StuckProcess   // Processes stuck in uninterruptible sleep
+	LostSensors     []LostSensor     // Sensors that stopped reporting
+	MissingWatched  []string         // Watched command substrings without a running process
+	ExitedCritical  []string         // Critical watched command substrings running in the previous sample but not anymore
+	Unexpected      []parser.Process // High CPU processes matching none of the expected command substrings
+	SystemStress    float64
+	StressBreakdown stress.Breakdown // Factors SystemStress is made of, before the cap
+
+	stressThreshold float64               // System stress reported as a problem, 0 uses DefaultStressThreshold
+	fsThresholds    filesystem.Thresholds // Thresholds the partitions were evaluated with
+}
+
+// StuckProcess is a process that stayed in uninterruptible sleep (D state)
+// for at least the configured number of consecutive samples
+type StuckProcess struct {
+	PID     int
+	Command string
+	Samples int // Consecutive samples spent in D state
+}
+
+// AnomalyCount returns the number of anomalies and alert conditions active in
+// the trend
+func (t *Trend) AnomalyCount() int {
+	count := len(t.StuckProcesses) + len(t.LostSensors) + len(t.MissingWatched) + len(t.ExitedCritical) + len(t.Unexpected)
+	for _, active := range []bool{
+		t.CPUUsage.Anomaly,
+		t.MemoryUsage.Anomaly,
+		t.MemoryUsage.CacheCollapse,
+		t.MemoryUsage.LowFree,
+		t.SwapUsage.Thrashing,
+		t.ProcessCount.Anomaly,
+		t.LoadAverage.Anomaly,
+		t.Temperature.Anomaly,
+		t.Temperature.ThresholdExceeded,
+		t.Filesystem.Anomaly,
+		t.Filesystem.Critical,
+	} {
+		if active {
+			count++
+		}
+	}
+	return count
+}
+
+// LostSensor is a temperature sensor that reported before but has been
+// absent for at least the configured number of consecutive samples
+type LostSensor struct {
+	Name    string
+	Samples int // Consecutive samples the sensor has been missing
 }
 
 type TrendAnalyzer struct {
-	history             []*parser.SystemStats
+	history             *ring.Buffer[*parser.SystemStats]
 	window              int
+	tempWindow          int // Samples kept per sensor in tempHistory
 	tempHistory         map[string][]float64
 	longTermTempHistory map[string][]float64
 	anomalyThreshold    float64
 	trendThreshold      float64
 	tempThreshold       float64
 	longTermWindow      int
+	baselineMean        map[string]float64
+	baselineVar         map[string]float64
+	baselineSamples     int
+	baselinePending     *parser.SystemStats // Latest sample, added to the baseline once scored
+	persistentBaseline  bool
+	dedupStrategy       parser.DedupStrategy
+	dStateSamples       map[int]StuckProcess
+	stuckSamples        int
+	sensorMissing       map[string]int
+	lostSensorSamples   int
+	watchlist           []string
+	criticalWatch       []string
+	suppressWarmup      bool                     // Suppress temperature trend anomalies correlated with a CPU rise
+	expected            []string                 // Command substrings of the processes expected to run, nil disables the check
+	expectedThresholds  parser.ProcessThresholds // Above which an unexpected process is reported
+	precision           int
+	fsThresholds        filesystem.Thresholds
+	memThresholds       parser.MemoryThresholds
+	stressThreshold     float64
+	anomalyMethod       AnomalyMethod
+	anomalyPercentile   float64
+	overallTemp         temperature.Overall
+	interval            time.Duration // Time between samples, trends are normalized per second with it
+	excludedPID         int           // Left out of the snapshot culprits, 0 excludes none
 }
 
+// DefaultStressThreshold is the system stress at or above which a trend is
+// unhealthy unless configured otherwise
+const DefaultStressThreshold = 85.0
+
+// DefaultInterval is the time between samples assumed unless configured
+// otherwise with SetInterval
+const DefaultInterval = 5 * time.Second
+
+// WindowFor returns the number of samples taken every interval that covers
+// retention, at least 1
+func WindowFor(retention, interval time.Duration) int {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	samples := int((retention + interval - 1) / interval)
+	if samples < 1 {
+		samples = 1
+	}
+	return samples
+}
+
+// DefaultTrendThreshold is the trend slope, in units per second, above which
+// a metric is anomalous unless configured otherwise
+const DefaultTrendThreshold = 0.02
+
 func New(window int) *TrendAnalyzer {
 	return &TrendAnalyzer{
-		history:             make([]*parser.SystemStats, 0, window),
+		history:             ring.New[*parser.SystemStats](window),
 		window:              window,
+		tempWindow:          window,
 		tempHistory:         make(map[string][]float64),
 		longTermTempHistory: make(map[string][]float64),
+		baselineMean:        make(map[string]float64),
+		baselineVar:         make(map[string]float64),
+		dedupStrategy:       parser.DedupCommand,
+		dStateSamples:       make(map[int]StuckProcess),
+		sensorMissing:       make(map[string]int),
+		precision:           -1,
+		fsThresholds:        filesystem.DefaultThresholds,
+		memThresholds:       parser.DefaultMemoryThresholds,
+		stressThreshold:     DefaultStressThreshold,
+		anomalyMethod:       AnomalyZScore,
+		anomalyPercentile:   DefaultAnomalyPercentile,
+		overallTemp:         temperature.OverallMax,
+		interval:            DefaultInterval,
 		anomalyThreshold:    2.0,
-		trendThreshold:      0.1,
+		trendThreshold:      DefaultTrendThreshold,
 		tempThreshold:       70.0,
 		longTermWindow:      100,
 	}
@@ -96,10 +243,24 @@ func New(window int) *TrendAnalyzer {
 // NewWithOptions creates a new TrendAnalyzer with configurable options
 func NewWithOptions(window int, anomalyThreshold float64, trendThreshold float64) *TrendAnalyzer {
 	return &TrendAnalyzer{
-		history:             make([]*parser.SystemStats, 0, window),
+		history:             ring.New[*parser.SystemStats](window),
 		window:              window,
+		tempWindow:          window,
 		tempHistory:         make(map[string][]float64),
 		longTermTempHistory: make(map[string][]float64),
+		baselineMean:        make(map[string]float64),
+		baselineVar:         make(map[string]float64),
+		dedupStrategy:       parser.DedupCommand,
+		dStateSamples:       make(map[int]StuckProcess),
+		sensorMissing:       make(map[string]int),
+		precision:           -1,
+		fsThresholds:        filesystem.DefaultThresholds,
+		memThresholds:       parser.DefaultMemoryThresholds,
+		stressThreshold:     DefaultStressThreshold,
+		anomalyMethod:       AnomalyZScore,
+		anomalyPercentile:   DefaultAnomalyPercentile,
+		overallTemp:         temperature.OverallMax,
+		interval:            DefaultInterval,
 		anomalyThreshold:    anomalyThreshold,
 		trendThreshold:      trendThreshold,
 		tempThreshold:       70.0,
@@ -110,10 +271,24 @@ func NewWithOptions(window int, anomalyThreshold float64, trendThreshold float64
 // Enhanced version with temperature threshold configuration
 func NewWithFullOptions(window int, anomalyThreshold float64, trendThreshold float64, tempThreshold float64, longTermWindow int) *TrendAnalyzer {
 	return &TrendAnalyzer{
-		history:             make([]*parser.SystemStats, 0, window),
+		history:             ring.New[*parser.SystemStats](window),
 		window:              window,
+		tempWindow:          window,
 		tempHistory:         make(map[string][]float64),
 		longTermTempHistory: make(map[string][]float64),
+		baselineMean:        make(map[string]float64),
+		baselineVar:         make(map[string]float64),
+		dedupStrategy:       parser.DedupCommand,
+		dStateSamples:       make(map[int]StuckProcess),
+		sensorMissing:       make(map[string]int),
+		precision:           -1,
+		fsThresholds:        filesystem.DefaultThresholds,
+		memThresholds:       parser.DefaultMemoryThresholds,
+		stressThreshold:     DefaultStressThreshold,
+		anomalyMethod:       AnomalyZScore,
+		anomalyPercentile:   DefaultAnomalyPercentile,
+		overallTemp:         temperature.OverallMax,
+		interval:            DefaultInterval,
 		anomalyThreshold:    anomalyThreshold,
 		trendThreshold:      trendThreshold,
 		tempThreshold:       tempThreshold,
@@ -121,20 +296,122 @@ func NewWithFullOptions(window int, anomalyThreshold float64, trendThreshold flo
 	}
 }
 
-func (t *TrendAnalyzer) AddStats(stats *parser.SystemStats) {
-	t.history = append(t.history, stats)
-	if len(t.history) > t.window {
-		t.history = t.history[1:]
+// SetDedupStrategy sets how processes are deduplicated in snapshots
+func (t *TrendAnalyzer) SetDedupStrategy(strategy parser.DedupStrategy) {
+	t.dedupStrategy = strategy
+}
+
+// SetPrecision sets the number of decimals percentages in snapshots are
+// rounded to. A negative value keeps full precision.
+func (t *TrendAnalyzer) SetPrecision(decimals int) {
+	t.precision = decimals
+}
+
+// SetWatchlist sets the command substrings of processes that must always be
+// running. A missing one is reported in MissingWatched.
+func (t *TrendAnalyzer) SetWatchlist(patterns []string) {
+	t.watchlist = patterns
+}
+
+// SetCriticalWatchlist sets the command substrings of critical processes.
+// One that was running in the previous sample but not in the latest is
+// reported in ExitedCritical, the moment it disappears.
+func (t *TrendAnalyzer) SetCriticalWatchlist(patterns []string) {
+	t.criticalWatch = patterns
+}
+
+// SetExpectedProcesses sets the command substrings of the processes expected
+// on the machine. A high CPU process by thresholds matching none of them is
+// reported in Unexpected. An empty list disables the check.
+func (t *TrendAnalyzer) SetExpectedProcesses(patterns []string, thresholds parser.ProcessThresholds) {
+	t.expected = patterns
+	t.expectedThresholds = thresholds
+}
+
+// SetLostSensorSamples sets after how many consecutive samples without a
+// previously seen sensor it is reported as lost. Zero disables it.
+func (t *TrendAnalyzer) SetLostSensorSamples(samples int) {
+	t.lostSensorSamples = samples
+}
+
+// SetFilesystemThresholds sets which partitions are reported as critical and
+// which are too small to be evaluated at all
+func (t *TrendAnalyzer) SetFilesystemThresholds(thresholds filesystem.Thresholds) {
+	t.fsThresholds = thresholds
+}
+
+// SetTempWindow sets the number of samples kept per temperature sensor,
+// independently of the stats history window. Noisy sensors benefit from a
+// longer window. A value of zero or less keeps the history window.
+func (t *TrendAnalyzer) SetTempWindow(window int) {
+	if window <= 0 {
+		window = t.window
+	}
+	t.tempWindow = window
+}
+
+// SetMemoryThresholds sets the free memory percentage and bytes below which
+// memory is reported as low
+func (t *TrendAnalyzer) SetMemoryThresholds(thresholds parser.MemoryThresholds) {
+	t.memThresholds = thresholds
+}
+
+// SetStressThreshold sets the system stress at or above which a trend is
+// unhealthy
+func (t *TrendAnalyzer) SetStressThreshold(threshold float64) {
+	t.stressThreshold = threshold
+}
+
+// SetInterval sets the time between the samples added, which trend slopes
+// are divided by so that they, and the trend threshold, are per second
+// whatever the sampling interval. A value of zero or less keeps the current
+// interval.
+func (t *TrendAnalyzer) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		t.interval = interval
 	}
+}
+
+// SetOverallTemperature sets how the sensors are combined into the overall
+// temperature the stress score is based on
+func (t *TrendAnalyzer) SetOverallTemperature(overall temperature.Overall) {
+	t.overallTemp = overall
+}
+
+// SetAnomalyMethod sets how the latest value of a metric is judged anomalous.
+// With AnomalyPercentile it is anomalous above the percentile of the
+// preceding values in the window.
+func (t *TrendAnalyzer) SetAnomalyMethod(method AnomalyMethod, percentile float64) {
+	t.anomalyMethod = method
+	t.anomalyPercentile = percentile
+}
+
+// SetExcludedPID sets a process left out of the top CPU and memory culprits
+// of snapshots, e.g. the analyzer itself, whose own spikes while marshaling
+// a large history would otherwise point at it. Zero excludes none.
+func (t *TrendAnalyzer) SetExcludedPID(pid int) {
+	t.excludedPID = pid
+}
+
+// SetStuckProcessSamples sets after how many consecutive samples in
+// uninterruptible sleep a process is reported as stuck. Zero disables it.
+func (t *TrendAnalyzer) SetStuckProcessSamples(samples int) {
+	t.stuckSamples = samples
+}
+
+func (t *TrendAnalyzer) AddStats(stats *parser.SystemStats) {
+	t.history.Add(stats)
+	t.updateDStateSamples(stats)
+	t.updateSensorPresence(stats)
 
 	// Update temperature history for each sensor
 	for name, temp := range stats.Temperature.Sensors {
 		// Update regular temperature history
 		if _, exists := t.tempHistory[name]; !exists {
-			t.tempHistory[name] = make([]float64, 0, t.window)
+			t.tempHistory[name] = make([]float64, 0, t.tempWindow)
 		}
 		t.tempHistory[name] = append(t.tempHistory[name], temp)
-		if len(t.tempHistory[name]) > t.window {
+		if len(t.tempHistory[name]) > t.tempWindow {
 			t.tempHistory[name] = t.tempHistory[name][1:]
 		}
 
@@ -147,12 +424,67 @@ func (t *TrendAnalyzer) AddStats(stats *parser.SystemStats) {
 			t.longTermTempHistory[name] = t.longTermTempHistory[name][1:]
 		}
 	}
+
+	// The previous sample joins the baseline only now, so no sample is
+	// scored against a baseline that already includes it
+	t.flushBaseline()
+	t.baselinePending = stats
+}
+
+// updateDStateSamples counts for how many consecutive samples each process
+// has been in uninterruptible sleep. A PID reused by another command restarts
+// the count.
+func (t *TrendAnalyzer) updateDStateSamples(stats *parser.SystemStats) {
+	current := make(map[int]StuckProcess)
+	for _, proc := range stats.Processes {
+		if !strings.HasPrefix(proc.State, "D") {
+			continue
+		}
+		entry := StuckProcess{PID: proc.PID, Command: proc.Command, Samples: 1}
+		if prev, ok := t.dStateSamples[proc.PID]; ok && prev.Command == proc.Command {
+			entry.Samples = prev.Samples + 1
+		}
+		current[proc.PID] = entry
+	}
+	t.dStateSamples = current
+}
+
+// sensorThreshold returns the absolute temperature threshold of a sensor:
+// its critical trip point when the latest sample exposes one, otherwise the
+// configured global threshold
+func (t *TrendAnalyzer) sensorThreshold(history []*parser.SystemStats, name string) float64 {
+	if trip, ok := history[len(history)-1].Temperature.Thresholds[name]; ok && trip > 0 {
+		return trip
+	}
+	return t.tempThreshold
+}
+
+// updateSensorPresence counts for how many consecutive samples each
+// previously seen sensor has been missing. A sensor reporting again resets
+// its count.
+func (t *TrendAnalyzer) updateSensorPresence(stats *parser.SystemStats) {
+	for name := range t.sensorMissing {
+		if _, ok := stats.Temperature.Sensors[name]; !ok {
+			t.sensorMissing[name]++
+		}
+	}
+	for name := range stats.Temperature.Sensors {
+		t.sensorMissing[name] = 0
+	}
 }
 
 func (t *TrendAnalyzer) Analyze() *Trend {
-	if len(t.history) < 2 {
+	defer t.flushBaseline()
+	return t.analyze()
+}
+
+// analyze is Analyze without adding the latest sample to the baseline, so
+// that e.g. saving a snapshot leaves the baseline alone
+func (t *TrendAnalyzer) analyze() *Trend {
+	if t.history.Len() < 2 {
 		return nil
 	}
+	history := t.history.Slice()
 
 	trend := &Trend{
 		Temperature: struct {
@@ -162,8 +494,11 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 			Anomaly           bool
 			Max               float64
 			Min               float64
+			Overall           float64
 			AbsoluteThreshold float64
 			ThresholdExceeded bool
+			Reasons           []string
+			Warmup            bool
 			Sensors           map[string]struct {
 				Mean              float64
 				StdDev            float64
@@ -199,6 +534,8 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				Critical   bool
 				Device     string
 				MountPoint string
+				Size       int64
+				TimeToFull time.Duration
 			}
 			Anomaly  bool
 			Critical bool
@@ -214,6 +551,8 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				Critical   bool
 				Device     string
 				MountPoint string
+				Size       int64
+				TimeToFull time.Duration
 			}),
 			Anomaly:  false,
 			Critical: false,
@@ -221,18 +560,21 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 	}
 
 	// Calculate CPU usage trend
-	cpuUsages := make([]float64, len(t.history))
-	for i, stats := range t.history {
+	cpuHistory := withSection(history, parser.SectionCPU)
+	cpuUsages := make([]float64, len(cpuHistory))
+	for i, stats := range cpuHistory {
 		cpuUsages[i] = stats.CPU.User + stats.CPU.Sys
 	}
 	trend.CPUUsage.Mean, trend.CPUUsage.StdDev = calculateStats(cpuUsages)
-	trend.CPUUsage.Trend = calculateTrend(cpuUsages)
-	trend.CPUUsage.Anomaly = detectAnomalyWithThreshold(cpuUsages, trend.CPUUsage.Mean, trend.CPUUsage.StdDev, t.anomalyThreshold) ||
-		detectTrendAnomaly(trend.CPUUsage.Trend, t.trendThreshold)
+	trend.CPUUsage.Trend = t.slope(cpuUsages)
+	cpuMean, cpuStdDev := t.anomalyBaseline(metricCPU, trend.CPUUsage.Mean, trend.CPUUsage.StdDev)
+	trend.CPUUsage.Reasons = t.anomalyReasons(cpuUsages, cpuMean, cpuStdDev, trend.CPUUsage.Trend)
+	trend.CPUUsage.Anomaly = len(trend.CPUUsage.Reasons) > 0
 
 	// Calculate memory usage trend
-	memUsages := make([]float64, len(t.history))
-	for i, stats := range t.history {
+	memHistory := withSection(history, parser.SectionMemory)
+	memUsages := make([]float64, len(memHistory))
+	for i, stats := range memHistory {
 		if stats.Memory.Total > 0 {
 			memUsages[i] = float64(stats.Memory.Used) / float64(stats.Memory.Total) * 100
 		} else {
@@ -240,34 +582,75 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 		}
 	}
 	trend.MemoryUsage.Mean, trend.MemoryUsage.StdDev = calculateStats(memUsages)
-	trend.MemoryUsage.Trend = calculateTrend(memUsages)
-	trend.MemoryUsage.Anomaly = detectAnomalyWithThreshold(memUsages, trend.MemoryUsage.Mean, trend.MemoryUsage.StdDev, t.anomalyThreshold) ||
-		detectTrendAnomaly(trend.MemoryUsage.Trend, t.trendThreshold)
+	trend.MemoryUsage.Trend = t.slope(memUsages)
+	memMean, memStdDev := t.anomalyBaseline(metricMemory, trend.MemoryUsage.Mean, trend.MemoryUsage.StdDev)
+	trend.MemoryUsage.Reasons = t.anomalyReasons(memUsages, memMean, memStdDev, trend.MemoryUsage.Trend)
+	trend.MemoryUsage.Anomaly = len(trend.MemoryUsage.Reasons) > 0
+
+	// Detect the kernel reclaiming buff/cache under memory pressure
+	trend.MemoryUsage.CacheCollapse = detectCacheCollapse(memHistory)
+
+	// Check the free memory of the latest sample against the thresholds
+	if latest := history[len(history)-1]; latest.Has(parser.SectionMemory) {
+		trend.MemoryUsage.LowFree = t.memThresholds.Low(latest.Memory)
+		if latest.Memory.Total > 0 {
+			trend.MemoryUsage.Free = latest.Memory.Total - latest.Memory.Used
+			trend.MemoryUsage.FreePercent = float64(trend.MemoryUsage.Free) / float64(latest.Memory.Total) * 100
+		}
+	}
+
+	// Calculate swap usage trend and detect thrashing
+	swapHistory := withSection(history, parser.SectionSwap)
+	swapUsages := make([]float64, len(swapHistory))
+	for i, stats := range swapHistory {
+		if stats.Swap.Total > 0 {
+			swapUsages[i] = float64(stats.Swap.Used) / float64(stats.Swap.Total) * 100
+		}
+	}
+	trend.SwapUsage.Mean, trend.SwapUsage.StdDev = calculateStats(swapUsages)
+	trend.SwapUsage.Trend = t.slope(swapUsages)
+	trend.SwapUsage.Reasons = detectSwapThrashing(swapHistory, swapUsages, trend.SwapUsage.Trend)
+	trend.SwapUsage.Thrashing = len(trend.SwapUsage.Reasons) > 0
 
 	// Calculate process count trend
-	procCounts := make([]float64, len(t.history))
-	for i, stats := range t.history {
-		procCounts[i] = float64(len(stats.Processes))
+	procHistory := withSection(history, parser.SectionProcesses)
+	procCounts := make([]float64, len(procHistory))
+	for i, stats := range procHistory {
+		procCounts[i] = float64(stats.ProcessCount())
 	}
 	trend.ProcessCount.Mean, trend.ProcessCount.StdDev = calculateStats(procCounts)
-	trend.ProcessCount.Trend = calculateTrend(procCounts)
-	trend.ProcessCount.Anomaly = detectAnomalyWithThreshold(procCounts, trend.ProcessCount.Mean, trend.ProcessCount.StdDev, t.anomalyThreshold) ||
-		detectTrendAnomaly(trend.ProcessCount.Trend, t.trendThreshold)
+	trend.ProcessCount.Trend = t.slope(procCounts)
+	procMean, procStdDev := t.anomalyBaseline(metricProcesses, trend.ProcessCount.Mean, trend.ProcessCount.StdDev)
+	trend.ProcessCount.Reasons = t.anomalyReasons(procCounts, procMean, procStdDev, trend.ProcessCount.Trend)
+	trend.ProcessCount.Anomaly = len(trend.ProcessCount.Reasons) > 0
+
+	// Calculate 1 minute load average trend
+	loadHistory := withSection(history, parser.SectionLoad)
+	loads := make([]float64, len(loadHistory))
+	for i, stats := range loadHistory {
+		loads[i] = stats.LoadAverage.One
+	}
+	trend.LoadAverage.Mean, trend.LoadAverage.StdDev = calculateStats(loads)
+	trend.LoadAverage.Trend = t.slope(loads)
+	loadMean, loadStdDev := t.anomalyBaseline(metricLoad, trend.LoadAverage.Mean, trend.LoadAverage.StdDev)
+	trend.LoadAverage.Reasons = t.anomalyReasons(loads, loadMean, loadStdDev, trend.LoadAverage.Trend)
+	trend.LoadAverage.Anomaly = len(trend.LoadAverage.Reasons) > 0
+
+	// A temperature rising with the CPU usage is expected, not a trend anomaly
+	trend.Temperature.Warmup = t.suppressWarmup && cpuWarmup(history, t.interval)
 
 	// Calculate temperature trends for each sensor
 	allTemps := make([]float64, 0)
-	maxTemps := make([]float64, 0)
-	avgTemps := make([]float64, 0)
 
 	for name, temps := range t.tempHistory {
 		if len(temps) > 0 {
 			mean, stddev := calculateStats(temps)
-			trendValue := calculateTrend(temps)
+			trendValue := t.slope(temps)
 
 			// Check long-term trend if available
 			longTermTrend := 0.0
 			if longTermTemps, exists := t.longTermTempHistory[name]; exists && len(longTermTemps) > 10 {
-				longTermTrend = calculateTrend(longTermTemps)
+				longTermTrend = t.slope(longTermTemps)
 			}
 
 			sensorStats := struct {
@@ -283,7 +666,7 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				Mean:              mean,
 				StdDev:            stddev,
 				Trend:             trendValue,
-				AbsoluteThreshold: t.tempThreshold,
+				AbsoluteThreshold: t.sensorThreshold(history, name),
 				Max:               temps[0],
 				Min:               temps[0],
 			}
@@ -299,18 +682,16 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 			}
 
 			// Check if max temperature exceeds absolute threshold
-			sensorStats.ThresholdExceeded = sensorStats.Max > t.tempThreshold
+			sensorStats.ThresholdExceeded = sensorStats.Max > sensorStats.AbsoluteThreshold
 
-			// Detect anomalies using both Z-score and trend
-			sensorStats.Anomaly = detectAnomalyWithThreshold(temps, mean, stddev, t.anomalyThreshold) ||
-				detectTrendAnomaly(trendValue, t.trendThreshold) ||
-				detectTrendAnomaly(longTermTrend, t.trendThreshold*0.5) ||
+			// Detect anomalies using both the anomaly method and trend
+			sensorStats.Anomaly = len(t.anomalyReasons(temps, mean, stddev, 0)) > 0 ||
+				(!trend.Temperature.Warmup && detectTrendAnomaly(trendValue, t.trendThreshold)) ||
+				(!trend.Temperature.Warmup && detectTrendAnomaly(longTermTrend, t.trendThreshold*0.5)) ||
 				sensorStats.ThresholdExceeded
 
 			trend.Temperature.Sensors[name] = sensorStats
 			allTemps = append(allTemps, temps...)
-			maxTemps = append(maxTemps, sensorStats.Max)
-			avgTemps = append(avgTemps, sensorStats.Mean)
 
 			// Update overall temperature stats
 			if sensorStats.Max > trend.Temperature.Max {
@@ -330,7 +711,7 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 	// Calculate overall temperature stats from all sensor history
 	if len(allTemps) > 0 {
 		trend.Temperature.Mean, trend.Temperature.StdDev = calculateStats(allTemps)
-		tempTrendValue := calculateTrend(allTemps)
+		tempTrendValue := t.slope(allTemps)
 		trend.Temperature.Trend = tempTrendValue
 
 		// Calculate long-term trend for all temperatures combined
@@ -341,50 +722,50 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 
 		longTermTrend := 0.0
 		if len(allLongTermTemps) > 10 {
-			longTermTrend = calculateTrend(allLongTermTemps)
+			longTermTrend = t.slope(allLongTermTemps)
 		}
 
 		// Detect temperature anomalies using both methods and threshold check
-		trend.Temperature.Anomaly = detectAnomalyWithThreshold(allTemps, trend.Temperature.Mean, trend.Temperature.StdDev, t.anomalyThreshold) ||
-			detectTrendAnomaly(tempTrendValue, t.trendThreshold) ||
-			detectTrendAnomaly(longTermTrend, t.trendThreshold*0.5) || // More sensitive for long-term
-			trend.Temperature.ThresholdExceeded
-	}
-
-	// Calculate max and average from all sensors
-	if len(maxTemps) > 0 {
-		trend.Temperature.Max = maxTemps[0]
-		for _, temp := range maxTemps {
-			if temp > trend.Temperature.Max {
-				trend.Temperature.Max = temp
+		tempMean, tempStdDev := t.anomalyBaseline(metricTemperature, trend.Temperature.Mean, trend.Temperature.StdDev)
+		if trend.Temperature.Warmup {
+			// Only the trends are suppressed, a zero trend never fires
+			tempTrendValue, longTermTrend = 0, 0
+		}
+		trend.Temperature.Reasons = t.anomalyReasons(allTemps, tempMean, tempStdDev, tempTrendValue)
+		if detectTrendAnomaly(longTermTrend, t.trendThreshold*0.5) { // More sensitive for long-term
+			trend.Temperature.Reasons = append(trend.Temperature.Reasons,
+				fmt.Sprintf("long-term trend %.3f/s exceeded %.3f", longTermTrend, math.Copysign(t.trendThreshold*0.5, longTermTrend)))
+		}
+		names := make([]string, 0, len(trend.Temperature.Sensors))
+		for name := range trend.Temperature.Sensors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sensor := trend.Temperature.Sensors[name]; sensor.ThresholdExceeded {
+				trend.Temperature.Reasons = append(trend.Temperature.Reasons,
+					fmt.Sprintf("%s %.1f°C exceeded absolute threshold %.1f°C", name, sensor.Max, sensor.AbsoluteThreshold))
 			}
 		}
-		// Check if max temperature exceeds threshold
-		trend.Temperature.ThresholdExceeded = trend.Temperature.Max > t.tempThreshold
+		trend.Temperature.Anomaly = len(trend.Temperature.Reasons) > 0
 	}
 
-	if len(avgTemps) > 0 {
-		sum := 0.0
-		for _, temp := range avgTemps {
-			sum += temp
-		}
-		trend.Temperature.Mean = sum / float64(len(avgTemps))
-	}
+	trend.Temperature.Overall = t.overallTemp.Of(t.tempHistory)
 
 	// Calculate filesystem space trends
-	if len(t.history) > 0 && t.history[len(t.history)-1].Filesystem != nil {
+	if len(history) > 0 && history[len(history)-1].Filesystem != nil {
 		// Map to track partition history across time
 		fsHistory := make(map[string][]float64)
 
 		// First collect historical data for each partition
-		for _, stats := range t.history {
+		for _, stats := range history {
 			if stats.Filesystem == nil {
 				continue
 			}
 
 			for mountPoint, fs := range stats.Filesystem {
 				if _, exists := fsHistory[mountPoint]; !exists {
-					fsHistory[mountPoint] = make([]float64, 0, len(t.history))
+					fsHistory[mountPoint] = make([]float64, 0, len(history))
 				}
 
 				// Store free space percentage
@@ -395,15 +776,18 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 
 		// Now analyze each partition
 		for mountPoint, freeSpaceHistory := range fsHistory {
-			if len(freeSpaceHistory) < 2 {
+			// Get current filesystem stats
+			currentFs, present := history[len(history)-1].Filesystem[mountPoint]
+
+			// A partition that just appeared has no trend yet, but its
+			// instantaneous critical state is still evaluated
+			hasTrend := len(freeSpaceHistory) >= 2
+			if !hasTrend && !present {
 				continue
 			}
 
-			// Get current filesystem stats
-			currentFs := t.history[len(t.history)-1].Filesystem[mountPoint]
-
 			mean, stddev := calculateStats(freeSpaceHistory)
-			trendValue := calculateTrend(freeSpaceHistory)
+			trendValue := t.slope(freeSpaceHistory)
 			current := 100.0 - currentFs.UsedPct
 
 			// Find min/max free space
@@ -417,12 +801,19 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				}
 			}
 
-			// Detect anomalies
-			anomaly := detectAnomalyWithThreshold(freeSpaceHistory, mean, stddev, t.anomalyThreshold) ||
-				detectTrendAnomaly(trendValue, t.trendThreshold*2) // More sensitive for filesystem trends
+			// Detect anomalies, ignoring jitter within the deadband. Used
+			// rather than free space is scored, so that a percentile flags
+			// the partition filling up.
+			usedSpaceHistory := make([]float64, len(freeSpaceHistory))
+			for i, free := range freeSpaceHistory {
+				usedSpaceHistory[i] = 100 - free
+			}
+			anomaly := hasTrend && t.fsThresholds.Evaluated(currentFs.Size) && t.fsThresholds.Significant(min, max) &&
+				(len(t.anomalyReasons(usedSpaceHistory, 100-mean, stddev, 0)) > 0 ||
+					detectTrendAnomaly(trendValue, t.trendThreshold*2)) // More sensitive for filesystem trends
 
-			// Detect critical state (less than 10% free)
-			critical := current < 10.0
+			// Detect critical state (less than the critical free percent)
+			critical := t.fsThresholds.IsCritical(current, currentFs.Size)
 
 			// Store partition stats
 			partitionStats := struct {
@@ -436,6 +827,8 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				Critical   bool
 				Device     string
 				MountPoint string
+				Size       int64
+				TimeToFull time.Duration
 			}{
 				Mean:       mean,
 				StdDev:     stddev,
@@ -447,6 +840,10 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 				Critical:   critical,
 				Device:     currentFs.Device,
 				MountPoint: mountPoint,
+				Size:       currentFs.Size,
+			}
+			if hasTrend && t.fsThresholds.Evaluated(currentFs.Size) {
+				partitionStats.TimeToFull = timeToFull(current, trendValue)
 			}
 
 			trend.Filesystem.Partitions[mountPoint] = partitionStats
@@ -461,177 +858,251 @@ func (t *TrendAnalyzer) Analyze() *Trend {
 		}
 	}
 
+	// Report processes stuck in uninterruptible sleep
+	if t.stuckSamples > 0 {
+		for _, proc := range t.dStateSamples {
+			if proc.Samples >= t.stuckSamples {
+				trend.StuckProcesses = append(trend.StuckProcesses, proc)
+			}
+		}
+		sort.Slice(trend.StuckProcesses, func(i, j int) bool {
+			return trend.StuckProcesses[i].PID < trend.StuckProcesses[j].PID
+		})
+	}
+
+	// Report watched processes that are not running
+	for _, status := range parser.Watch(history[len(history)-1].Processes, t.watchlist) {
+		if !status.Present {
+			trend.MissingWatched = append(trend.MissingWatched, status.Pattern)
+		}
+	}
+
+	// Report critical processes that exited since the previous sample
+	previous := parser.Watch(history[len(history)-2].Processes, t.criticalWatch)
+	for i, status := range parser.Watch(history[len(history)-1].Processes, t.criticalWatch) {
+		if previous[i].Present && !status.Present {
+			trend.ExitedCritical = append(trend.ExitedCritical, status.Pattern)
+		}
+	}
+
+	// Report busy processes outside the expected set
+	if len(t.expected) > 0 {
+		trend.Unexpected = parser.Unexpected(history[len(history)-1].Processes, t.expected, t.expectedThresholds)
+	}
+
+	// Report sensors that stopped reporting
+	if t.lostSensorSamples > 0 {
+		for name, missing := range t.sensorMissing {
+			if missing >= t.lostSensorSamples {
+				trend.LostSensors = append(trend.LostSensors, LostSensor{Name: name, Samples: missing})
+			}
+		}
+		sort.Slice(trend.LostSensors, func(i, j int) bool {
+			return trend.LostSensors[i].Name < trend.LostSensors[j].Name
+		})
+	}
+
 	// Calculate system stress
-	trend.SystemStress = calculateSystemStress(trend)
+	trend.stressThreshold = t.stressThreshold
+	trend.fsThresholds = t.fsThresholds
+	trend.StressBreakdown = calculateSystemStress(trend)
+	trend.SystemStress = trend.StressBreakdown.Total()
 
 	return trend
 }
 
-func calculateSystemStress(trend *Trend) float64 {
-	risk := 0.0
+// Free space trends, in percent per second, from which a partition adds
+// stress as filling. They were 1 and 0.5 percent per sample at the default
+// 5s interval.
+const (
+	fsFillingFastRate = -0.2
+	fsFillingRate     = -0.1
+)
+
+// Stress a critical or low space partition adds, unless weighted by
+// -critical-partitions
+var (
+	partitionStress       = filesystem.PartitionStress{Critical: 15, Low: 5}
+	systemPartitionStress = map[string]filesystem.PartitionStress{
+		"/":     {Critical: 30, Low: 15},
+		"/boot": {Critical: 25, Low: 10},
+	}
+)
+
+// calculateSystemStress itemizes the factors of the system stress
+func calculateSystemStress(trend *Trend) stress.Breakdown {
+	var factors stress.Breakdown
 
 	// CPU stress factors
 	if trend.CPUUsage.Mean > 20 {
-		risk += 20
+		factors.Add("cpu", 20)
 	} else if trend.CPUUsage.Mean > 10 {
-		risk += 10
+		factors.Add("cpu", 10)
 	}
 
 	// Memory stress factors
 	if trend.MemoryUsage.Mean > 90 {
-		risk += 30
+		factors.Add("memory", 30)
 	} else if trend.MemoryUsage.Mean > 80 {
-		risk += 20
+		factors.Add("memory", 20)
 	} else if trend.MemoryUsage.Mean > 70 {
-		risk += 10
+		factors.Add("memory", 10)
 	}
 
 	// Process count stress factors
 	if trend.ProcessCount.Mean > 100 {
-		risk += 20
+		factors.Add("process count", 20)
 	} else if trend.ProcessCount.Mean > 50 {
-		risk += 10
+		factors.Add("process count", 10)
 	}
 
 	// Uninterruptible processes stress
 	if trend.ProcessCount.Anomaly {
-		risk += 20
+		factors.Add("process count anomaly", 20)
 	}
 
 	// High CPU processes stress
 	if trend.CPUUsage.Anomaly {
-		risk += 20
+		factors.Add("cpu anomaly", 20)
+	}
+
+	// Swap thrashing leaves the box nearly unusable
+	if trend.SwapUsage.Thrashing {
+		factors.Add("swap thrashing", 30)
+	}
+
+	// Rising load stress, before the load reaches a high absolute level
+	if trend.LoadAverage.Anomaly && trend.LoadAverage.Trend > 0 {
+		factors.Add("rising load", 10)
 	}
 
 	// Temperature stress - Operating range: -25°C to 75°C
 	// Use the ThresholdExceeded flag instead of hardcoded temperature limits
 	if trend.Temperature.ThresholdExceeded {
 		// Approaching the upper limit of operating range
-		risk += 50
-	} else if trend.Temperature.Max > 60 {
-		risk += 20
-	} else if trend.Temperature.Max > 50 {
-		risk += 10
-	} else if trend.Temperature.Max < -20 {
+		factors.Add("temperature threshold exceeded", 50)
+	} else if trend.Temperature.Overall > 60 {
+		factors.Add("temperature high", 20)
+	} else if trend.Temperature.Overall > 50 {
+		factors.Add("temperature high", 10)
+	} else if trend.Temperature.Overall < -20 {
 		// Approaching the lower limit of operating range
-		risk += 20
-	} else if trend.Temperature.Max < -10 {
-		risk += 10
+		factors.Add("temperature low", 20)
+	} else if trend.Temperature.Overall < -10 {
+		factors.Add("temperature low", 10)
 	}
 
 	// Add stress for temperature anomalies detected by trend analysis
 	if trend.Temperature.Anomaly && !trend.Temperature.ThresholdExceeded {
-		risk += 15 // Add some risk, but less than threshold violation
+		factors.Add("temperature anomaly", 15) // Add some risk, but less than threshold violation
 	}
 
 	// Filesystem stress factors
 	if trend.Filesystem.Critical {
-		// Critical disk space situation (less than 10% free on any partition)
-		risk += 40
+		// Critical disk space situation (below the critical free percent on any partition)
+		factors.Add("filesystem critical", 40)
 	} else if trend.Filesystem.Anomaly {
 		// Anomalous disk space trends detected
-		risk += 20
+		factors.Add("filesystem anomaly", 20)
 	}
 
-	// Add stress for individual critical partitions, especially root and boot
-	for mountPoint, fs := range trend.Filesystem.Partitions {
+	// Add stress for individual critical partitions, weighted so that e.g.
+	// root and boot count more
+	mounts := make([]string, 0, len(trend.Filesystem.Partitions))
+	for mountPoint := range trend.Filesystem.Partitions {
+		mounts = append(mounts, mountPoint)
+	}
+	sort.Strings(mounts)
+	for _, mountPoint := range mounts {
+		fs := trend.Filesystem.Partitions[mountPoint]
+		partition := trend.fsThresholds.Weights.Stress(mountPoint, partitionStress, systemPartitionStress)
 		if fs.Critical {
-			// Higher stress for critical system partitions
-			if mountPoint == "/" {
-				risk += 30 // Root partition critical
-			} else if mountPoint == "/boot" {
-				risk += 25 // Boot partition critical
-			} else {
-				risk += 15 // Other partition critical
-			}
-		} else if fs.Current < 20 {
-			// Warning level (less than 20% free)
-			if mountPoint == "/" {
-				risk += 15 // Root partition low
-			} else if mountPoint == "/boot" {
-				risk += 10 // Boot partition low
-			} else {
-				risk += 5 // Other partition low
-			}
+			factors.Add("partition "+mountPoint+" critical", partition.Critical)
+		} else if fs.Current < 20 && trend.fsThresholds.Evaluated(fs.Size) {
+			// Warning level (less than 20% free), unless too small to matter
+			factors.Add("partition "+mountPoint+" low", partition.Low)
 		}
 
 		// Negative trend in free space is also a concern
-		if fs.Trend < -1.0 {
+		if fs.Trend < fsFillingFastRate {
 			// Rapidly decreasing free space
-			risk += 15
-		} else if fs.Trend < -0.5 {
+			factors.Add("partition "+mountPoint+" filling fast", 15)
+		} else if fs.Trend < fsFillingRate {
 			// Moderately decreasing free space
-			risk += 5
+			factors.Add("partition "+mountPoint+" filling", 5)
 		}
 	}
 
-	return min(risk, 100.0)
+	return factors
 }
 
 func (t *TrendAnalyzer) SaveSnapshot(filename string) error {
+	return t.SaveSnapshotWithNote(filename, "")
+}
+
+// SaveSnapshotWithNote saves a snapshot annotated with a free-text operator
+// note, e.g. the context of a capture triggered during an incident
+func (t *TrendAnalyzer) SaveSnapshotWithNote(filename, note string) error {
 	// Create a copy of history with deduplicated processes to avoid redundancy in crash dumps
-	deduplicatedHistory := make([]*parser.SystemStats, len(t.history))
+	deduplicatedHistory := make([]*parser.SystemStats, t.history.Len())
 
 	// Deep copy with process deduplication
-	for i, stats := range t.history {
+	for i, stats := range t.history.Slice() {
 		// Copy the stats
 		newStats := &parser.SystemStats{
+			Timestamp:   stats.Timestamp,
+			Format:      stats.Format,
 			Memory:      stats.Memory,
+			Swap:        stats.Swap,
+			Tasks:       stats.Tasks,
+			Truncated:   stats.Truncated,
 			CPU:         stats.CPU,
+			PerCore:     stats.PerCore,
+			CPUScale:    stats.CPUScale,
+			CPUClamped:  stats.CPUClamped,
 			LoadAverage: stats.LoadAverage,
 			Temperature: stats.Temperature,
 			Filesystem:  make(map[string]parser.FilesystemStats),
-			// We'll deduplicate processes
-			Processes: make([]parser.Process, 0),
 		}
+		newStats.ParsedSections = stats.ParsedSections
 
 		// Copy filesystem stats
 		for mountPoint, fs := range stats.Filesystem {
 			newStats.Filesystem[mountPoint] = fs
 		}
 
-		// Deduplicate processes by command
-		processMap := make(map[string]parser.Process)
-		for _, proc := range stats.Processes {
-			if existingProc, ok := processMap[proc.Command]; ok {
-				// Keep the process with higher VSZPercent or CPU
-				if proc.VSZPercent > existingProc.VSZPercent || proc.CPUPercent > existingProc.CPUPercent {
-					processMap[proc.Command] = proc
-				}
-			} else {
-				processMap[proc.Command] = proc
-			}
-		}
-
-		// Add deduplicated processes back to the stats
-		for _, proc := range processMap {
-			newStats.Processes = append(newStats.Processes, proc)
-		}
+		// Deduplicate processes using the configured strategy
+		newStats.Processes = parser.DedupProcesses(stats.Processes, t.dedupStrategy)
 
 		deduplicatedHistory[i] = newStats
 	}
 
-	data := struct {
-		Timestamp time.Time
-		Stats     []*parser.SystemStats
-		Trend     *Trend
-		Summary   struct {
-			TotalStorage       int64
-			UsedStorage        int64
-			FreeStorage        int64
-			StorageUsagePct    float64
-			CriticalPartitions []string
-			LowSpacePartitions []string
-		}
-	}{
-		Timestamp: time.Now(),
-		Stats:     deduplicatedHistory,
-		Trend:     t.Analyze(),
+	data := Snapshot{
+		SchemaVersion: SnapshotVersion,
+		Timestamp:     time.Now(),
+		Instance:      instance.Current(),
+		Note:          note,
+		Config:        instance.Config(),
+		Stats:         deduplicatedHistory,
+		Trend:         t.analyze(),
 	}
 
 	// Calculate storage summary from latest stats
 	if len(deduplicatedHistory) > 0 {
 		latest := deduplicatedHistory[len(deduplicatedHistory)-1]
+		candidates := make([]parser.Process, 0, len(latest.Processes))
+		for _, proc := range latest.Processes {
+			if t.excludedPID == 0 || proc.PID != t.excludedPID {
+				candidates = append(candidates, proc)
+			}
+		}
+		data.Culprits.TopCPU = topProcesses(candidates, culpritCount, func(p parser.Process) float64 {
+			return p.CPUPercent
+		})
+		data.Culprits.TopMemory = topProcesses(candidates, culpritCount, func(p parser.Process) float64 {
+			return p.MemoryPercent()
+		})
+
 		data.Summary.TotalStorage = 0
 		data.Summary.UsedStorage = 0
 		data.Summary.FreeStorage = 0
@@ -651,10 +1122,15 @@ func (t *TrendAnalyzer) SaveSnapshot(filename string) error {
 
 		// Calculate overall storage usage percentage
 		if data.Summary.TotalStorage > 0 {
-			data.Summary.StorageUsagePct = float64(data.Summary.UsedStorage) / float64(data.Summary.TotalStorage) * 100
+			data.Summary.StorageUsagePct = round.To(float64(data.Summary.UsedStorage)/float64(data.Summary.TotalStorage)*100, t.precision)
 		}
 	}
 
+	roundTrend(data.Trend, t.precision)
+
+	// A NaN or Inf anywhere would make the marshal fail and lose the snapshot
+	sanitize.Floats(&data)
+
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal snapshot: %w", err)
@@ -671,6 +1147,52 @@ func (t *TrendAnalyzer) SaveSnapshot(filename string) error {
 	return nil
 }
 
+// roundTrend rounds the percentages of a trend to the given number of decimals
+func roundTrend(trend *Trend, decimals int) {
+	if trend == nil || decimals < 0 {
+		return
+	}
+	trend.CPUUsage.Mean = round.To(trend.CPUUsage.Mean, decimals)
+	trend.CPUUsage.StdDev = round.To(trend.CPUUsage.StdDev, decimals)
+	trend.CPUUsage.Trend = round.To(trend.CPUUsage.Trend, decimals)
+	trend.MemoryUsage.Mean = round.To(trend.MemoryUsage.Mean, decimals)
+	trend.MemoryUsage.StdDev = round.To(trend.MemoryUsage.StdDev, decimals)
+	trend.MemoryUsage.Trend = round.To(trend.MemoryUsage.Trend, decimals)
+	trend.SwapUsage.Mean = round.To(trend.SwapUsage.Mean, decimals)
+	trend.SwapUsage.StdDev = round.To(trend.SwapUsage.StdDev, decimals)
+	trend.SwapUsage.Trend = round.To(trend.SwapUsage.Trend, decimals)
+	trend.SystemStress = round.To(trend.SystemStress, decimals)
+	for mountPoint, fs := range trend.Filesystem.Partitions {
+		fs.Mean = round.To(fs.Mean, decimals)
+		fs.StdDev = round.To(fs.StdDev, decimals)
+		fs.Trend = round.To(fs.Trend, decimals)
+		fs.Min = round.To(fs.Min, decimals)
+		fs.Max = round.To(fs.Max, decimals)
+		fs.Current = round.To(fs.Current, decimals)
+		trend.Filesystem.Partitions[mountPoint] = fs
+	}
+}
+
+// culpritCount is the number of top CPU and memory processes in a snapshot
+const culpritCount = 5
+
+// topProcesses returns up to n processes with the highest non-zero usage
+func topProcesses(procs []parser.Process, n int, usage func(parser.Process) float64) []parser.Process {
+	sorted := make([]parser.Process, 0, len(procs))
+	for _, proc := range procs {
+		if usage(proc) > 0 {
+			sorted = append(sorted, proc)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return usage(sorted[i]) > usage(sorted[j])
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
 func calculateStats(values []float64) (mean, stdDev float64) {
 	if len(values) == 0 {
 		return 0, 0
@@ -694,6 +1216,34 @@ func calculateStats(values []float64) (mean, stdDev float64) {
 	return mean, stdDev
 }
 
+// withSection returns the samples of history whose top output had section,
+// so samples cut short don't pull its metrics towards zero
+func withSection(history []*parser.SystemStats, section parser.Section) []*parser.SystemStats {
+	filtered := make([]*parser.SystemStats, 0, len(history))
+	for _, stats := range history {
+		if stats.Has(section) {
+			filtered = append(filtered, stats)
+		}
+	}
+	return filtered
+}
+
+// slope returns the trend of values sampled every interval, per second
+func (t *TrendAnalyzer) slope(values []float64) float64 {
+	return Slope(values, t.interval)
+}
+
+// Slope returns the least squares trend of values sampled every interval,
+// per second, so it means the same whatever the sampling interval. A zero
+// or negative interval is taken as DefaultInterval.
+func Slope(values []float64, interval time.Duration) float64 {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return calculateTrend(values) / interval.Seconds()
+}
+
+// calculateTrend returns the slope of values per sample
 func calculateTrend(values []float64) float64 {
 	if len(values) < 2 {
 		return 0
@@ -728,6 +1278,111 @@ func detectAnomalyWithThreshold(values []float64, mean, stdDev, threshold float6
 	return zScore > threshold || zScore < -threshold
 }
 
+// anomalyReasons explains why the latest value is an anomaly based on the
+// anomaly method and the trend of the values. It returns nil when there is
+// none.
+func (t *TrendAnalyzer) anomalyReasons(values []float64, mean, stdDev, trend float64) []string {
+	var reasons []string
+	switch t.anomalyMethod {
+	case AnomalyPercentile:
+		if limit, ok := detectPercentileAnomaly(values, t.anomalyPercentile); ok {
+			reasons = append(reasons, fmt.Sprintf("value %.1f exceeded p%g %.1f", values[len(values)-1], t.anomalyPercentile, limit))
+		}
+	default:
+		if detectAnomalyWithThreshold(values, mean, stdDev, t.anomalyThreshold) {
+			zScore := (values[len(values)-1] - mean) / stdDev
+			reasons = append(reasons, fmt.Sprintf("z-score %.1f exceeded %.1f", zScore, math.Copysign(t.anomalyThreshold, zScore)))
+		}
+	}
+	if detectTrendAnomaly(trend, t.trendThreshold) {
+		reasons = append(reasons, fmt.Sprintf("trend %.3f/s exceeded %.3f", trend, math.Copysign(t.trendThreshold, trend)))
+	}
+	return reasons
+}
+
+// cacheCollapseRatio is the relative drop of buff/cache below its baseline
+// that counts as a collapse
+const cacheCollapseRatio = 0.5
+
+// detectCacheCollapse reports whether buff/cache in the latest sample fell
+// well below its mean over the rest of the window while used memory rose
+// above its own mean, an early sign of the kernel reclaiming cache before OOM
+func detectCacheCollapse(history []*parser.SystemStats) bool {
+	if len(history) < 2 {
+		return false
+	}
+
+	latest := history[len(history)-1]
+	cacheSum, usedSum := 0.0, 0.0
+	for _, stats := range history[:len(history)-1] {
+		cacheSum += float64(stats.Memory.Buffers + stats.Memory.Cached)
+		usedSum += float64(stats.Memory.Used)
+	}
+	n := float64(len(history) - 1)
+	cacheBaseline, usedBaseline := cacheSum/n, usedSum/n
+
+	cache := float64(latest.Memory.Buffers + latest.Memory.Cached)
+	return cacheBaseline > 0 &&
+		cache < cacheBaseline*(1-cacheCollapseRatio) &&
+		float64(latest.Memory.Used) > usedBaseline
+}
+
+const (
+	swapIOWaitHigh   = 20.0 // Mean iowait percentage that makes swap activity thrashing
+	swapGrowthRate   = 0.2  // Swap used growth in percent per second that counts as sustained
+	swapSwingPercent = 5.0  // Swap used change in percent that counts as a swing
+	swapOscillations = 3    // Direction changes of large swings that count as oscillation
+)
+
+// detectSwapThrashing returns why swap used, given as percentages of the
+// swap space, indicates thrashing: sustained growth or rapid oscillation of
+// swap used while the mean iowait over the window is high
+func detectSwapThrashing(history []*parser.SystemStats, swapUsages []float64, swapTrend float64) []string {
+	if len(history) < 2 || history[len(history)-1].Swap.Total == 0 {
+		return nil
+	}
+
+	ioWait := 0.0
+	for _, stats := range history {
+		ioWait += stats.CPU.IO
+	}
+	ioWait /= float64(len(history))
+	if ioWait < swapIOWaitHigh {
+		return nil
+	}
+
+	var reasons []string
+	if swapTrend > swapGrowthRate {
+		reasons = append(reasons, fmt.Sprintf("swap used growing %.3f%%/s with %.1f%% iowait", swapTrend, ioWait))
+	}
+
+	swings, direction := 0, 0.0
+	for i := 1; i < len(swapUsages); i++ {
+		delta := swapUsages[i] - swapUsages[i-1]
+		if math.Abs(delta) < swapSwingPercent {
+			continue
+		}
+		if direction != 0 && (delta > 0) != (direction > 0) {
+			swings++
+		}
+		direction = delta
+	}
+	if swings >= swapOscillations {
+		reasons = append(reasons, fmt.Sprintf("swap used oscillated %d times with %.1f%% iowait", swings, ioWait))
+	}
+	return reasons
+}
+
+// timeToFull extrapolates the free space percentage current, falling by
+// trend percent per second, to the time it reaches 0. It returns 0 when the
+// free space is not falling.
+func timeToFull(current, trend float64) time.Duration {
+	if trend >= 0 || current <= 0 {
+		return 0
+	}
+	return time.Duration(current / -trend * float64(time.Second))
+}
+
 // detectTrendAnomaly checks if the trend (slope) exceeds the given threshold
 func detectTrendAnomaly(trend float64, trendThreshold float64) bool {
 	return math.Abs(trend) > trendThreshold
@@ -742,18 +1397,25 @@ func sqrt(x float64) float64 {
 	return z
 }
 
-func min(a, b float64) float64 {
-	if a < b {
-		return a
+// TrimHistory releases memory by dropping all but the keep most recent
+// samples from the stats and temperature histories
+func (t *TrendAnalyzer) TrimHistory(keep int) {
+	t.history.Trim(keep)
+	for name, temps := range t.tempHistory {
+		if len(temps) > keep {
+			t.tempHistory[name] = append([]float64(nil), temps[len(temps)-keep:]...)
+		}
+	}
+	for name, temps := range t.longTermTempHistory {
+		if len(temps) > keep {
+			t.longTermTempHistory[name] = append([]float64(nil), temps[len(temps)-keep:]...)
+		}
 	}
-	return b
 }
 
 func (t *TrendAnalyzer) GetCurrentStats() *parser.SystemStats {
-	if len(t.history) == 0 {
-		return nil
-	}
-	return t.history[len(t.history)-1]
+	stats, _ := t.history.Last()
+	return stats
 }
 
 // GetTempHistory returns the temperature history.
@@ -763,7 +1425,7 @@ func (t *TrendAnalyzer) GetTempHistory() map[string][]float64 {
 }
 
 // GetHistory returns the system stats history.
-// It provides access to the t.history field.
+// It returns a copy of the t.history buffer in chronological order.
 func (t *TrendAnalyzer) GetHistory() []*parser.SystemStats {
-	return t.history
+	return t.history.Slice()
 }
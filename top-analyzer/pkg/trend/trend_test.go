@@ -0,0 +1,716 @@
+package trend
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/filesystem"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/stress"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+// loadSample returns stats with a CPU usage and a 1 minute load average
+func loadSample(cpu, load float64) *parser.SystemStats {
+	return &parser.SystemStats{
+		CPU:         parser.CPU{User: cpu},
+		LoadAverage: parser.LoadAverage{One: load},
+		ParsedSections: map[parser.Section]bool{
+			parser.SectionCPU:  true,
+			parser.SectionLoad: true,
+		},
+	}
+}
+
+// TestAnalyzeSlidingWindow checks that the analysis after more samples than
+// the window only depends on the last window of them, as with the sliding
+// slice the ring buffer replaced
+func TestAnalyzeSlidingWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  int
+		samples int
+	}{
+		{name: "window not full", window: 10, samples: 6},
+		{name: "window just full", window: 10, samples: 10},
+		{name: "wrapped", window: 5, samples: 23},
+		{name: "smallest window", window: 2, samples: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var samples []*parser.SystemStats
+			for i := 0; i < tt.samples; i++ {
+				samples = append(samples, loadSample(float64(i*7%40), float64(i%5)/2))
+			}
+			full := New(tt.window)
+			for _, stats := range samples {
+				full.AddStats(stats)
+			}
+			last := samples[max(len(samples)-tt.window, 0):]
+			windowed := New(tt.window)
+			for _, stats := range last {
+				windowed.AddStats(stats)
+			}
+
+			if got := full.GetHistory(); !reflect.DeepEqual(got, last) {
+				t.Errorf("GetHistory() has %d samples, want the last %d", len(got), len(last))
+			}
+			got, want := full.Analyze(), windowed.Analyze()
+			if got.CPUUsage.Mean != want.CPUUsage.Mean || got.CPUUsage.StdDev != want.CPUUsage.StdDev || got.CPUUsage.Trend != want.CPUUsage.Trend {
+				t.Errorf("CPU usage = %+v, want %+v", got.CPUUsage, want.CPUUsage)
+			}
+			if got.LoadAverage.Mean != want.LoadAverage.Mean || got.LoadAverage.StdDev != want.LoadAverage.StdDev || got.LoadAverage.Trend != want.LoadAverage.Trend {
+				t.Errorf("load average = %+v, want %+v", got.LoadAverage, want.LoadAverage)
+			}
+		})
+	}
+}
+
+func TestAnomalyReasons(t *testing.T) {
+	tempSample := func(temp float64) *parser.SystemStats {
+		stats := loadSample(10, 0.5)
+		stats.Temperature.Sensors = map[string]float64{"cpu": temp}
+		return stats
+	}
+
+	tests := []struct {
+		name        string
+		samples     []*parser.SystemStats
+		reasons     func(trend *Trend) []string
+		wantReasons []string
+	}{
+		{
+			name: "absolute threshold breach",
+			samples: []*parser.SystemStats{
+				tempSample(72), tempSample(72), tempSample(72), tempSample(72),
+			},
+			reasons:     func(trend *Trend) []string { return trend.Temperature.Reasons },
+			wantReasons: []string{"cpu 72.0°C exceeded absolute threshold 70.0°C"},
+		},
+		{
+			name: "z-score breach",
+			samples: []*parser.SystemStats{
+				loadSample(10, 0.5), loadSample(10, 0.5), loadSample(10, 0.5), loadSample(10, 0.5), loadSample(10, 0.5),
+				loadSample(10, 0.5), loadSample(10, 0.5), loadSample(10, 0.5), loadSample(10, 0.5), loadSample(90, 0.5),
+			},
+			reasons:     func(trend *Trend) []string { return trend.CPUUsage.Reasons },
+			wantReasons: []string{"z-score 3.0 exceeded 2.0"},
+		},
+		{
+			name: "no breach",
+			samples: []*parser.SystemStats{
+				tempSample(50), tempSample(50), tempSample(50), tempSample(50),
+			},
+			reasons:     func(trend *Trend) []string { return trend.Temperature.Reasons },
+			wantReasons: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A trend threshold out of reach leaves the z-score and the
+			// absolute threshold as the only causes
+			analyzer := NewWithFullOptions(10, 2, 1000, 70, 10)
+			for _, stats := range tt.samples {
+				analyzer.AddStats(stats)
+			}
+			if got := tt.reasons(analyzer.Analyze()); !reflect.DeepEqual(got, tt.wantReasons) {
+				t.Errorf("Reasons = %q, want %q", got, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestStuckProcesses(t *testing.T) {
+	type sample struct {
+		state   string
+		command string
+	}
+	d := sample{state: "D", command: "rsync /mnt/nfs"}
+
+	tests := []struct {
+		name         string
+		stuckSamples int
+		samples      []sample
+		want         []StuckProcess
+		wantProblem  string
+	}{
+		{
+			name:         "in D state across the limit",
+			stuckSamples: 3,
+			samples:      []sample{d, d, d, d},
+			want:         []StuckProcess{{PID: 42, Command: "rsync /mnt/nfs", Samples: 4}},
+			wantProblem:  "Process rsync /mnt/nfs (PID: 42) stuck in uninterruptible sleep for 4 samples",
+		},
+		{
+			name:         "below the limit",
+			stuckSamples: 3,
+			samples:      []sample{d, d},
+		},
+		{
+			name:         "woke up in between",
+			stuckSamples: 3,
+			samples:      []sample{d, d, {state: "S", command: "rsync /mnt/nfs"}, d},
+		},
+		{
+			name:         "PID reused by another command",
+			stuckSamples: 3,
+			samples:      []sample{d, d, {state: "D", command: "cp /mnt/nfs"}},
+		},
+		{
+			name:    "disabled",
+			samples: []sample{d, d, d, d},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetStuckProcessSamples(tt.stuckSamples)
+			for _, s := range tt.samples {
+				stats := loadSample(10, 0.5)
+				stats.Processes = []parser.Process{
+					{PID: 1, State: "S", Command: "init"},
+					{PID: 42, State: s.state, Command: s.command},
+				}
+				analyzer.AddStats(stats)
+			}
+
+			trend := analyzer.Analyze()
+			if !reflect.DeepEqual(trend.StuckProcesses, tt.want) {
+				t.Errorf("StuckProcesses = %+v, want %+v", trend.StuckProcesses, tt.want)
+			}
+			var problems []string
+			for _, problem := range trend.ProblemDetails() {
+				if problem.Source == "processes" {
+					problems = append(problems, problem.Message)
+				}
+			}
+			if tt.wantProblem == "" && len(problems) > 0 {
+				t.Errorf("problems = %q, want none", problems)
+			}
+			if tt.wantProblem != "" && !reflect.DeepEqual(problems, []string{tt.wantProblem}) {
+				t.Errorf("problems = %q, want %q", problems, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestCacheCollapse(t *testing.T) {
+	const gb = 1 << 30
+	memory := func(used, cache int64) *parser.SystemStats {
+		return &parser.SystemStats{
+			Memory:         parser.Memory{Total: 8 * gb, Used: used, Cached: cache, Free: 8*gb - used - cache},
+			ParsedSections: map[parser.Section]bool{parser.SectionMemory: true},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		samples []*parser.SystemStats
+		want    bool
+	}{
+		{
+			name:    "cache collapses while used climbs",
+			samples: []*parser.SystemStats{memory(2*gb, 4*gb), memory(2*gb, 4*gb), memory(2*gb, 4*gb), memory(5*gb, gb)},
+			want:    true,
+		},
+		{
+			name:    "cache shrinks gradually",
+			samples: []*parser.SystemStats{memory(2*gb, 4*gb), memory(2*gb, 3500<<20), memory(2*gb, 3*gb), memory(2500<<20, 2600<<20)},
+			want:    false,
+		},
+		{
+			name:    "cache dropped while used fell",
+			samples: []*parser.SystemStats{memory(4*gb, 3*gb), memory(4*gb, 3*gb), memory(2*gb, gb)},
+			want:    false,
+		},
+		{
+			name:    "steady",
+			samples: []*parser.SystemStats{memory(2*gb, 4*gb), memory(2*gb, 4*gb), memory(2*gb, 4*gb)},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			for _, stats := range tt.samples {
+				analyzer.AddStats(stats)
+			}
+			trend := analyzer.Analyze()
+			if trend.MemoryUsage.CacheCollapse != tt.want {
+				t.Errorf("CacheCollapse = %v, want %v", trend.MemoryUsage.CacheCollapse, tt.want)
+			}
+			alerted := slices.Contains(trend.Problems(), "Memory pressure: buff/cache collapsed while used memory climbed")
+			if alerted != tt.want {
+				t.Errorf("cache collapse problem reported = %v, want %v", alerted, tt.want)
+			}
+		})
+	}
+}
+
+// TestFilesystemCriticalConsistent checks that the trend agrees with the
+// filesystem reader on which partitions are critical under the same
+// thresholds
+func TestFilesystemCriticalConsistent(t *testing.T) {
+	const size = 10 << 30
+
+	tests := []struct {
+		name         string
+		criticalFree float64
+		want         bool
+	}{
+		{name: "default 10% critical free", criticalFree: 10, want: false},
+		{name: "15% critical free", criticalFree: 15, want: true},
+		{name: "12% critical free", criticalFree: 12, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := filesystem.DefaultThresholds
+			thresholds.CriticalFreePercent = tt.criticalFree
+			analyzer := New(10)
+			analyzer.SetFilesystemThresholds(thresholds)
+
+			// 12% free, flagged the way the filesystem reader does
+			readerCritical := thresholds.IsCritical(12, size)
+			for i := 0; i < 3; i++ {
+				stats := loadSample(10, 0.5)
+				stats.Filesystem = map[string]parser.FilesystemStats{
+					"/": {Device: "/dev/sda1", Size: size, UsedPct: 88, MountPoint: "/", Critical: readerCritical},
+				}
+				analyzer.AddStats(stats)
+			}
+
+			trend := analyzer.Analyze()
+			if readerCritical != tt.want {
+				t.Errorf("filesystem critical = %v, want %v", readerCritical, tt.want)
+			}
+			if got := trend.Filesystem.Partitions["/"].Critical; got != tt.want {
+				t.Errorf("trend partition critical = %v, want %v", got, tt.want)
+			}
+			if trend.Filesystem.Critical != tt.want {
+				t.Errorf("trend filesystem critical = %v, want %v", trend.Filesystem.Critical, tt.want)
+			}
+		})
+	}
+}
+
+func TestLostSensors(t *testing.T) {
+	both := map[string]float64{"cpu": 50, "board": 40}
+	cpuOnly := map[string]float64{"cpu": 50}
+
+	tests := []struct {
+		name        string
+		lostSamples int
+		sensors     []map[string]float64
+		want        []LostSensor
+		wantProblem string
+	}{
+		{
+			name:        "sensor vanished",
+			lostSamples: 3,
+			sensors:     []map[string]float64{both, both, cpuOnly, cpuOnly, cpuOnly},
+			want:        []LostSensor{{Name: "board", Samples: 3}},
+			wantProblem: "Sensor board lost: missing for 3 samples",
+		},
+		{
+			name:        "missing for fewer samples",
+			lostSamples: 3,
+			sensors:     []map[string]float64{both, both, cpuOnly, cpuOnly},
+		},
+		{
+			name:        "sensor came back",
+			lostSamples: 2,
+			sensors:     []map[string]float64{both, cpuOnly, cpuOnly, both},
+		},
+		{
+			name:        "every sensor vanished",
+			lostSamples: 2,
+			sensors:     []map[string]float64{both, {}, {}},
+			want:        []LostSensor{{Name: "board", Samples: 2}, {Name: "cpu", Samples: 2}},
+			wantProblem: "Sensor board lost: missing for 2 samples",
+		},
+		{
+			name:    "disabled",
+			sensors: []map[string]float64{both, cpuOnly, cpuOnly, cpuOnly},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			analyzer.SetLostSensorSamples(tt.lostSamples)
+			for _, sensors := range tt.sensors {
+				stats := loadSample(10, 0.5)
+				stats.Temperature.Sensors = sensors
+				analyzer.AddStats(stats)
+			}
+
+			trend := analyzer.Analyze()
+			if !reflect.DeepEqual(trend.LostSensors, tt.want) {
+				t.Errorf("LostSensors = %+v, want %+v", trend.LostSensors, tt.want)
+			}
+			if tt.wantProblem != "" && !slices.Contains(trend.Problems(), tt.wantProblem) {
+				t.Errorf("problems = %q, want %q among them", trend.Problems(), tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestNewPartitionCritical(t *testing.T) {
+	const size = 32 << 30
+	partition := func(usedPct float64) parser.FilesystemStats {
+		return parser.FilesystemStats{Device: "/dev/sdb1", Size: size, UsedPct: usedPct, MountPoint: "/mnt/usb"}
+	}
+
+	tests := []struct {
+		name         string
+		samples      []float64 // Used percentage of the new partition, 0 when absent
+		wantPresent  bool
+		wantCritical bool
+	}{
+		{name: "critical in its first sample", samples: []float64{0, 0, 95}, wantPresent: true, wantCritical: true},
+		{name: "healthy in its first sample", samples: []float64{0, 0, 50}, wantPresent: true, wantCritical: false},
+		{name: "critical with history", samples: []float64{0, 94, 95}, wantPresent: true, wantCritical: true},
+		{name: "seen once and gone", samples: []float64{0, 95, 0}},
+		{name: "never seen", samples: []float64{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			for _, usedPct := range tt.samples {
+				stats := loadSample(10, 0.5)
+				stats.Filesystem = map[string]parser.FilesystemStats{
+					"/": {Device: "/dev/sda1", Size: size, UsedPct: 50, MountPoint: "/"},
+				}
+				if usedPct > 0 {
+					stats.Filesystem["/mnt/usb"] = partition(usedPct)
+				}
+				analyzer.AddStats(stats)
+			}
+
+			trend := analyzer.Analyze()
+			usb, present := trend.Filesystem.Partitions["/mnt/usb"]
+			if present != tt.wantPresent {
+				t.Fatalf("partition in trend = %v, want %v", present, tt.wantPresent)
+			}
+			if usb.Critical != tt.wantCritical {
+				t.Errorf("partition critical = %v, want %v", usb.Critical, tt.wantCritical)
+			}
+			if trend.Filesystem.Critical != tt.wantCritical {
+				t.Errorf("filesystem critical = %v, want %v", trend.Filesystem.Critical, tt.wantCritical)
+			}
+		})
+	}
+}
+
+func TestLoadAverageTrend(t *testing.T) {
+	tests := []struct {
+		name           string
+		step           float64 // change of the load between samples
+		wantAnomaly    bool
+		wantRisingLoad bool
+	}{
+		{name: "steadily rising", step: 0.5, wantAnomaly: true, wantRisingLoad: true},
+		{name: "slowly rising", step: 0.05},
+		{name: "flat", step: 0},
+		{name: "steadily falling", step: -0.5, wantAnomaly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			for i := 0; i < 10; i++ {
+				analyzer.AddStats(loadSample(10, 5+tt.step*float64(i)))
+			}
+			trend := analyzer.Analyze()
+
+			if got := trend.LoadAverage.Anomaly; got != tt.wantAnomaly {
+				t.Errorf("load average anomaly = %v (reasons %q), want %v", got, trend.LoadAverage.Reasons, tt.wantAnomaly)
+			}
+			if tt.step != 0 && (trend.LoadAverage.Trend > 0) != (tt.step > 0) {
+				t.Errorf("load average trend = %v, want the sign of %v", trend.LoadAverage.Trend, tt.step)
+			}
+			risingLoad := slices.ContainsFunc(trend.StressBreakdown, func(f stress.Factor) bool {
+				return f.Name == "rising load"
+			})
+			if risingLoad != tt.wantRisingLoad {
+				t.Errorf("rising load stress factor = %v, want %v (breakdown %v)", risingLoad, tt.wantRisingLoad, trend.StressBreakdown)
+			}
+		})
+	}
+}
+
+func TestSensorTripPointThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		trips         map[string]float64
+		wantThreshold float64
+		wantExceeded  bool
+	}{
+		{name: "trip point above the reading", trips: map[string]float64{"cpu-thermal": 95}, wantThreshold: 95},
+		{name: "trip point below the reading", trips: map[string]float64{"cpu-thermal": 75}, wantThreshold: 75, wantExceeded: true},
+		{name: "no trip point falls back to the global threshold", wantThreshold: 70, wantExceeded: true},
+		{name: "trip point of another sensor", trips: map[string]float64{"gpu-thermal": 95}, wantThreshold: 70, wantExceeded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewWithFullOptions(10, 2, 1000, 70, 10)
+			for i := 0; i < 3; i++ {
+				stats := loadSample(10, 0.5)
+				stats.Temperature.Sensors = map[string]float64{"cpu-thermal": 80}
+				stats.Temperature.Thresholds = tt.trips
+				analyzer.AddStats(stats)
+			}
+			sensor := analyzer.Analyze().Temperature.Sensors["cpu-thermal"]
+
+			if sensor.AbsoluteThreshold != tt.wantThreshold {
+				t.Errorf("AbsoluteThreshold = %v, want %v", sensor.AbsoluteThreshold, tt.wantThreshold)
+			}
+			if sensor.ThresholdExceeded != tt.wantExceeded {
+				t.Errorf("ThresholdExceeded = %v, want %v", sensor.ThresholdExceeded, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestSwapThrashing(t *testing.T) {
+	growing := []float64{10, 15, 20, 25, 30, 35}
+	oscillating := []float64{10, 30, 10, 30, 10, 30, 10}
+
+	tests := []struct {
+		name          string
+		swapUsed      []float64 // percent of the swap space
+		iowait        float64
+		wantThrashing bool
+		wantReason    string
+	}{
+		{name: "growth with high iowait", swapUsed: growing, iowait: 40, wantThrashing: true, wantReason: "swap used growing"},
+		{name: "oscillation with high iowait", swapUsed: oscillating, iowait: 40, wantThrashing: true, wantReason: "swap used oscillated 5 times"},
+		{name: "growth with low iowait", swapUsed: growing, iowait: 5},
+		{name: "steady swap with high iowait", swapUsed: []float64{30, 30, 30, 30, 30, 30}, iowait: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			for _, used := range tt.swapUsed {
+				analyzer.AddStats(&parser.SystemStats{
+					CPU:  parser.CPU{User: 10, IO: tt.iowait},
+					Swap: parser.Swap{Total: 1000, Used: int64(used * 10), Free: 1000 - int64(used*10)},
+				})
+			}
+			trend := analyzer.Analyze()
+
+			if trend.SwapUsage.Thrashing != tt.wantThrashing {
+				t.Errorf("Thrashing = %v (reasons %q), want %v", trend.SwapUsage.Thrashing, trend.SwapUsage.Reasons, tt.wantThrashing)
+			}
+			if tt.wantReason != "" && (len(trend.SwapUsage.Reasons) == 0 || !strings.HasPrefix(trend.SwapUsage.Reasons[0], tt.wantReason)) {
+				t.Errorf("Reasons = %q, want %q first", trend.SwapUsage.Reasons, tt.wantReason)
+			}
+
+			var critical bool
+			for _, problem := range trend.ProblemDetails() {
+				if problem.Source == "swap" {
+					critical = problem.Critical
+				}
+			}
+			if critical != tt.wantThrashing {
+				t.Errorf("critical swap problem = %v, want %v", critical, tt.wantThrashing)
+			}
+		})
+	}
+}
+
+func TestTempWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      int
+		tempWindow  int
+		samples     int
+		wantHistory int
+		wantTemps   int
+	}{
+		{name: "longer temperature window", window: 5, tempWindow: 20, samples: 30, wantHistory: 5, wantTemps: 20},
+		{name: "shorter temperature window", window: 10, tempWindow: 3, samples: 12, wantHistory: 10, wantTemps: 3},
+		{name: "temperature window not full", window: 5, tempWindow: 20, samples: 8, wantHistory: 5, wantTemps: 8},
+		{name: "history window shared", window: 5, tempWindow: 0, samples: 30, wantHistory: 5, wantTemps: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(tt.window)
+			analyzer.SetTempWindow(tt.tempWindow)
+			for i := 0; i < tt.samples; i++ {
+				analyzer.AddStats(&parser.SystemStats{
+					Temperature: temperature.TemperatureStats{Sensors: map[string]float64{"cpu": 40 + float64(i%3)}},
+				})
+			}
+
+			if got := len(analyzer.GetHistory()); got != tt.wantHistory {
+				t.Errorf("history = %d samples, want %d", got, tt.wantHistory)
+			}
+			if got := len(analyzer.GetTempHistory()["cpu"]); got != tt.wantTemps {
+				t.Errorf("temperature history = %d samples, want %d", got, tt.wantTemps)
+			}
+		})
+	}
+}
+
+func TestTrendPerSecond(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		rate     float64 // CPU change per second
+		want     float64 // Trend of CPU usage per second
+	}{
+		{name: "1s interval", interval: time.Second, rate: 1, want: 1},
+		{name: "5s interval", interval: 5 * time.Second, rate: 1, want: 1},
+		{name: "1 minute interval", interval: time.Minute, rate: 0.1, want: 0.1},
+		{name: "falling at 5s interval", interval: 5 * time.Second, rate: -0.5, want: -0.5},
+		{name: "default interval", rate: 1, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(10)
+			interval := tt.interval
+			if interval != 0 {
+				analyzer.SetInterval(interval)
+			} else {
+				interval = DefaultInterval
+			}
+			for i := 0; i < 10; i++ {
+				analyzer.AddStats(cpuSample(50 + tt.rate*interval.Seconds()*float64(i)))
+			}
+
+			if got := analyzer.Analyze().CPUUsage.Trend; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CPU trend = %v/s, want %v/s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilesystemDeadband(t *testing.T) {
+	steady := []float64{50, 50, 50, 50, 50, 50, 50, 50, 50}
+	jitter := []float64{50, 50.3, 49.7, 50, 50.3, 49.7, 50, 50.3, 49.7, 50}
+
+	tests := []struct {
+		name        string
+		deadband    float64
+		free        []float64 // Free space percentages in turn
+		wantAnomaly bool
+	}{
+		{name: "steady then a 0.3% move", deadband: 1, free: append(steady, 49.7), wantAnomaly: false},
+		{name: "steady then a 0.3% move without deadband", deadband: 0, free: append(steady, 49.7), wantAnomaly: true},
+		{name: "jitter of 0.3%", deadband: 1, free: jitter, wantAnomaly: false},
+		{name: "steady then a 5% drop", deadband: 1, free: append(steady, 45), wantAnomaly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := filesystem.DefaultThresholds
+			thresholds.AnomalyDeadband = tt.deadband
+			analyzer := New(10)
+			analyzer.SetFilesystemThresholds(thresholds)
+			for _, free := range tt.free {
+				analyzer.AddStats(&parser.SystemStats{Filesystem: map[string]parser.FilesystemStats{
+					"/": {Device: "/dev/sda1", Size: 50 << 30, UsedPct: 100 - free, MountPoint: "/"},
+				}})
+			}
+
+			trend := analyzer.Analyze()
+			if got := trend.Filesystem.Partitions["/"].Anomaly; got != tt.wantAnomaly {
+				t.Errorf("partition anomaly = %v, want %v", got, tt.wantAnomaly)
+			}
+			if trend.Filesystem.Anomaly != tt.wantAnomaly {
+				t.Errorf("filesystem anomaly = %v, want %v", trend.Filesystem.Anomaly, tt.wantAnomaly)
+			}
+		})
+	}
+}
+
+func TestWindowFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		interval  time.Duration
+		want      int
+	}{
+		{name: "24h at 5s", retention: 24 * time.Hour, interval: 5 * time.Second, want: 17280},
+		{name: "24h at 1m", retention: 24 * time.Hour, interval: time.Minute, want: 1440},
+		{name: "partial interval rounds up", retention: 7 * time.Second, interval: 5 * time.Second, want: 2},
+		{name: "shorter than the interval", retention: time.Second, interval: 5 * time.Second, want: 1},
+		{name: "no interval", retention: time.Minute, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WindowFor(tt.retention, tt.interval); got != tt.want {
+				t.Errorf("WindowFor(%v, %v) = %d, want %d", tt.retention, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDefaultPartitionStress pins the stress of critical and low space
+// partitions without -critical-partitions
+func TestDefaultPartitionStress(t *testing.T) {
+	const size = 10 << 30
+
+	tests := []struct {
+		name    string
+		usedPct float64
+		want    map[string]float64 // Stress factor by name
+	}{
+		{
+			name:    "critical",
+			usedPct: 95,
+			want:    map[string]float64{"partition / critical": 30, "partition /boot critical": 25, "partition /data critical": 15},
+		},
+		{
+			name:    "low",
+			usedPct: 85,
+			want:    map[string]float64{"partition / low": 15, "partition /boot low": 10, "partition /data low": 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := filesystem.DefaultThresholds
+			analyzer := New(10)
+			analyzer.SetFilesystemThresholds(thresholds)
+
+			for i := 0; i < 2; i++ {
+				stats := loadSample(5, 0.5)
+				stats.Filesystem = make(map[string]parser.FilesystemStats)
+				for _, mount := range []string{"/", "/boot", "/data"} {
+					stats.Filesystem[mount] = parser.FilesystemStats{
+						Size: size, UsedPct: tt.usedPct, MountPoint: mount,
+						Critical: thresholds.IsCritical(100-tt.usedPct, size),
+					}
+				}
+				analyzer.AddStats(stats)
+			}
+
+			got := make(map[string]float64)
+			for _, factor := range analyzer.Analyze().StressBreakdown {
+				got[factor.Name] = factor.Value
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("stress factor %q = %v, want %v (breakdown %v)", name, got[name], want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package trend
+
+import (
+	"time"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+)
+
+// SetWarmupSuppression sets whether temperature trend anomalies are
+// suppressed while the temperature rises along with the CPU usage, as it
+// does right after a CPU-heavy task starts. Z-score anomalies and absolute
+// threshold breaches are still reported.
+func (t *TrendAnalyzer) SetWarmupSuppression(suppress bool) {
+	t.suppressWarmup = suppress
+}
+
+// cpuWarmup reports whether the temperature of the samples follows the CPU
+// usage
+func cpuWarmup(history []*parser.SystemStats, interval time.Duration) bool {
+	thermal, ok := CorrelateThermal(history, interval)
+	return ok && thermal.FollowsCPU()
+}
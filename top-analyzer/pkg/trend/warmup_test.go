@@ -0,0 +1,52 @@
+package trend
+
+import (
+	"testing"
+
+	"github.com/parth2601/monchecker/top-analyzer/pkg/parser"
+	"github.com/parth2601/monchecker/top-analyzer/pkg/temperature"
+)
+
+func TestWarmupSuppression(t *testing.T) {
+	// The temperature ramps 3°C per sample, staying under the threshold
+	const samples = 10
+	rampingCPU := func(i int) float64 { return 10 + 8*float64(i) }
+	flatCPU := func(i int) float64 { return 50 + float64(i%2)*2 }
+
+	tests := []struct {
+		name        string
+		suppress    bool
+		cpu         func(i int) float64
+		wantWarmup  bool
+		wantAnomaly bool
+	}{
+		{name: "rise following the cpu", suppress: true, cpu: rampingCPU, wantWarmup: true},
+		{name: "rise with a flat cpu", suppress: true, cpu: flatCPU, wantAnomaly: true},
+		{name: "suppression off", cpu: rampingCPU, wantAnomaly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New(samples)
+			analyzer.SetWarmupSuppression(tt.suppress)
+			for i := 0; i < samples; i++ {
+				analyzer.AddStats(&parser.SystemStats{
+					CPU:            parser.CPU{User: tt.cpu(i), Idle: 100 - tt.cpu(i)},
+					ParsedSections: map[parser.Section]bool{parser.SectionCPU: true},
+					Temperature:    temperature.TemperatureStats{Sensors: map[string]float64{"cpu_thermal": 40 + 3*float64(i)}},
+				})
+			}
+			trend := analyzer.Analyze()
+
+			if trend.Temperature.Warmup != tt.wantWarmup {
+				t.Errorf("Warmup = %v, want %v", trend.Temperature.Warmup, tt.wantWarmup)
+			}
+			if trend.Temperature.Anomaly != tt.wantAnomaly {
+				t.Errorf("temperature anomaly = %v, want %v (reasons %v)", trend.Temperature.Anomaly, tt.wantAnomaly, trend.Temperature.Reasons)
+			}
+			if trend.Temperature.ThresholdExceeded {
+				t.Errorf("threshold exceeded, want only a trend")
+			}
+		})
+	}
+}